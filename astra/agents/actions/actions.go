@@ -4,13 +4,17 @@ package actions
 
 import (
 	"astra/astra/agents/configs"
+	"astra/astra/services/knowledge"
+	"astra/astra/services/memory"
 	"astra/astra/sources/psql/dao"
-	"encoding/json"
+	"astra/astra/utils/logging"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"reflect"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -19,7 +23,22 @@ type DataActions struct {
 	actions              map[string]ActionSpec
 	db                   *gorm.DB
 	UserID               int
+	AgentName            string // Agent the actions run for, set from the agent config; matched by policy rules
+	SessionID            string // Chat session of the agent, recorded as knowledge provenance
+	Channel              string // Where the run comes from (ChannelWeb, ChannelCLI, ChannelMCP)
+	Workspace            string // Root directory file and process actions are confined to
 	longTermKnowledgeDao *dao.LongTermKnowledgeDAO
+	knowledge            *knowledge.Service
+	memory               *memory.Service
+	noteDao              *dao.NoteDAO
+	executions           executionRecorder // Nil when there is no database to record to
+	commandPolicy        configs.RunCommandConfig
+	httpPolicy           configs.HTTPRequestConfig
+	dbConfig             configs.DatabaseConfig
+	validation           configs.ValidationConfig
+	approvalGate         ApprovalGate
+	policy               *actionPolicy
+	output               outputState
 }
 
 type ActionSummary struct {
@@ -29,25 +48,41 @@ type ActionSummary struct {
 
 // ActionSpec describes metadata for a registered action.
 type ActionSpec struct {
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Details     string      `json:"details"`
-	Params      interface{} `json:"params"` // Struct type for parameters
-	Fn          interface{} `json:"-"`      // Actual function (not serialized)
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Details     string                 `json:"details"`
+	Params      interface{}            `json:"params"`           // Struct type for parameters
+	Schema      map[string]interface{} `json:"schema,omitempty"` // JSON Schema of Params, generated on register
+	// ContentSource marks output the user and Astra did not write (ContentWeb, ContentMCP,
+	// ContentFile); agents label it as untrusted in prompts.
+	ContentSource string `json:"content_source,omitempty"`
+	// Effects lists what the action can change outside the conversation (EffectWrite,
+	// EffectDelete, EffectExecute, EffectNetwork); such calls need approval once
+	// untrusted content entered the run.
+	Effects []string `json:"effects,omitempty"`
+	Fn      Handler  `json:"-"` // Set by Register (not serialized)
 }
 
 // NewDataActions initializes the DataActions registry.
 func NewDataActions(db *gorm.DB, userId int) *DataActions {
 	longTermKnowledgeDao := dao.NewLongTermKnowledgeDAO(db)
+	workspace, _ := os.Getwd()
 	a := &DataActions{
 		actions:              make(map[string]ActionSpec),
 		db:                   db,
 		UserID:               userId,
+		Workspace:            workspace,
 		longTermKnowledgeDao: longTermKnowledgeDao,
+		knowledge:            knowledge.NewService(longTermKnowledgeDao),
+		noteDao:              dao.NewNoteDAO(db),
+	}
+	if db != nil {
+		a.executions = dao.NewActionExecutionDAO(db)
 	}
 
-	a.register(ActionSpec{
-		Name: "apply_code_edits",
+	Register(a, ActionSpec{
+		Name:    "apply_code_edits",
+		Effects: []string{EffectWrite, EffectDelete},
 		Description: `
 		Applies intelligent, context-aware code modifications to source files
 		within the Astra repository. Supports:
@@ -152,22 +187,140 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 		The Astra Code Editing Engine gives agents the power to modify or regenerate
 		any part of the codebase—safely, predictably, and under precise control.
 	`,
-		Params: ApplyCodeEditsParams{},
-		Fn:     a.applyCodeEdits,
-	})
+	}, a.applyCodeEdits)
 
-	a.register(ActionSpec{
+	Register(a, ActionSpec{
 		Name:        "fetch_file_structure_in_this_repo",
-		Description: "Fetches the file and folder structure of the current repository using the system `tree` command.",
-		Details: `Usage Exampole: {
-				"path": ".",
-				"ignore_dirs": [".git", ".vscode", "logs"]
-			} `,
-		Params: FetchFileStructureParams{},
-		Fn:     a.FetchFileStructureInRepo,
-	})
+		Description: "Lists the file and folder structure of the repository as a tree, respecting .gitignore, with optional depth limit, globs, sizes and line counts. Large directories are summarized.",
+		Details: `
+			# 🌳 Astra Repository Tree Action
 
-	a.register(ActionSpec{
+			Params:
+				- path: string → directory to list, relative to the repo root (default ".")
+				- ignore_dirs: []string → extra names/globs to skip on top of .gitignore, e.g. ["logs", "*.lock"]
+				- max_depth: int → levels to expand; deeper directories are collapsed into a summary (0 = unlimited)
+				- include: []string → only list files matching these globs, e.g. ["*.go"]
+				- exclude: []string → skip files matching these globs, e.g. ["*_test.go"]
+				- show_sizes: bool → show file and directory sizes
+				- show_line_counts: bool → show line counts of text files
+				- summarize_over: int → directories with more direct files than this are summarized (default 50)
+				- format: "text" (default) | "json" | "both"
+
+			Start with a small max_depth for an overview, then list specific directories.
+
+			**Usage Example**
+			{
+				"path": "astra",
+				"max_depth": 2,
+				"show_line_counts": true
+			}
+
+			**Output (text)**
+			astra/  (9120 lines)
+			├── agents/
+			│   ├── actions/  (24 files, mostly .go)
+			│   └── core/  (3 files, mostly .go)
+			└── main.go  (120 lines)
+
+			2 directories, 28 files
+		`,
+	}, a.FetchFileStructureInRepo)
+
+	Register(a, ActionSpec{
+		Name:          "search_code",
+		ContentSource: ContentFile,
+		Description:   "Searches file contents in the repository (literal or regex), respecting .gitignore, and returns matches grouped by file with line numbers.",
+		Details: `
+			# 🔎 Astra Code Search Action
+
+			Use this before read_files_in_this_repo to locate the exact files and lines you need.
+
+			Params:
+				- query: string → literal text (or a Go regexp when regex is true) (required)
+				- regex: bool → treat query as a regular expression (default false)
+				- case_sensitive: bool → default false
+				- path: string → directory to search, relative to the repo root (default ".")
+				- include: []string → globs to search, e.g. ["*.go", "frontend/src/**/*.tsx"]
+				- exclude: []string → globs to skip, e.g. ["*_test.go"]
+				- context_lines: int → lines of context around each match (max 10)
+				- max_results: int → maximum number of matches (default 100)
+
+			**Usage Example**
+			{
+				"query": "func (dao \\*\\w+DAO) Save",
+				"regex": true,
+				"include": ["*.go"],
+				"context_lines": 2,
+				"max_results": 20
+			}
+
+			**Output**
+			{
+				"files": [
+					{ "file": "astra/sources/psql/dao/dao.chat.go", "matches": [ { "line": 25, "text": "...", "context": "..." } ] }
+				],
+				"total_matches": 1,
+				"files_searched": 120,
+				"truncated": false
+			}
+		`,
+	}, a.SearchCode)
+
+	Register(a, ActionSpec{
+		Name:        "list_go_declarations",
+		Description: "Lists the exported and unexported declarations (funcs, methods, types, vars, consts) of Go packages with signatures and file:line locations.",
+		Details: `
+			Params:
+				- package: string → package pattern (default "./...")
+				- exported_only: bool → skip unexported declarations
+
+			**Usage Example**
+			{
+				"package": "./astra/sources/psql/dao",
+				"exported_only": true
+			}
+		`,
+	}, a.ListGoDeclarations)
+
+	Register(a, ActionSpec{
+		Name:        "find_go_definition",
+		Description: "Finds where a Go symbol (func, method, type, field, var, const) is declared, using the type checker rather than text search.",
+		Details: `
+			Symbols may be written as "Name", "Type.Method" or "pkg.Type.Method".
+
+			**Usage Example**
+			{
+				"symbol": "ChatMessageDAO.SaveMessage",
+				"package": "./..."
+			}
+		`,
+	}, a.FindGoDefinition)
+
+	Register(a, ActionSpec{
+		Name:        "find_go_references",
+		Description: "Finds every reference to a Go symbol across the loaded packages (e.g. \"where is SaveMessage called?\") with file:line:column and the source line.",
+		Details: `
+			**Usage Example**
+			{
+				"symbol": "SaveMessage",
+				"package": "./..."
+			}
+		`,
+	}, a.FindGoReferences)
+
+	Register(a, ActionSpec{
+		Name:        "go_type_info",
+		Description: "Shows a Go type's method set (value and pointer receivers) and, for interfaces, every type in the loaded packages that implements it.",
+		Details: `
+			**Usage Example** (what implements LLMClient?)
+			{
+				"symbol": "llm.LLMClient",
+				"package": "./..."
+			}
+		`,
+	}, a.GoTypeInfo)
+
+	Register(a, ActionSpec{
 		Name:        "ask_follow_up_questions_to_user",
 		Description: "This is created to initiate asking questions to user.",
 		Details: `Usage Example: {
@@ -176,26 +329,48 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 					"Q2",
 				]
 			}`,
-		Params: AskFollowUpQuestionsParams{},
-		Fn:     a.AskFollowUpQuestions,
-	})
+	}, a.AskFollowUpQuestions)
 
-	a.register(ActionSpec{
-		Name:        "read_files_in_this_repo",
-		Description: "Reads the content of multiple files from the current repository safely.",
-		Details: `Usage example- {
-				"paths": [
-					"astra/agents/actions/actions.go",
-					"astra/agents/core/agent.go"
-				]
-			}`,
-		Params: ReadFilesParams{},
-		Fn:     a.ReadFilesInRepo,
-	})
+	Register(a, ActionSpec{
+		Name:          "read_files_in_this_repo",
+		ContentSource: ContentFile,
+		Description:   "Reads files from the current repository safely: whole files, line ranges or single Go declarations, with line numbers and a total byte budget.",
+		Details: `
+			# 📖 Astra Read Files Action
 
-	a.register(ActionSpec{
-		Name:        "scrape_urls",
-		Description: "Scrapes given URLs and returns clean readable text content from each page using Playwright browser automation.",
+			Params:
+				- paths: []string → whole files to read
+				- files: []object → per-file reads:
+					- path: string (required)
+					- start_line / end_line: int → 1-based, inclusive line range
+					- symbol: string → Go only, read just one declaration ("NewDataActions", "DataActions.ExecuteAction")
+				- max_bytes: int → total content budget across all files (default 100KB)
+				- raw: bool → return content without line-number prefixes (default false)
+
+			Content is returned as "   12 | code" lines so you can refer to exact line numbers.
+			Strip the prefixes when copying code into apply_code_edits.
+
+			When the budget runs out the result has "truncated": true and "continue_with",
+			which you can pass back unchanged as params to read the rest.
+
+			Prefer search_code or symbol reads over whole files for large sources.
+
+			**Usage Example**
+			{
+				"paths": ["astra/agents/core/agent.go"],
+				"files": [
+					{ "path": "astra/agents/actions/actions.go", "symbol": "DataActions.ExecuteAction" },
+					{ "path": "astra/main.go", "start_line": 20, "end_line": 60 }
+				],
+				"max_bytes": 40000
+			}
+		`,
+	}, a.ReadFilesInRepo)
+
+	Register(a, ActionSpec{
+		Name:          "scrape_urls",
+		ContentSource: ContentWeb,
+		Description:   "Scrapes given URLs and returns clean readable text content from each page using Playwright browser automation.",
 		Details: `
 			# 🌐 Astra Web Scraping Action
 
@@ -209,9 +384,9 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 			## 🧩 Input 
 
 					Input → ScrapeURLsParams
-						- urls: []string → list of URLs to scrape
+						- urls: []string → list of URLs to scrape (required)
+						- word_limit: int → keep at most this many words per page (optional)
 
-					
 
 			---
 
@@ -222,7 +397,7 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 							"https://example.com",
 							"https://wikipedia.org"
 						],
-						"word_limit": 10000,
+						"word_limit": 10000
 					}
 
 			---
@@ -232,13 +407,12 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 					serving as the foundation for knowledge retrieval, summarization, 
 					or data enrichment workflows.
 	`,
-		Params: ScrapeURLsParams{},
-		Fn:     a.ScrapeURLs,
-	})
+	}, a.ScrapeURLs)
 
-	a.register(ActionSpec{
-		Name:        "query_web",
-		Description: "Performs a search query on DuckDuckGo and returns top search results (titles, snippets, and links).",
+	Register(a, ActionSpec{
+		Name:          "query_web",
+		ContentSource: ContentWeb,
+		Description:   "Performs a search query on DuckDuckGo and returns top search results (titles, snippets, and links).",
 		Details: `
 			# 🔍 Astra Web Query Action
 
@@ -251,8 +425,8 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 			## 🧩 Input / Output
 
 					Input → QueryWebParams
-						- queries: []string → search phrases
-						- result_limit: int → number of results per query
+						- queries: []string → search phrases (required)
+						- result_limit: int → number of results per query (default 5)
 
 					Output → QueryWebResult
 						- results: map[string]interface{} → each query key maps to list of:
@@ -275,54 +449,357 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 					This action gives Astra the ability to perform real-time web lookups 
 					and retrieve contextual search snippets for reasoning or LLM grounding.
 	`,
-		Params: QueryWebParams{},
-		Fn:     a.QueryWeb,
-	})
+	}, a.QueryWeb)
 
-	a.register(ActionSpec{
+	Register(a, ActionSpec{
 		Name:        "fmt_vet_build",
-		Description: "Formats (go fmt), vets (go vet), and builds (go build) the entire Go project. Used to validate Astra’s code after edits.",
+		Effects:     []string{EffectExecute, EffectWrite},
+		Description: "Formats (goimports, go fmt), vets (go vet), tidies and builds (go build) the Go project and returns structured diagnostics. Used to validate Astra’s code after edits.",
 		Details: `
 			# 🧹 Astra Code Validation Action
 
 			This action ensures that Astra’s Go codebase is correctly formatted,
 			static analysis passes, and the project compiles without errors.
 
-			- Runs: go fmt ./...
-			- Runs: go vet ./...
-			- Runs: go build ./...
+			Default commands (configurable under "validation" in astra.yaml):
+			- goimports -w ./
+			- go fmt ./...
+			- go vet ./...
+			- go mod tidy
+			- go build ./...
+
+			Every command runs even if an earlier one fails. Commands whose binary is
+			not installed are reported as "skipped" instead of failing the action.
 
 			**Usage Example**
 			{
 				"action": "fmt_vet_build",
 				"action_params": {}
 			}
+
+			**Output**
+			{
+				"success": false,
+				"root": ".",
+				"steps": [
+					{ "command": "goimports -w ./", "tool": "goimports", "status": "skipped", "reason": "goimports not found in PATH" },
+					{ "command": "go vet ./...", "tool": "go vet", "status": "failed", "exit_code": 1 }
+				],
+				"diagnostics": [
+					{ "tool": "go vet", "file": "astra/main.go", "line": 12, "column": 5, "severity": "error", "message": "undefined: foo" }
+				],
+				"error_count": 1,
+				"warning_count": 0
+			}
 		`,
-		Params: struct{}{}, // no params needed
-		Fn:     a.FmtVetBuild,
-	})
+	}, a.FmtVetBuild)
 
-	a.register(ActionSpec{
+	Register(a, ActionSpec{
+		Name:        "run_go_tests",
+		Effects:     []string{EffectExecute},
+		Description: "Runs go test -json for the chosen packages (optionally filtered with -run) and returns per-test pass/fail/skip results with durations, failure output and optional coverage.",
+		Details: `
+			# 🧪 Astra Go Test Runner Action
+
+			Runs "go test -json" in the repository and parses the event stream, so you get
+			structured results instead of raw test output. Failing tests always include their
+			output; passing tests are only listed when include_passed is true.
+
+			Params:
+				- packages: []string → package patterns relative to the repo root (default ["./..."])
+				- run: string → -run regexp, e.g. "TestSearchCode|TestGlob"
+				- coverage: bool → add -cover and report coverage per package
+				- include_passed: bool → list passing and skipped tests too (default false)
+				- timeout_seconds: int → overall timeout (default 600)
+
+			**Usage Example**
+			{
+				"packages": ["./astra/agents/actions/..."],
+				"run": "TestSearchCode",
+				"coverage": true
+			}
+
+			**Output**
+			{
+				"success": false,
+				"passed": 12, "failed": 1, "skipped": 0,
+				"packages": [
+					{ "package": "astra/astra/agents/actions", "status": "fail", "duration_seconds": 1.2, "passed": 12, "failed": 1, "coverage_percent": 41.5 }
+				],
+				"tests": [
+					{ "package": "astra/astra/agents/actions", "name": "TestSearchCode", "status": "fail", "duration_seconds": 0.01, "output": "search_test.go:47: expected ..." }
+				]
+			}
+
+			A package that fails to build has status "fail" with the compiler errors in its "output".
+		`,
+	}, a.RunGoTests)
+
+	Register(a, ActionSpec{
 		Name:        "frontend_build",
-		Description: "use npm run build to build and check for errors in frontend code",
+		Effects:     []string{EffectExecute},
+		Description: "Builds the frontend (npm run build, i.e. tsc + vite) and returns structured tsc/eslint diagnostics.",
 		Details: `
+			Runs the configured frontend commands (default: npm run build in frontend/)
+			and parses tsc and eslint output into diagnostics with the same shape as
+			fmt_vet_build: tool, file, line, column, severity and message.
+
 			**Usage Example**
 			{
 				"action": "frontend_build",
 				"action_params": {}
 			}
 		`,
-		Params: struct{}{}, // no params needed
-		Fn:     a.FrontendBuild,
-	})
+	}, a.FrontendBuild)
 
-	a.register(ActionSpec{
+	Register(a, ActionSpec{
+		Name:        "project_profile",
+		Description: "Detects the project's languages (go.mod, package.json, pyproject.toml, Cargo.toml, Makefile) in the workspace and its top-level directories and lists their build, test, lint and format commands.",
+		Details: `
+			Shows the commands build_project, test_project and lint_project will run.
+
+			- Manifests are looked up in the workspace root and its direct subdirectories.
+			- Makefile targets (build, test, lint/vet, fmt/format) replace the commands of
+			  the other languages in the same root.
+			- A checked-in .astra.yaml can override or add commands per language and root:
+
+				project:
+				  languages:
+				    - name: go
+				      root: .
+				      lint: [["golangci-lint", "run"]]
+				    - name: python
+				      root: tools
+				      disabled: true
+
+			Phases listed in "configured" come from .astra.yaml; when those commands are
+			not covered by the run_command allowlist, running them needs user approval.
+
+			**Usage Example**
+			{
+				"action": "project_profile",
+				"action_params": {}
+			}
+
+			**Output**
+			{
+				"languages": [
+					{ "name": "go", "root": ".", "manifest": "go.mod", "build": [["go", "build", "./..."]], "test": [["go", "test", "./..."]], "lint": [["go", "vet", "./..."]], "format": [["gofmt", "-l", "-w", "."]] },
+					{ "name": "node", "root": "frontend", "manifest": "package.json", "build": [["npm", "run", "build"]], "lint": [["npm", "run", "lint"]] }
+				]
+			}
+		`,
+	}, a.ProjectProfile)
+
+	Register(a, ActionSpec{
+		Name:        "build_project",
+		Effects:     []string{EffectExecute},
+		Description: "Builds every detected language of the project (see project_profile), optionally limited to one root or language, and returns structured diagnostics per language.",
+		Details: `
+			Runs the build commands of each language in its root. Each run has the same
+			shape as fmt_vet_build (steps, diagnostics, counts) plus the language name.
+
+			**Usage Example**
+			{
+				"action": "build_project",
+				"action_params": { "root": "frontend" }
+			}
+
+			**Output**
+			{
+				"phase": "build",
+				"success": true,
+				"runs": [
+					{ "language": "node", "success": true, "root": "frontend", "steps": [...], "diagnostics": [], "error_count": 0, "warning_count": 0 }
+				]
+			}
+		`,
+	}, a.BuildProject)
+
+	Register(a, ActionSpec{
+		Name:        "test_project",
+		Effects:     []string{EffectExecute},
+		Description: "Runs the tests of every detected language of the project (see project_profile), optionally limited to one root or language.",
+		Details: `
+			Runs the test commands of each language in its root and returns one run per
+			language, shaped like build_project. For per-test Go results use run_go_tests.
+
+			**Usage Example**
+			{
+				"action": "test_project",
+				"action_params": { "language": "python" }
+			}
+		`,
+	}, a.TestProject)
+
+	Register(a, ActionSpec{
+		Name:        "lint_project",
+		Effects:     []string{EffectExecute},
+		Description: "Lints every detected language of the project (see project_profile), optionally running the format commands first.",
+		Details: `
+			Runs the lint commands of each language in its root and returns one run per
+			language, shaped like build_project. With "format": true the format commands
+			(gofmt, prettier scripts, ruff format, cargo fmt, ...) run first and rewrite files.
+
+			**Usage Example**
+			{
+				"action": "lint_project",
+				"action_params": { "format": true }
+			}
+		`,
+	}, a.LintProject)
+
+	Register(a, ActionSpec{
+		Name:        "scaffold_resource",
+		Effects:     []string{EffectWrite},
+		Description: "Generates a new backend resource across all layers (model, DAO, controller, routes), mounts its routes in main.go, adds it to AutoMigrate and returns the diff.",
+		Details: `
+			# 🏗️ Astra Resource Scaffolding Action
+
+			Creates, for a resource "project_task":
+			- sources/psql/models/project_task.go   → ProjectTask with uuid ID, user_id, fields, timestamps
+			- sources/psql/dao/dao.project_task.go  → ProjectTaskDAO (create, get, list, update, delete)
+			- controllers/project_task.go           → ProjectTaskController with ownership checks
+			- routes/project_task.go                → ProjectTaskRoutes: POST /, GET /, GET /{id}, PUT /{id}, DELETE /{id}
+			and wires the DAO, controller and r.Mount("/project_tasks", ...) into main.go and
+			&models.ProjectTask{} into AutoMigrate in sources/psql/database.go.
+
+			- Use "dry_run": true to review the diff before anything is written.
+			- Existing files are never overwritten. Wiring that cannot be placed automatically
+			  is reported in "warnings" and has to be done by hand.
+			- Field types: string, text, int, float, bool, time, uuid.
+			- Projects can replace any of model.go.tmpl, dao.go.tmpl, controller.go.tmpl and
+			  route.go.tmpl in .astra/templates/scaffold (or scaffold.templates_dir in .astra.yaml).
+			- Run build_project afterwards and adjust the generated code as needed.
+
+			**Usage Example**
+			{
+				"action": "scaffold_resource",
+				"action_params": {
+					"name": "project_task",
+					"fields": [
+						{ "name": "title", "type": "string", "required": true },
+						{ "name": "due_date", "type": "time" },
+						{ "name": "done", "type": "bool" }
+					],
+					"dry_run": true
+				}
+			}
+
+			**Output**
+			{ "created": ["astra/sources/psql/models/project_task.go", ...], "modified": ["astra/main.go", "astra/sources/psql/database.go"], "diff": "--- /dev/null\n+++ b/astra/...", "dry_run": true }
+		`,
+	}, a.ScaffoldResource)
+
+	Register(a, ActionSpec{
+		Name:        "run_command",
+		Effects:     []string{EffectExecute},
+		Description: "Runs an allowlisted command (no shell) inside the workspace with a timeout and returns exit code, stdout and stderr separately.",
+		Details: `
+			# 🛡️ Astra Sandboxed Command Action
+
+			Runs a single binary with explicit arguments. No shell is involved, so pipes,
+			redirects, globs and "&&" are not interpreted.
+
+			- The binary and every argument must match the agent's run_command allowlist.
+			- Anything outside the allowlist is sent to the user for approval; if the
+			  user declines, the result contains an "error" and exit_code -1.
+			- The working directory is confined to the workspace.
+			- Secrets are stripped from the environment.
+			- Output is capped; "truncated" is set when output was cut.
+
+			**Usage Example**
+			{
+				"action": "run_command",
+				"action_params": {
+					"command": "go",
+					"args": ["test", "./astra/agents/..."],
+					"dir": ".",
+					"timeout_seconds": 120
+				}
+			}
+
+			**Output**
+			{ "command": "...", "exit_code": 0, "stdout": "...", "stderr": "...", "timed_out": false }
+		`,
+	}, a.RunCommand)
+
+	Register(a, ActionSpec{
+		Name:          "http_request",
+		Effects:       []string{EffectNetwork},
+		ContentSource: ContentWeb,
+		Description:   "Calls an HTTP/JSON API (method, url, headers, body) on an allowlisted host, e.g. to test endpoints of the local backend. Auth headers can use configured secrets by name.",
+		Details:       httpRequestDetails + a.describeHTTPPolicy(),
+	}, a.HTTPRequest)
+
+	Register(a, ActionSpec{
+		Name:        "db_list_tables",
+		Description: "Lists the tables and views of a schema in the project's Postgres database, with estimated row counts.",
+		Details: `
+			# 🗄️ Astra Database Tables Action
+
+			Read-only. Use before designing migrations, models or DAO code to see what exists.
+
+			**Usage Example**
+			{ "action": "db_list_tables", "action_params": { "schema": "public" } }
+
+			**Output**
+			{ "schema": "public", "tables": [ { "name": "notes", "type": "table", "estimated_rows": 120 } ] }
+		`,
+	}, a.DBListTables)
+
+	Register(a, ActionSpec{
+		Name:        "db_describe_table",
+		Description: "Describes a Postgres table: columns (type, nullability, default), indexes, foreign keys and the tables referencing it.",
+		Details: `
+			# 🗄️ Astra Database Table Description Action
+
+			Read-only. Prefer this over guessing the schema from GORM models.
+
+			**Usage Example**
+			{ "action": "db_describe_table", "action_params": { "table": "notes" } }
+
+			**Output**
+			{
+				"schema": "public", "table": "notes",
+				"columns": [ { "name": "id", "type": "uuid", "nullable": false, "default": "uuid_generate_v4()" } ],
+				"indexes": [ { "name": "notes_pkey", "definition": "CREATE UNIQUE INDEX ...", "unique": true, "primary": true } ],
+				"foreign_keys": [ { "name": "fk_notes_user", "table": "notes", "definition": "FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE" } ],
+				"referenced_by": []
+			}
+		`,
+	}, a.DBDescribeTable)
+
+	Register(a, ActionSpec{
+		Name:        "db_query",
+		Effects:     []string{EffectExecute},
+		Description: "Runs one read-only SQL statement (SELECT/WITH/EXPLAIN/SHOW) against the project's Postgres database and returns the rows.",
+		Details: `
+			# 🗄️ Astra Read-Only Query Action
+
+			- Runs inside a READ ONLY transaction that is always rolled back; writes fail.
+			- A statement timeout and a row limit apply; "truncated" is set when rows were cut.
+			- Only a single statement is accepted.
+
+			**Usage Example**
+			{
+				"action": "db_query",
+				"action_params": {
+					"sql": "SELECT status, count(*) FROM orders GROUP BY 1",
+					"max_rows": 50
+				}
+			}
+
+			**Output**
+			{ "columns": ["status", "count"], "rows": [["shipped", 12]], "row_count": 1, "duration_ms": 3 }
+		`,
+	}, a.DBQuery)
+
+	Register(a, ActionSpec{
 		Name:        "pwd",
 		Description: "Fetch current working directory",
 		Details:     ``,
-		Params:      struct{}{},
-		Fn:          a.GetPWD,
-	})
+	}, a.GetPWD)
 
 	a.register(ActionSpec{
 		Name:        "think_aloud_reasoning",
@@ -382,43 +859,99 @@ func NewDataActions(db *gorm.DB, userId int) *DataActions {
 	}
 
 	// Registration data pairing key, params, and function
-	var longTermKnowledgeRegistrations = []struct {
-		key    string
-		params interface{}
-		fn     interface{}
-	}{
-		{"create_long_term_knowledge", CreateLongTermKnowledgeParams{}, a.CreateLongTermKnowledgeAction},
-		{"fetch_knowledge_types", struct{}{}, a.GetAllKnowledgeTypesForUser},
-		{"get_all_long_term_knowledge_for_user", struct{}{}, a.GetAllLongTermKnowledgeForUserAction},
-		{"get_all_long_term_knowledge_for_user_by_type", GetAllLongTermKnowledgeByTypeParams{}, a.GetAllLongTermKnowledgeForUserByTypeAction},
+	var longTermKnowledgeRegistrations = []yamlActionRegistration{
+		yamlAction("create_long_term_knowledge", a.CreateLongTermKnowledgeAction),
+		yamlAction("update_learning_knowledge", a.UpdateLongTermKnowledgeAction),
+		yamlAction("delete_long_term_knowledge", a.DeleteLongTermKnowledgeAction),
+		yamlAction("semantic_search_memory", a.SemanticSearchMemoryAction),
+		yamlAction("fetch_knowledge_types", a.GetAllKnowledgeTypesForUser),
+		yamlAction("get_all_long_term_knowledge_for_user", a.GetAllLongTermKnowledgeForUserAction),
+		yamlAction("get_all_long_term_knowledge_for_user_by_type", a.GetAllLongTermKnowledgeForUserByTypeAction),
 	}
-	for _, reg := range longTermKnowledgeRegistrations {
-		yamlCfg, ok := learningActionsYAML[reg.key]
+	a.registerYAMLBacked(learningActionsYAML, longTermKnowledgeRegistrations)
+
+	noteActionsYAML, err := configs.LoadActionsYAMLInDir(filepath.Join(filepath.Dir(yamlBase), "notes"))
+	if err != nil {
+		panic("Failed to load note actions YAML configs: " + err.Error())
+	}
+	a.registerYAMLBacked(noteActionsYAML, a.noteRegistrations())
+	// --- End YAML-driven learning actions registration ---
+
+	// Declarative actions (params + command/http executor) need no Go code.
+	customYAMLDir := filepath.Join(filepath.Dir(yamlBase), "custom")
+	if names, err := a.LoadDeclarativeActions(customYAMLDir); err != nil {
+		logging.ErrorLogger.Error("Failed to load declarative actions", zap.Error(err))
+	} else if len(names) > 0 {
+		logging.AppLogger.Info("Loaded declarative actions", zap.Strings("actions", names))
+	}
+
+	return a
+}
+
+// yamlActionRegistration pairs a Go-backed action with the YAML file holding its docs.
+type yamlActionRegistration struct {
+	key      string
+	register func(a *DataActions, spec ActionSpec)
+}
+
+// yamlAction binds fn to the docs under key of the action YAML.
+func yamlAction[P, R any](key string, fn func(context.Context, P) (R, error)) yamlActionRegistration {
+	return yamlActionRegistration{key: key, register: func(a *DataActions, spec ActionSpec) { Register(a, spec, fn) }}
+}
+
+// registerYAMLBacked registers actions whose name, description, details and effects come from YAML.
+func (a *DataActions) registerYAMLBacked(yamlCfgs map[string]*configs.ActionYAMLConfig, regs []yamlActionRegistration) {
+	for _, reg := range regs {
+		yamlCfg, ok := yamlCfgs[reg.key]
 		if !ok {
 			panic("Missing YAML: " + reg.key)
 		}
-		a.register(ActionSpec{
+		reg.register(a, ActionSpec{
 			Name:        yamlCfg.Name,
 			Description: yamlCfg.Description,
 			Details:     yamlCfg.Details,
-			Params:      reg.params,
-			Fn:          reg.fn,
+			Effects:     yamlCfg.Effects,
 		})
 	}
-	// --- End YAML-driven learning actions registration ---
-
-	return a
 }
 
-// register adds an action spec to the registry.
-func (a *DataActions) register(spec ActionSpec) {
-	a.actions[spec.Name] = spec
+// ApplyAgentConfig applies the agent name, command, validation, HTTP, database and policy
+// settings of cfg and loads its custom action dirs (relative dirs resolve against the workspace). It
+// returns the declarative actions that were loaded; an invalid policy and dirs that
+// failed are reported in the joined error.
+func (a *DataActions) ApplyAgentConfig(cfg *configs.AgentConfig) ([]string, error) {
+	a.AgentName = cfg.Name()
+	a.SetCommandPolicy(cfg.RunCommand)
+	a.SetValidationConfig(cfg.Validation)
+	a.SetHTTPRequestPolicy(cfg.HTTPRequest)
+	a.SetDatabaseConfig(cfg.Database)
+	var loaded []string
+	var errs []error
+	if err := a.SetPolicy(cfg.Policy); err != nil {
+		errs = append(errs, fmt.Errorf("policy: %w", err))
+	}
+	for _, dir := range cfg.CustomActionDirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(a.Workspace, dir)
+		}
+		// The agent can write inside the workspace, so actions from there need approval.
+		names, err := a.loadDeclarativeActions(dir, a.withinWorkspace(dir))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dir, err))
+		}
+		loaded = append(loaded, names...)
+	}
+	return loaded, errors.Join(errs...)
 }
 
-// ListActions returns all registered action metadata (excluding function pointers).
+// ListActions returns the metadata (excluding function pointers) of all registered actions
+// the policy does not forbid.
 func (a *DataActions) ListActions() []ActionSpec {
 	specs := make([]ActionSpec, 0, len(a.actions))
 	for _, spec := range a.actions {
+		if a.policyHides(spec.Name) {
+			continue
+		}
 		specs = append(specs, spec)
 	}
 	return specs
@@ -433,6 +966,9 @@ func (a *DataActions) GetAction(name string) (ActionSpec, bool) {
 func (a *DataActions) ListActionSummaries() []ActionSummary {
 	summaries := make([]ActionSummary, 0, len(a.actions))
 	for _, spec := range a.actions {
+		if a.policyHides(spec.Name) {
+			continue
+		}
 		summaries = append(summaries, ActionSummary{
 			Name:        spec.Name,
 			Description: spec.Description,
@@ -440,94 +976,3 @@ func (a *DataActions) ListActionSummaries() []ActionSummary {
 	}
 	return summaries
 }
-
-// ExecuteAction executes a registered action by name using the provided params (map).
-// It returns the action's result as a map[string]interface{} or an error.
-func (a *DataActions) ExecuteAction(name string, rawParams map[string]interface{}) (map[string]interface{}, error) {
-	spec, ok := a.actions[name]
-	if !ok {
-		return nil, fmt.Errorf("action not found: %s", name)
-	}
-	if spec.Fn == nil {
-		return nil, fmt.Errorf("no function registered for action: %s", name)
-	}
-
-	fnVal := reflect.ValueOf(spec.Fn)
-	fnType := fnVal.Type()
-
-	// Currently we only support functions with exactly 1 input parameter.
-	numIn := fnType.NumIn()
-	var argVal reflect.Value
-	if numIn == 0 {
-		// no input; nothing to prepare
-	} else if numIn == 1 {
-		inType := fnType.In(0)
-
-		// Create a pointer to the expected input type so we can json.Unmarshal into it
-		inPtr := reflect.New(inType)
-		// But json.Unmarshal expects a pointer to a concrete type. We'll marshal rawParams and unmarshal.
-		paramBytes, err := json.Marshal(rawParams)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal params: %w", err)
-		}
-		if err := json.Unmarshal(paramBytes, inPtr.Interface()); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal params into %s: %w", inType.String(), err)
-		}
-
-		// If function expects a non-pointer (value), pass Elem(); else pass pointer
-		if inType.Kind() == reflect.Ptr {
-			argVal = inPtr
-		} else {
-			argVal = inPtr.Elem()
-		}
-	} else {
-		return nil, fmt.Errorf("action function has unsupported number of input parameters: %d", numIn)
-	}
-
-	// Build arguments slice
-	var args []reflect.Value
-	if numIn == 1 {
-		args = []reflect.Value{argVal}
-	} else {
-		args = []reflect.Value{}
-	}
-
-	// Call the function
-	outVals := fnVal.Call(args)
-
-	// Handle outputs:
-	// supported forms:
-	//  - (ResultStruct)
-	//  - (ResultStruct, error)
-	if len(outVals) == 0 {
-		return nil, nil
-	}
-
-	// If second return is error, check it
-	if len(outVals) == 2 {
-		// second value should be error or nil
-		errInterface := outVals[1].Interface()
-		if errInterface != nil {
-			if errObj, ok := errInterface.(error); ok {
-				return nil, errObj
-			}
-			// not standard error type, format it
-			return nil, fmt.Errorf("action returned non-nil second value: %v", errInterface)
-		}
-		// proceed to convert first output to map
-	}
-
-	// Convert first return value to map[string]interface{}
-	first := outVals[0].Interface()
-	// marshal then unmarshal into map for simplicity
-	b, err := json.Marshal(first)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal action result: %w", err)
-	}
-	var result map[string]interface{}
-	if err := json.Unmarshal(b, &result); err != nil {
-		// If result isn't a JSON object (could be a primitive), wrap it
-		return map[string]interface{}{"result": first}, nil
-	}
-	return result, nil
-}
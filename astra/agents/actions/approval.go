@@ -0,0 +1,31 @@
+package actions
+
+import "context"
+
+// ApprovalRequest describes an action call that needs an explicit user decision
+// before it is allowed to run.
+type ApprovalRequest struct {
+	Action string                 `json:"action"`
+	Reason string                 `json:"reason"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// ApprovalGate asks the user whether a risky action call may proceed.
+// It returns true only if the user explicitly approved the request.
+type ApprovalGate func(req ApprovalRequest) bool
+
+// SetApprovalGate installs the gate used for calls that fall outside the agent's policy.
+// Without a gate every such call is denied.
+func (a *DataActions) SetApprovalGate(gate ApprovalGate) {
+	a.approvalGate = gate
+}
+
+// requestApproval routes a request through the approval gate, denying when none is set.
+// The decision is noted on the execution record of the call ctx belongs to.
+func (a *DataActions) requestApproval(ctx context.Context, req ApprovalRequest) bool {
+	approved := a.approvalGate != nil && a.approvalGate(req)
+	if trace := executionTraceFromContext(ctx); trace != nil {
+		trace.noteApproval(approved, a.approverName())
+	}
+	return approved
+}
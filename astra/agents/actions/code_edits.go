@@ -3,11 +3,9 @@ package actions
 
 import (
 	"astra/astra/utils/logging"
-	"bytes"
+	"context"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -16,20 +14,20 @@ import (
 
 // CodeEdit represents a single code modification operation.
 type CodeEdit struct {
-	Type          string `json:"type"`           // "create_file", "delete_file", "update_file"
-	File          string `json:"file"`           // Absolute or relative file path
-	Target        string `json:"target"`         // Target line or block (optional)
-	Start         string `json:"start"`          // Start of block (optional)
-	End           string `json:"end"`            // End of block (optional)
-	Replacement   string `json:"replacement"`    // Replacement text
-	Content       string `json:"content"`        // Insert/create content
-	Position      string `json:"position"`       // "before" or "after" (default "after")
-	ContextBefore string `json:"context_before"` // Context before target (optional)
-	ContextAfter  string `json:"context_after"`  // Context after target (optional)
+	Type          string `json:"type" required:"true" enum:"create_file,delete_file,update_file_content" desc:"Kind of edit"`
+	File          string `json:"file" required:"true" desc:"File path, relative to the workspace"`
+	Target        string `json:"target" desc:"Target line or block"`
+	Start         string `json:"start" desc:"Start of block"`
+	End           string `json:"end" desc:"End of block"`
+	Replacement   string `json:"replacement" desc:"New file contents for update_file_content"`
+	Content       string `json:"content" desc:"File contents for create_file"`
+	Position      string `json:"position" desc:"Insert before or after the target (default after)"`
+	ContextBefore string `json:"context_before" desc:"Context before target"`
+	ContextAfter  string `json:"context_after" desc:"Context after target"`
 }
 
 type ApplyCodeEditsParams struct {
-	Edits []CodeEdit `json:"edits"`
+	Edits []CodeEdit `json:"edits" required:"true" desc:"Edits to apply, in order"`
 }
 
 type ApplyCodeEditsResult struct {
@@ -39,34 +37,25 @@ type ApplyCodeEditsResult struct {
 }
 
 // applyCodeEdits applies a batch of file modifications (insert, replace, create, delete).
-func (a *DataActions) applyCodeEdits(params ApplyCodeEditsParams) ApplyCodeEditsResult {
+func (a *DataActions) applyCodeEdits(ctx context.Context, params ApplyCodeEditsParams) (ApplyCodeEditsResult, error) {
 	edits := params.Edits
 	if len(edits) == 0 {
-		return ApplyCodeEditsResult{Error: "edits list must not be empty"}
+		return ApplyCodeEditsResult{Error: "edits list must not be empty"}, nil
 	}
 
 	for i, edit := range edits {
 		if strings.TrimSpace(edit.File) == "" {
 			return ApplyCodeEditsResult{
 				Error: fmt.Sprintf("edit[%d] is missing required 'file' field", i),
-			}
+			}, nil
 		}
-		absFile, err := filepath.Abs(edit.File)
+		// Paths are relative to the session workspace, which may be an isolated worktree.
+		absFile, err := a.resolveInWorkspace(edit.File)
 		if err != nil {
 			return ApplyCodeEditsResult{
-				Error: fmt.Sprintf("failed to resolve absolute path for file %s: %v", edit.File, err),
-			}
-		}
-		edits[i].File = absFile
-
-		fmt.Println("applyCodeEdits basepath for edit 4", absFile, err)
-
-		if err != nil {
-			return ApplyCodeEditsResult{
-				Error: fmt.Sprintf("failed to resolve absolute path for file %s: %v", edit.File, err),
-			}
+				Error: fmt.Sprintf("failed to resolve path for file %s: %v", edit.File, err),
+			}, nil
 		}
-
 		edits[i].File = absFile
 
 	}
@@ -80,19 +69,19 @@ func (a *DataActions) applyCodeEdits(params ApplyCodeEditsParams) ApplyCodeEdits
 		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
 			return ApplyCodeEditsResult{
 				Error: fmt.Sprintf("failed to create directory for file %s: %v", file, err),
-			}
+			}, nil
 		}
 	}
 
 	applied := 0
 	for file, fileEdits := range editsByFile {
 		if err := a.applyEditsToFile(file, fileEdits); err != nil {
-			return ApplyCodeEditsResult{Error: err.Error()}
+			return ApplyCodeEditsResult{Error: err.Error()}, nil
 		}
 		applied += len(fileEdits)
 	}
 
-	return ApplyCodeEditsResult{Success: true, EditsApplied: applied}
+	return ApplyCodeEditsResult{Success: true, EditsApplied: applied}, nil
 }
 
 // applyEditsToFile applies multiple edits to a single file safely.
@@ -404,74 +393,3 @@ func (a *DataActions) rollbackFiles(editsByFile map[string][]CodeEdit) {
 		}
 	}
 }
-
-func (a *DataActions) FmtVetBuild() (map[string]interface{}, error) {
-	cmds := [][]string{
-		{"goimports", "-w", "./"},
-		{"go", "fmt", "./..."},
-		{"go", "vet", "./..."},
-		{"go", "mod", "tidy"},
-		{"go", "build", "./..."},
-	}
-
-	var outputs []string
-
-	for _, cmdArgs := range cmds {
-		cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-		cmd.Dir = "."
-
-		out, err := cmd.CombinedOutput()
-		output := fmt.Sprintf("🔹 Running: %s\n", strings.Join(cmdArgs, " "))
-		if len(out) > 0 {
-			output += fmt.Sprintf("Output:\n%s\n", string(out))
-		} else {
-			output += "(no output)\n"
-		}
-
-		if err != nil {
-			output += fmt.Sprintf("❌ Command failed: %v\n", err)
-			outputs = append(outputs, output)
-			return map[string]interface{}{
-				"success": false,
-				"output":  strings.Join(outputs, "\n"),
-			}, err
-		}
-
-		output += "✅ Command succeeded.\n"
-		outputs = append(outputs, output)
-	}
-
-	outputs = append(outputs, "✅ All Go code formatted, vetted, and compiled successfully.")
-
-	return map[string]interface{}{
-		"success": true,
-		"output":  strings.Join(outputs, "\n"),
-	}, nil
-}
-
-func (a *DataActions) FrontendBuild() (map[string]interface{}, error) {
-	cmd := exec.Command("npm", "run", "build")
-	cmd.Dir = "." // project root
-
-	var outputBuf bytes.Buffer
-	cmd.Stdout = io.MultiWriter(os.Stdout, &outputBuf)
-	cmd.Stderr = io.MultiWriter(os.Stderr, &outputBuf)
-
-	fmt.Println("🚀 Starting frontend build...")
-
-	err := cmd.Run()
-
-	result := map[string]interface{}{
-		"output": outputBuf.String(),
-	}
-
-	if err != nil {
-		fmt.Printf("❌ Build failed: %v\n", err)
-		result["success"] = false
-		return result, err
-	}
-
-	fmt.Println("✅ Frontend build completed successfully.")
-	result["success"] = true
-	return result, nil
-}
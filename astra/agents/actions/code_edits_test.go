@@ -1,9 +1,11 @@
 package actions
 
 import (
+	"astra/astra/utils/logging"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -520,3 +522,26 @@ func TestApplyCodeEdits_ReplaceInsideFunction_GetUserByID2(t *testing.T) {
 	// 	t.Errorf("replacement wrongly affected GetUserByID")
 	// }
 }
+
+func TestApplyCodeEdits_ResolvesAgainstWorkspace(t *testing.T) {
+	logging.InitLogger()
+	root := t.TempDir()
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+
+	res, _ := a.applyCodeEdits(t.Context(), ApplyCodeEditsParams{Edits: []CodeEdit{
+		{Type: "create_file", File: "pkg/new.go", Content: "package pkg\n"},
+	}})
+	if !res.Success {
+		t.Fatalf("edit failed: %+v", res)
+	}
+	if got := readFile(filepath.Join(root, "pkg", "new.go")); got != "package pkg\n" {
+		t.Errorf("file not created in the workspace: %q", got)
+	}
+
+	res, _ = a.applyCodeEdits(t.Context(), ApplyCodeEditsParams{Edits: []CodeEdit{
+		{Type: "create_file", File: "../escape.go", Content: "package x\n"},
+	}})
+	if res.Success || !strings.Contains(res.Error, "outside the workspace") {
+		t.Errorf("expected an edit outside the workspace to be rejected, got %+v", res)
+	}
+}
@@ -0,0 +1,409 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDBQueryRows        = 100
+	defaultDBMaxRows          = 500
+	defaultDBStatementTimeout = 10 * time.Second
+	defaultDBSchema           = "public"
+)
+
+// readOnlyStatementKeywords are the statements db_query accepts. Writes would fail in
+// the READ ONLY transaction anyway; this gives the agent a clear error up front.
+var readOnlyStatementKeywords = []string{"select", "with", "explain", "show", "values", "table"}
+
+// targetDBPool keeps one connection pool per configured DSN for the whole process.
+var targetDBPool = struct {
+	sync.Mutex
+	dbs map[string]*gorm.DB
+}{dbs: make(map[string]*gorm.DB)}
+
+type DBListTablesParams struct {
+	Schema string `json:"schema,omitempty" default:"public" desc:"Postgres schema"`
+}
+
+type DBDescribeTableParams struct {
+	Table  string `json:"table" required:"true" desc:"Table or view name"`
+	Schema string `json:"schema,omitempty" default:"public" desc:"Postgres schema"`
+}
+
+type DBQueryParams struct {
+	SQL            string `json:"sql" required:"true" desc:"A single read-only statement (SELECT, WITH, EXPLAIN, SHOW, VALUES, TABLE)"`
+	MaxRows        int    `json:"max_rows,omitempty" default:"100" desc:"Maximum number of rows to return, capped by the agent's configured limit"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" desc:"Statement timeout in seconds, capped by the agent's configured timeout"`
+}
+
+type DBTable struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"` // table, view or materialized_view
+	EstimatedRows int64  `json:"estimated_rows"`
+	Comment       string `json:"comment,omitempty"`
+}
+
+type DBTablesResult struct {
+	Schema string    `json:"schema"`
+	Tables []DBTable `json:"tables"`
+}
+
+type DBColumn struct {
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	Nullable     bool    `json:"nullable"`
+	DefaultValue *string `json:"default,omitempty"`
+	Comment      string  `json:"comment,omitempty"`
+}
+
+type DBIndex struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+	IsUnique   bool   `json:"unique"`
+	IsPrimary  bool   `json:"primary"`
+}
+
+type DBForeignKey struct {
+	Name       string `json:"name"`
+	Table      string `json:"table"` // The referencing table
+	Definition string `json:"definition"`
+}
+
+type DBTableDescription struct {
+	Schema       string         `json:"schema"`
+	Table        string         `json:"table"`
+	Columns      []DBColumn     `json:"columns"`
+	Indexes      []DBIndex      `json:"indexes"`
+	ForeignKeys  []DBForeignKey `json:"foreign_keys"`
+	ReferencedBy []DBForeignKey `json:"referenced_by"`
+}
+
+type DBQueryResult struct {
+	Columns    []string        `json:"columns"`
+	Rows       [][]interface{} `json:"rows"`
+	RowCount   int             `json:"row_count"`
+	Truncated  bool            `json:"truncated,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+}
+
+// databaseActions need a configured DSN; they never fall back to Astra's own database.
+var databaseActions = []string{"db_list_tables", "db_describe_table", "db_query"}
+
+// SetDatabaseConfig applies the agent's database configuration. Without a DSN the
+// database actions are removed so the planner does not see them.
+func (a *DataActions) SetDatabaseConfig(cfg configs.DatabaseConfig) {
+	a.dbConfig = cfg
+	if os.ExpandEnv(cfg.DSN) == "" {
+		for _, name := range databaseActions {
+			delete(a.actions, name)
+		}
+	}
+}
+
+func (a *DataActions) DBListTables(ctx context.Context, p DBListTablesParams) (DBTablesResult, error) {
+	schema := schemaOrDefault(p.Schema)
+	res := DBTablesResult{Schema: schema, Tables: []DBTable{}}
+	err := a.readOnly(ctx, 0, func(tx *gorm.DB) error {
+		return tx.Raw(`
+			SELECT c.relname AS name,
+			       CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized_view' ELSE 'table' END AS type,
+			       GREATEST(c.reltuples, 0)::bigint AS estimated_rows,
+			       COALESCE(obj_description(c.oid, 'pg_class'), '') AS comment
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = ? AND c.relkind IN ('r', 'p', 'v', 'm')
+			ORDER BY c.relname`, schema).Scan(&res.Tables).Error
+	})
+	return res, err
+}
+
+func (a *DataActions) DBDescribeTable(ctx context.Context, p DBDescribeTableParams) (DBTableDescription, error) {
+	schema := schemaOrDefault(p.Schema)
+	res := DBTableDescription{Schema: schema, Table: p.Table}
+	err := a.readOnly(ctx, 0, func(tx *gorm.DB) error {
+		var oid int64
+		err := tx.Raw(`
+			SELECT c.oid::bigint FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = ? AND c.relname = ? AND c.relkind IN ('r', 'p', 'v', 'm')`, schema, p.Table).Scan(&oid).Error
+		if err != nil {
+			return err
+		}
+		if oid == 0 {
+			return fmt.Errorf("table %s.%s not found", schema, p.Table)
+		}
+
+		if err := tx.Raw(`
+			SELECT a.attname AS name,
+			       format_type(a.atttypid, a.atttypmod) AS type,
+			       NOT a.attnotnull AS nullable,
+			       pg_get_expr(d.adbin, d.adrelid) AS default_value,
+			       COALESCE(col_description(a.attrelid, a.attnum), '') AS comment
+			FROM pg_attribute a
+			LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE a.attrelid = ?::oid AND a.attnum > 0 AND NOT a.attisdropped
+			ORDER BY a.attnum`, oid).Scan(&res.Columns).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(`
+			SELECT i.relname AS name,
+			       pg_get_indexdef(ix.indexrelid) AS definition,
+			       ix.indisunique AS is_unique,
+			       ix.indisprimary AS is_primary
+			FROM pg_index ix
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			WHERE ix.indrelid = ?::oid
+			ORDER BY i.relname`, oid).Scan(&res.Indexes).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(`
+			SELECT con.conname AS name, con.conrelid::regclass::text AS "table", pg_get_constraintdef(con.oid) AS definition
+			FROM pg_constraint con
+			WHERE con.contype = 'f' AND con.conrelid = ?::oid
+			ORDER BY con.conname`, oid).Scan(&res.ForeignKeys).Error; err != nil {
+			return err
+		}
+		return tx.Raw(`
+			SELECT con.conname AS name, con.conrelid::regclass::text AS "table", pg_get_constraintdef(con.oid) AS definition
+			FROM pg_constraint con
+			WHERE con.contype = 'f' AND con.confrelid = ?::oid
+			ORDER BY con.conrelid::regclass::text, con.conname`, oid).Scan(&res.ReferencedBy).Error
+	})
+	return res, err
+}
+
+// DBQuery runs one read-only statement and returns at most max_rows rows.
+func (a *DataActions) DBQuery(ctx context.Context, p DBQueryParams) (DBQueryResult, error) {
+	query, err := checkReadOnlySQL(p.SQL)
+	if err != nil {
+		return DBQueryResult{}, err
+	}
+	limit := a.dbConfig.MaxRows
+	if limit <= 0 {
+		limit = defaultDBMaxRows
+	}
+	if p.MaxRows <= 0 {
+		p.MaxRows = defaultDBQueryRows
+	}
+	if p.MaxRows < limit {
+		limit = p.MaxRows
+	}
+
+	res := DBQueryResult{Rows: [][]interface{}{}}
+	start := time.Now()
+	err = a.readOnly(ctx, time.Duration(p.TimeoutSeconds)*time.Second, func(tx *gorm.DB) error {
+		rows, err := tx.Raw(query).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		if res.Columns, err = rows.Columns(); err != nil {
+			return err
+		}
+		for rows.Next() {
+			if len(res.Rows) == limit {
+				res.Truncated = true
+				break
+			}
+			values := make([]interface{}, len(res.Columns))
+			ptrs := make([]interface{}, len(values))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				return err
+			}
+			for i, v := range values {
+				values[i] = jsonFriendlyValue(v)
+			}
+			res.Rows = append(res.Rows, values)
+		}
+		return rows.Err()
+	})
+	res.DurationMs = time.Since(start).Milliseconds()
+	res.RowCount = len(res.Rows)
+	return res, err
+}
+
+// readOnly runs fn in a READ ONLY transaction with a statement timeout (the configured
+// one when timeout is zero or larger) and always rolls it back. The timeout is also the
+// context deadline, so a statement that raises its own statement_timeout is still
+// cancelled. With a configured role the transaction runs as that role.
+func (a *DataActions) readOnly(ctx context.Context, timeout time.Duration, fn func(tx *gorm.DB) error) error {
+	db, err := a.targetDB()
+	if err != nil {
+		return err
+	}
+	limit := defaultDBStatementTimeout
+	if a.dbConfig.StatementTimeoutSeconds > 0 {
+		limit = time.Duration(a.dbConfig.StatementTimeoutSeconds) * time.Second
+	}
+	if timeout <= 0 || timeout > limit {
+		timeout = limit
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	tx := db.WithContext(ctx).Begin(&sql.TxOptions{ReadOnly: true})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.Rollback()
+	if err := tx.Exec("SET TRANSACTION READ ONLY").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())).Error; err != nil {
+		return err
+	}
+	if role := a.dbConfig.Role; role != "" {
+		if err := tx.Exec("SET LOCAL ROLE " + quoteIdentifier(role)).Error; err != nil {
+			return fmt.Errorf("switching to role %s: %w", role, err)
+		}
+	}
+	err = fn(tx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("statement cancelled after %s", timeout)
+	}
+	return err
+}
+
+// targetDB returns the configured database. Astra's own database is never used.
+func (a *DataActions) targetDB() (*gorm.DB, error) {
+	dsn := os.ExpandEnv(a.dbConfig.DSN)
+	if dsn == "" {
+		return nil, errors.New("no database configured: set database.dsn in the agent config")
+	}
+	targetDBPool.Lock()
+	defer targetDBPool.Unlock()
+	if db, ok := targetDBPool.dbs[dsn]; ok {
+		return db, nil
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the configured database: %w", err)
+	}
+	targetDBPool.dbs[dsn] = db
+	return db, nil
+}
+
+// checkReadOnlySQL accepts a single statement starting with a read-only keyword and
+// returns it without a trailing semicolon.
+func checkReadOnlySQL(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+	if query == "" {
+		return "", errors.New("sql must not be empty")
+	}
+	if hasStatementSeparator(query) {
+		return "", errors.New("only a single statement is allowed")
+	}
+	first := strings.ToLower(strings.Fields(stripLeadingSQLComments(query) + " ")[0])
+	first = strings.TrimLeft(first, "(")
+	for _, kw := range readOnlyStatementKeywords {
+		if first == kw {
+			return query, nil
+		}
+	}
+	return "", fmt.Errorf("only read-only statements (%s) are allowed", strings.ToUpper(strings.Join(readOnlyStatementKeywords, ", ")))
+}
+
+// hasStatementSeparator reports a ';' outside string literals, quoted identifiers,
+// dollar-quoted strings and comments.
+func hasStatementSeparator(q string) bool {
+	for i := 0; i < len(q); i++ {
+		switch {
+		case q[i] == '\'' || q[i] == '"':
+			end := strings.IndexByte(q[i+1:], q[i])
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+		case strings.HasPrefix(q[i:], "--"):
+			end := strings.IndexByte(q[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case strings.HasPrefix(q[i:], "/*"):
+			end := strings.Index(q[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		case q[i] == '$':
+			end := strings.IndexByte(q[i+1:], '$')
+			if end < 0 {
+				continue
+			}
+			tag := q[i : i+end+2]
+			if strings.ContainsAny(tag[1:len(tag)-1], " \t\n(),;") {
+				continue // Not a dollar-quote tag, e.g. a $1 placeholder
+			}
+			closing := strings.Index(q[i+len(tag):], tag)
+			if closing < 0 {
+				return false
+			}
+			i += len(tag) + closing + len(tag) - 1
+		case q[i] == ';':
+			return true
+		}
+	}
+	return false
+}
+
+func stripLeadingSQLComments(q string) string {
+	for {
+		q = strings.TrimSpace(q)
+		switch {
+		case strings.HasPrefix(q, "--"):
+			end := strings.IndexByte(q, '\n')
+			if end < 0 {
+				return ""
+			}
+			q = q[end+1:]
+		case strings.HasPrefix(q, "/*"):
+			end := strings.Index(q, "*/")
+			if end < 0 {
+				return ""
+			}
+			q = q[end+2:]
+		default:
+			return q
+		}
+	}
+}
+
+// jsonFriendlyValue turns driver values that encode badly as JSON into strings.
+func jsonFriendlyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case [16]byte:
+		return uuid.UUID(v).String()
+	}
+	return v
+}
+
+// quoteIdentifier quotes a Postgres identifier such as a role name.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func schemaOrDefault(schema string) string {
+	if strings.TrimSpace(schema) == "" {
+		return defaultDBSchema
+	}
+	return schema
+}
@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"testing"
+)
+
+func TestCheckReadOnlySQL(t *testing.T) {
+	allowed := []string{
+		"SELECT 1;",
+		"  -- recent notes\n  select * from notes where title = 'a;b' order by created_at desc",
+		"WITH t AS (SELECT 1) SELECT * FROM t",
+		"EXPLAIN SELECT * FROM users",
+		"SELECT $tag$;$tag$ AS semicolon, $1::int",
+		`SELECT "weird;name" FROM t /* ; */`,
+	}
+	for _, q := range allowed {
+		if _, err := checkReadOnlySQL(q); err != nil {
+			t.Errorf("checkReadOnlySQL(%q) = %v, want nil", q, err)
+		}
+	}
+	rejected := []string{
+		"",
+		"DELETE FROM notes",
+		"SELECT 1; DROP TABLE notes",
+		"/* hi */ UPDATE users SET email = ''",
+		"COMMIT",
+	}
+	for _, q := range rejected {
+		if _, err := checkReadOnlySQL(q); err == nil {
+			t.Errorf("checkReadOnlySQL(%q) = nil, want an error", q)
+		}
+	}
+}
+
+func TestSetDatabaseConfig_DisablesActionsWithoutDSN(t *testing.T) {
+	a := &DataActions{actions: make(map[string]ActionSpec)}
+	for _, name := range databaseActions {
+		a.actions[name] = ActionSpec{Name: name}
+	}
+	a.SetDatabaseConfig(configs.DatabaseConfig{})
+	for _, name := range databaseActions {
+		if _, ok := a.GetAction(name); ok {
+			t.Errorf("expected %s to be removed without a DSN", name)
+		}
+	}
+	if _, err := a.DBQuery(t.Context(), DBQueryParams{SQL: "SELECT 1"}); err == nil {
+		t.Error("expected db_query to fail without a configured database")
+	}
+}
@@ -0,0 +1,588 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultDeclarativeHTTPTimeout = 30 * time.Second
+
+// declarativeAction is a YAML-defined action with its templates compiled.
+type declarativeAction struct {
+	cfg      *configs.ActionYAMLConfig
+	file     string
+	editable bool // Loaded from inside the workspace, where the agent can rewrite it
+	args     []*template.Template
+	dir      *template.Template
+	url      *template.Template
+	body     *template.Template
+	headers  map[string]*template.Template
+	patterns map[string]*regexp.Regexp
+}
+
+var declarativeTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": func(v interface{}, sep string) string {
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Sprint(v)
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// LoadDeclarativeActions registers every YAML file in dir that declares an executor
+// and returns the names of the registered actions. Files without an executor only
+// carry metadata for Go-backed actions and are ignored. A broken file does not stop
+// the others from loading; all problems are returned together.
+func (a *DataActions) LoadDeclarativeActions(dir string) ([]string, error) {
+	return a.loadDeclarativeActions(dir, false)
+}
+
+// loadDeclarativeActions is LoadDeclarativeActions; actions of an editable dir need
+// approval for every run.
+func (a *DataActions) loadDeclarativeActions(dir string, editable bool) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	var errs []error
+	for _, f := range files {
+		cfg, err := configs.LoadActionYAML(f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(f), err))
+			continue
+		}
+		if cfg.Executor == nil {
+			continue
+		}
+		act, err := compileDeclarativeAction(cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(f), err))
+			continue
+		}
+		act.file, act.editable = f, editable
+		if _, exists := a.actions[cfg.Name]; exists {
+			errs = append(errs, fmt.Errorf("%s: action %q is already registered", filepath.Base(f), cfg.Name))
+			continue
+		}
+		source, effect := "", EffectExecute
+		if cfg.Executor.HTTP != nil {
+			source, effect = ContentWeb, EffectNetwork
+		}
+		effects := cfg.Effects
+		if !slices.Contains(effects, effect) {
+			effects = append([]string{effect}, effects...)
+		}
+		Register(a, ActionSpec{
+			Name:          cfg.Name,
+			Description:   cfg.Description,
+			Details:       cfg.Details,
+			Params:        cfg.Params,
+			Schema:        declarativeParamsSchema(cfg.Params),
+			ContentSource: source,
+			Effects:       effects,
+		}, func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+			return a.runDeclarativeAction(ctx, act, params), nil
+		})
+		names = append(names, cfg.Name)
+	}
+	return names, errors.Join(errs...)
+}
+
+// declarativeParamsSchema describes YAML-declared params as a JSON Schema.
+func declarativeParamsSchema(params map[string]configs.ActionParamYAML) map[string]interface{} {
+	props := make(map[string]interface{}, len(params))
+	var required []string
+	for name, p := range params {
+		prop := map[string]interface{}{}
+		if p.Type != "" {
+			prop["type"] = p.Type
+		}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		if len(p.Enum) > 0 {
+			enum := make([]interface{}, len(p.Enum))
+			for i, e := range p.Enum {
+				enum[i] = normalizeYAMLValue(e)
+			}
+			prop["enum"] = enum
+		}
+		if p.Pattern != "" {
+			prop["pattern"] = "^(?:" + p.Pattern + ")$"
+		}
+		if p.Default != nil {
+			prop["default"] = normalizeYAMLValue(p.Default)
+		}
+		if p.Example != nil {
+			prop["examples"] = []interface{}{normalizeYAMLValue(p.Example)}
+		}
+		if p.Required {
+			required = append(required, name)
+		}
+		props[name] = prop
+	}
+	sort.Strings(required)
+	schema := map[string]interface{}{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func compileDeclarativeAction(cfg *configs.ActionYAMLConfig) (*declarativeAction, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	ex := cfg.Executor
+	if (ex.Command == nil) == (ex.HTTP == nil) {
+		return nil, errors.New("executor must define exactly one of command or http")
+	}
+	act := &declarativeAction{cfg: cfg, patterns: map[string]*regexp.Regexp{}}
+	parse := func(field, text string) (*template.Template, error) {
+		t, err := template.New(field).Funcs(declarativeTemplateFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template in %s: %w", field, err)
+		}
+		return t, nil
+	}
+
+	for name, p := range cfg.Params {
+		switch p.Type {
+		case "", "string", "integer", "number", "boolean", "array", "object":
+		default:
+			return nil, fmt.Errorf("param %s: unsupported type %q", name, p.Type)
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile("^(?:" + p.Pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("param %s: invalid pattern: %w", name, err)
+			}
+			act.patterns[name] = re
+		}
+	}
+
+	var err error
+	if c := ex.Command; c != nil {
+		if c.Binary == "" || strings.Contains(c.Binary, "{{") {
+			return nil, errors.New("command.binary is required and cannot be templated")
+		}
+		for i, arg := range c.Args {
+			t, err := parse(fmt.Sprintf("command.args[%d]", i), arg)
+			if err != nil {
+				return nil, err
+			}
+			act.args = append(act.args, t)
+		}
+		if act.dir, err = parse("command.dir", c.Dir); err != nil {
+			return nil, err
+		}
+		return act, nil
+	}
+
+	h := ex.HTTP
+	if h.URL == "" {
+		return nil, errors.New("http.url is required")
+	}
+	if act.url, err = parse("http.url", h.URL); err != nil {
+		return nil, err
+	}
+	if act.body, err = parse("http.body", h.Body); err != nil {
+		return nil, err
+	}
+	act.headers = map[string]*template.Template{}
+	for k, v := range h.Headers {
+		if act.headers[k], err = parse("http.headers."+k, v); err != nil {
+			return nil, err
+		}
+	}
+	return act, nil
+}
+
+// runDeclarativeAction validates params, runs the executor and maps the raw response.
+func (a *DataActions) runDeclarativeAction(ctx context.Context, act *declarativeAction, params map[string]interface{}) map[string]interface{} {
+	fail := func(err error) map[string]interface{} {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+	values, err := bindDeclarativeParams(act, params)
+	if err != nil {
+		return fail(err)
+	}
+
+	var reasons []string
+	if act.cfg.Executor.RequireApproval {
+		reasons = append(reasons, "action is declared with require_approval")
+	}
+	if act.editable {
+		reasons = append(reasons, fmt.Sprintf("action is defined in the workspace (%s), which the agent can edit", act.file))
+	}
+	var cmd *sandboxCommand
+	if act.cfg.Executor.Command != nil {
+		if cmd, err = a.renderDeclarativeCommand(act, values); err != nil {
+			return fail(err)
+		}
+		// YAML actions get no more than run_command: commands outside the allowlist need approval.
+		if reason := a.commandPolicyViolation(cmd.Binary, cmd.Args); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(reasons) > 0 {
+		approvalParams := values
+		if cmd != nil {
+			approvalParams = map[string]interface{}{"command": formatCommandLine(cmd.Binary, cmd.Args), "dir": cmd.Dir, "params": values}
+		}
+		if !a.requestApproval(ctx, ApprovalRequest{
+			Action: act.cfg.Name,
+			Reason: strings.Join(reasons, "; "),
+			Params: approvalParams,
+		}) {
+			return fail(fmt.Errorf("action %s denied: %s", act.cfg.Name, strings.Join(reasons, "; ")))
+		}
+	}
+
+	var raw map[string]interface{}
+	var success bool
+	if cmd != nil {
+		raw, success = a.runDeclarativeCommand(ctx, act, *cmd)
+	} else {
+		raw, success, err = a.runDeclarativeHTTP(ctx, act, values)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	result := raw
+	if len(act.cfg.Executor.Result) > 0 {
+		result = make(map[string]interface{}, len(act.cfg.Executor.Result)+2)
+		for key, path := range act.cfg.Executor.Result {
+			result[key] = lookupPath(raw, path)
+		}
+		if msg, ok := raw["error"]; ok {
+			result["error"] = msg
+		}
+	}
+	result["success"] = success
+	return result
+}
+
+// renderDeclarativeCommand renders the command executor's args and dir for values.
+func (a *DataActions) renderDeclarativeCommand(act *declarativeAction, values map[string]interface{}) (*sandboxCommand, error) {
+	c := act.cfg.Executor.Command
+	args := make([]string, 0, len(act.args))
+	for _, t := range act.args {
+		arg, err := renderTemplate(t, values)
+		if err != nil {
+			return nil, err
+		}
+		if arg != "" {
+			args = append(args, arg)
+		}
+	}
+	dirArg, err := renderTemplate(act.dir, values)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := a.resolveInWorkspace(dirArg)
+	if err != nil {
+		return nil, err
+	}
+	timeout := a.commandTimeout()
+	if c.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.TimeoutSeconds) * time.Second
+	}
+	return &sandboxCommand{Binary: c.Binary, Args: args, Dir: dir, Timeout: timeout, Stream: true}, nil
+}
+
+func (a *DataActions) runDeclarativeCommand(ctx context.Context, act *declarativeAction, cmd sandboxCommand) (map[string]interface{}, bool) {
+	c := act.cfg.Executor.Command
+	run := a.runSandboxed(ctx, cmd)
+	raw := map[string]interface{}{
+		"command":   run.Command,
+		"exit_code": run.ExitCode,
+		"stdout":    run.Stdout,
+		"stderr":    run.Stderr,
+		"timed_out": run.TimedOut,
+		"truncated": run.Truncated,
+	}
+	if run.Error != "" {
+		raw["error"] = run.Error
+	}
+	success := run.ExitCode == 0 && run.Error == ""
+	if c.ParseJSON && run.Stdout != "" {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(run.Stdout), &parsed); err != nil {
+			raw["error"] = fmt.Sprintf("stdout is not valid JSON: %v", err)
+			success = false
+		} else {
+			raw["json"] = parsed
+		}
+	}
+	return raw, success
+}
+
+func (a *DataActions) runDeclarativeHTTP(ctx context.Context, act *declarativeAction, values map[string]interface{}) (map[string]interface{}, bool, error) {
+	h := act.cfg.Executor.HTTP
+	rawURL, err := renderTemplate(act.url, values)
+	if err != nil {
+		return nil, false, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false, fmt.Errorf("invalid url %q", rawURL)
+	}
+	if !isLoopbackHost(u.Hostname()) {
+		return nil, false, fmt.Errorf("declarative http actions may only call local services, got host %q", u.Hostname())
+	}
+	body, err := renderTemplate(act.body, values)
+	if err != nil {
+		return nil, false, err
+	}
+	method := strings.ToUpper(h.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	timeout := defaultDeclarativeHTTPTimeout
+	if h.TimeoutSeconds > 0 {
+		timeout = time.Duration(h.TimeoutSeconds) * time.Second
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	for k, t := range act.headers {
+		v, err := renderTemplate(t, values)
+		if err != nil {
+			return nil, false, err
+		}
+		req.Header.Set(k, v)
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		// Redirects could leave the local host; surface them instead of following.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	limit := a.maxOutputBytes()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := len(data) > limit
+	if truncated {
+		data = data[:limit]
+	}
+	raw := map[string]interface{}{
+		"status":    resp.StatusCode,
+		"truncated": truncated,
+	}
+	var parsed interface{}
+	if !truncated && json.Unmarshal(bytes.TrimSpace(data), &parsed) == nil {
+		raw["body"] = parsed
+	} else {
+		raw["body"] = string(data)
+	}
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !success {
+		raw["error"] = fmt.Sprintf("service responded with %s", resp.Status)
+	}
+	return raw, success, nil
+}
+
+// bindDeclarativeParams checks params against the declared schema, applies defaults
+// and fills unset optional params with "" so templates can test them with {{if}}.
+// For command executors, values of params without a pattern may not start with "-",
+// so a rendered value cannot turn into a flag.
+func bindDeclarativeParams(act *declarativeAction, params map[string]interface{}) (map[string]interface{}, error) {
+	var problems []string
+	for name := range params {
+		if _, ok := act.cfg.Params[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown param", name))
+		}
+	}
+	values := make(map[string]interface{}, len(act.cfg.Params))
+	for name, spec := range act.cfg.Params {
+		v, ok := params[name]
+		if !ok || v == nil {
+			if spec.Required {
+				problems = append(problems, fmt.Sprintf("%s: is required", name))
+				continue
+			}
+			if spec.Default == nil {
+				values[name] = ""
+				continue
+			}
+			v = normalizeYAMLValue(spec.Default)
+		}
+		v, err := coerceParamType(spec.Type, v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if len(spec.Enum) > 0 && !enumContains(spec.Enum, v) {
+			problems = append(problems, fmt.Sprintf("%s: must be one of %v", name, spec.Enum))
+			continue
+		}
+		if re := act.patterns[name]; re != nil && !re.MatchString(fmt.Sprint(v)) {
+			problems = append(problems, fmt.Sprintf("%s: %q does not match %s", name, fmt.Sprint(v), spec.Pattern))
+			continue
+		}
+		if act.cfg.Executor.Command != nil && act.patterns[name] == nil {
+			if flag := flagLikeValue(v); flag != "" {
+				problems = append(problems, fmt.Sprintf("%s: %q must not start with \"-\"", name, flag))
+				continue
+			}
+		}
+		values[name] = v
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("invalid params: %s", strings.Join(problems, "; "))
+	}
+	return values, nil
+}
+
+// flagLikeValue returns the first string in v (or its items) that starts with "-".
+func flagLikeValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if strings.HasPrefix(strings.TrimSpace(v), "-") {
+			return v
+		}
+	case []interface{}:
+		for _, item := range v {
+			if flag := flagLikeValue(item); flag != "" {
+				return flag
+			}
+		}
+	}
+	return ""
+}
+
+func coerceParamType(typ string, v interface{}) (interface{}, error) {
+	switch typ {
+	case "", "any":
+		return v, nil
+	case "string":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "integer":
+		if f, ok := v.(float64); ok && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+	case "number":
+		if f, ok := v.(float64); ok {
+			return f, nil
+		}
+	case "boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case "array":
+		if arr, ok := v.([]interface{}); ok {
+			return arr, nil
+		}
+	case "object":
+		if obj, ok := v.(map[string]interface{}); ok {
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", typ, v)
+}
+
+// normalizeYAMLValue converts YAML-decoded values (int, map[string]interface{} ...)
+// into the shapes encoding/json produces, so defaults validate like caller params.
+func normalizeYAMLValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func enumContains(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(normalizeYAMLValue(e)) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func renderTemplate(t *template.Template, data map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", t.Name(), err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// lookupPath walks a dotted path such as "body.items.0.name" through maps and slices.
+func lookupPath(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch cur := v.(type) {
+		case map[string]interface{}:
+			v = cur[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(cur) {
+				return nil
+			}
+			v = cur[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
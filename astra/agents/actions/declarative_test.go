@@ -0,0 +1,172 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadDeclarativeActions_BundledAndKnowledgeYAML(t *testing.T) {
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+
+	names, err := a.LoadDeclarativeActions("../configs/actions/custom")
+	if err != nil || len(names) == 0 {
+		t.Fatalf("expected bundled declarative actions to load, got %v (%v)", names, err)
+	}
+	// Knowledge YAMLs only carry metadata for Go-backed actions.
+	names, err = a.LoadDeclarativeActions("../configs/actions/knowledge")
+	if err != nil || len(names) != 0 {
+		t.Errorf("expected knowledge YAMLs to be skipped, got %v (%v)", names, err)
+	}
+}
+
+func TestDeclarativeActions_CommandAndHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"path":  r.URL.Path,
+			"token": r.Header.Get("X-Token"),
+			"items": []interface{}{map[string]interface{}{"name": body["name"]}},
+		})
+	}))
+	defer srv.Close()
+
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"actions/echo.yaml": `name: echo_words
+description: echoes words
+params:
+  words:
+    type: array
+    required: true
+  upper:
+    type: boolean
+  mode:
+    type: string
+    default: plain
+    enum: [plain, fancy]
+executor:
+  command:
+    binary: echo
+    args: ["{{.mode}}", "{{if .upper}}UPPER{{end}}", "{{join .words \",\"}}"]
+`,
+		"actions/lookup.yaml": `name: lookup_item
+description: asks a local service
+params:
+  id:
+    type: integer
+    required: true
+  name:
+    type: string
+    pattern: "[a-z]+"
+executor:
+  http:
+    method: POST
+    url: "` + srv.URL + `/items/{{.id}}"
+    headers:
+      X-Token: "secret-{{.id}}"
+    body: '{"name": {{json .name}}}'
+  result:
+    path: body.path
+    first_name: body.items.0.name
+    token: body.token
+`,
+		"actions/broken.yaml": `name: broken
+executor:
+  command:
+    binary: echo
+  http:
+    url: http://localhost
+`,
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+	a.SetCommandPolicy(configs.RunCommandConfig{Allowlist: []configs.CommandRule{{Binary: "echo", Args: []string{"plain", "fancy", "UPPER", "[a-z,]+"}}}})
+	names, err := a.LoadDeclarativeActions(root + "/actions")
+	if err == nil || !strings.Contains(err.Error(), "exactly one of command or http") {
+		t.Errorf("expected broken.yaml to be reported, got %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("expected 2 actions, got %v", names)
+	}
+	if spec, _ := a.GetAction("echo_words"); !slices.Contains(spec.Effects, EffectExecute) {
+		t.Errorf("expected a command action to count as execute, got %v", spec.Effects)
+	}
+
+	res, err := a.ExecuteAction("echo_words", map[string]interface{}{"words": []string{"a", "b"}, "upper": true})
+	if err != nil || res["success"] != true || res["stdout"] != "plain UPPER a,b\n" {
+		t.Errorf("unexpected command result: %v (%v)", res, err)
+	}
+	res, _ = a.ExecuteAction("echo_words", map[string]interface{}{"words": []string{"a"}, "mode": "loud", "extra": 1})
+	if res["success"] != false || !strings.Contains(res["error"].(string), "extra: unknown param") || !strings.Contains(res["error"].(string), "mode: must be one of") {
+		t.Errorf("expected param validation errors, got %v", res)
+	}
+
+	res, err = a.ExecuteAction("lookup_item", map[string]interface{}{"id": 7, "name": "widget"})
+	if err != nil || res["success"] != true {
+		t.Fatalf("unexpected http result: %v (%v)", res, err)
+	}
+	if res["path"] != "/items/7" || res["first_name"] != "widget" || res["token"] != "secret-7" {
+		t.Errorf("unexpected mapped result: %v", res)
+	}
+	res, _ = a.ExecuteAction("lookup_item", map[string]interface{}{"id": 1.5, "name": "Bad Name"})
+	if res["success"] != false || !strings.Contains(res["error"].(string), "id: expected integer") {
+		t.Errorf("expected type validation error, got %v", res)
+	}
+}
+
+func TestDeclarativeCommand_AllowlistAndWorkspaceNeedApproval(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		".astra/actions/shell.yaml": `name: shell
+params:
+  script:
+    type: string
+executor:
+  command:
+    binary: sh
+    args: ["-c", "{{.script}}"]
+`,
+		".astra/actions/echo.yaml": `name: echo_arg
+params:
+  word:
+    type: string
+executor:
+  command:
+    binary: echo
+    args: ["{{.word}}"]
+`,
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+	a.SetCommandPolicy(configs.RunCommandConfig{Allowlist: []configs.CommandRule{{Binary: "echo", Args: []string{"[a-z-]+"}}}})
+	var asked []ApprovalRequest
+	a.SetApprovalGate(func(req ApprovalRequest) bool {
+		asked = append(asked, req)
+		return false
+	})
+	if _, err := a.loadDeclarativeActions(root+"/.astra/actions", a.withinWorkspace(root+"/.astra/actions")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, _ := a.ExecuteAction("shell", map[string]interface{}{"script": "id"})
+	if res["success"] != false || len(asked) != 1 || !strings.Contains(asked[0].Reason, `binary "sh" is not in the allowlist`) || !strings.Contains(asked[0].Reason, "defined in the workspace") {
+		t.Errorf("expected sh from the workspace to need approval, got %v (%+v)", res, asked)
+	}
+	res, _ = a.ExecuteAction("echo_arg", map[string]interface{}{"word": "--help"})
+	if res["success"] != false || !strings.Contains(res["error"].(string), `must not start with "-"`) || len(asked) != 1 {
+		t.Errorf("expected a flag-like value to be rejected before running, got %v", res)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true, "example.com": false, "10.0.0.1": false} {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
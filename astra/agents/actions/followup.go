@@ -1,7 +1,9 @@
 package actions
 
+import "context"
+
 type AskFollowUpQuestionsParams struct {
-	Questions []string `json:"questions"`
+	Questions []string `json:"questions" required:"true" desc:"Questions to ask the user"`
 }
 
 type AskFollowUpQuestionsResult struct {
@@ -13,6 +15,6 @@ type FollowUpItem struct {
 	Question string `json:"question"`
 }
 
-func (a *DataActions) AskFollowUpQuestions(params AskFollowUpQuestionsParams) (AskFollowUpQuestionsParams, error) {
+func (a *DataActions) AskFollowUpQuestions(ctx context.Context, params AskFollowUpQuestionsParams) (AskFollowUpQuestionsParams, error) {
 	return params, nil
 }
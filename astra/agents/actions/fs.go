@@ -1,179 +1,276 @@
 package actions
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"log"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
-// FetchFileStructureParams defines parameters for fetching a repo structure.
-type FetchFileStructureParams struct {
-	Path       string   `json:"path"`        // Base path (defaults to current dir if empty)
-	IgnoreDirs []string `json:"ignore_dirs"` // Directories to ignore (e.g. ".git", "logs", "node_modules")
-}
-
-// FetchFileStructureResult holds the resulting file tree.
-type FetchFileStructureResult struct {
-	Structure string `json:"structure"`       // Formatted tree output
-	Error     string `json:"error,omitempty"` // Error message, if any
-}
-
-// FetchFileStructureInRepo runs the `tree` command and captures its output.
-// It automatically includes the `-I` flag for ignored directories.
-func (a *DataActions) FetchFileStructureInRepo(params FetchFileStructureParams) FetchFileStructureResult {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("⚠️ Recovered from panic in FetchFileStructureInRepo: %v\n", r)
-		}
-	}()
-
-	path := params.Path
-	if path == "" {
-		path = "."
-	}
-
-	// Defensive: if IgnoreDirs is nil, initialize it
-	if params.IgnoreDirs == nil {
-		params.IgnoreDirs = []string{}
-	}
-
-	// Construct ignore pattern for `tree -I`
-	ignoreArr := "venv|node_modules|.env|dist|package-lock.json"
-	ignorePattern := ignoreArr
-	if len(params.IgnoreDirs) > 0 {
-		ignorePattern = fmt.Sprintf("%s|%s", ignoreArr, strings.Join(params.IgnoreDirs, "|"))
-	}
-
-	// Verify that the tree binary exists
-	treePath, err := exec.LookPath("tree")
-	if err != nil {
-		return FetchFileStructureResult{Error: "the 'tree' command is not installed or not in PATH"}
-	}
-
-	var cmd *exec.Cmd
-	if ignorePattern != "" {
-		cmd = exec.Command(treePath, path, "-I", ignorePattern)
-	} else {
-		cmd = exec.Command(treePath, path)
-	}
-
-	fmt.Println("cmd:", cmd.String())
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-
-	// Safely run command
-	if err := cmd.Run(); err != nil {
-		return FetchFileStructureResult{
-			Error: fmt.Sprintf("tree command failed: %v\n%s", err, out.String()),
-		}
-	}
-
-	// Safety: Limit very large output
-	output := out.String()
-	if len(output) > 500000 { // ~500KB limit
-		output = output[:500000] + "\n... (output truncated)"
-	}
-
-	return FetchFileStructureResult{
-		Structure: output,
-	}
-}
+const (
+	defaultReadBudgetBytes = 100 * 1024       // total content returned by one read_files_in_this_repo call
+	maxReadFileBytes       = 10 * 1024 * 1024 // files larger than this are never loaded
+)
 
 // ReadFileParams defines parameters for reading a specific file in the repo.
 type ReadFileParams struct {
-	Path string `json:"path"` // Full or relative path to the file
+	Path      string `json:"path" required:"true" desc:"Full or relative path to the file"`
+	StartLine int    `json:"start_line,omitempty" desc:"First line to return (1-based, default 1)"`
+	EndLine   int    `json:"end_line,omitempty" desc:"Last line to return (inclusive, default end of file)"`
+	Symbol    string `json:"symbol,omitempty" desc:"Go only: read just this declaration, e.g. NewDataActions or DataActions.ExecuteAction"`
 }
 
 // ReadFileResult defines the output containing the file’s contents.
 type ReadFileResult struct {
-	Path    string `json:"path"`              // Path of the file read
-	Content string `json:"content,omitempty"` // File content (if read successfully)
-	Error   string `json:"error,omitempty"`   // Error message, if any
+	Path          string `json:"path"`                      // Path of the file read
+	StartLine     int    `json:"start_line,omitempty"`      // First line included in content
+	EndLine       int    `json:"end_line,omitempty"`        // Last line included in content
+	TotalLines    int    `json:"total_lines,omitempty"`     // Lines in the whole file
+	Content       string `json:"content,omitempty"`         // File content (if read successfully)
+	Truncated     bool   `json:"truncated,omitempty"`       // The byte budget ran out before end_line
+	NextStartLine int    `json:"next_start_line,omitempty"` // Where to continue reading when truncated
+	Error         string `json:"error,omitempty"`           // Error message, if any
 }
 
 type ReadFilesParams struct {
-	Paths []string `json:"paths"` // List of files to read
+	Paths    []string         `json:"paths,omitempty" desc:"Whole files to read"`
+	Files    []ReadFileParams `json:"files,omitempty" desc:"Files with line ranges or symbols"`
+	MaxBytes int              `json:"max_bytes,omitempty" desc:"Total content budget across all files (default 100KB)"`
+	Raw      bool             `json:"raw,omitempty" desc:"Return content without line-number prefixes"`
 }
 
 type ReadFilesResult struct {
-	Results []ReadFileResult `json:"results"`
+	Results      []ReadFileResult `json:"results"`
+	Truncated    bool             `json:"truncated,omitempty"`     // Not everything requested was returned
+	ContinueWith *ReadFilesParams `json:"continue_with,omitempty"` // Pass back as params to read the rest
 }
 
-// ReadFileInRepo reads the contents of a file within the repository.
+// ReadFileInRepo reads a file (or a line range / Go symbol of it) within the repository.
 func (a *DataActions) ReadFileInRepo(params ReadFileParams) ReadFileResult {
+	return a.readFileRange(params, defaultReadBudgetBytes, false)
+}
+
+// readFileRange returns up to budget bytes of the requested lines. Unless raw is set,
+// each line is prefixed with its line number ("   12 | ...").
+func (a *DataActions) readFileRange(params ReadFileParams, budget int, raw bool) ReadFileResult {
 	if params.Path == "" {
 		return ReadFileResult{Error: "file path is required"}
 	}
-
-	// Resolve absolute path
-	absPath, err := filepath.Abs(params.Path)
+	absPath, err := a.resolveInWorkspace(params.Path)
 	if err != nil {
-		return ReadFileResult{Error: fmt.Sprintf("failed to resolve path: %v", err)}
+		return ReadFileResult{Path: params.Path, Error: err.Error()}
 	}
-
-	// Ensure it's within repo root
-	repoRoot, err := os.Getwd()
+	info, err := os.Stat(absPath)
 	if err != nil {
-		return ReadFileResult{Error: fmt.Sprintf("failed to get working directory: %v", err)}
+		return ReadFileResult{Path: absPath, Error: fmt.Sprintf("failed to read file: %v", err)}
 	}
-
-	if !strings.HasPrefix(absPath, repoRoot) {
-		return ReadFileResult{Error: "access denied: file outside repository root"}
+	if info.IsDir() {
+		return ReadFileResult{Path: absPath, Error: "path is a directory; use fetch_file_structure_in_this_repo"}
+	}
+	if info.Size() > maxReadFileBytes {
+		return ReadFileResult{Path: absPath, Error: "file too large to read (>10MB)"}
 	}
-
-	// Read file using modern API
 	data, err := os.ReadFile(absPath)
 	if err != nil {
-		return ReadFileResult{
-			Path:  absPath,
-			Error: fmt.Sprintf("failed to read file: %v", err),
-		}
+		return ReadFileResult{Path: absPath, Error: fmt.Sprintf("failed to read file: %v", err)}
 	}
+	if isBinary(data) {
+		return ReadFileResult{Path: absPath, Error: "binary file"}
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	res := ReadFileResult{Path: absPath, TotalLines: len(lines)}
 
-	// Safety: limit file size
-	if len(data) > 2*1024*1024 { // 2MB
-		return ReadFileResult{
-			Path:  absPath,
-			Error: "file too large to display (>2MB)",
+	start, end := params.StartLine, params.EndLine
+	if params.Symbol != "" {
+		if filepath.Ext(absPath) != ".go" {
+			res.Error = "symbol reads are only supported for Go files; use start_line/end_line"
+			return res
+		}
+		symStart, symEnd, err := goSymbolLineRange(absPath, data, params.Symbol)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		// A start_line inside the symbol continues a previously truncated symbol read.
+		if start < symStart || start > symEnd {
+			start = symStart
 		}
+		end = symEnd
+	}
+	if start < 1 {
+		start = 1
+	}
+	if end <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+	if len(lines) == 0 {
+		return res
+	}
+	if start > len(lines) {
+		res.Error = fmt.Sprintf("start_line %d is beyond the end of the file (%d lines)", start, len(lines))
+		return res
+	}
+	if start > end {
+		res.Error = fmt.Sprintf("start_line %d is after end_line %d", start, end)
+		return res
 	}
 
-	return ReadFileResult{
-		Path:    absPath,
-		Content: string(data),
+	var sb strings.Builder
+	res.StartLine = start
+	for n := start; n <= end; n++ {
+		line := lines[n-1]
+		if !raw {
+			line = fmt.Sprintf("%5d | %s", n, line)
+		}
+		// Always return at least one line so a continuation makes progress.
+		if n > start && sb.Len()+len(line)+1 > budget {
+			res.Truncated = true
+			res.NextStartLine = n
+			break
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+		res.EndLine = n
 	}
+	res.Content = sb.String()
+	return res
 }
 
-func (a *DataActions) ReadFilesInRepo(params ReadFilesParams) ReadFilesResult {
-	if len(params.Paths) == 0 {
+// ReadFilesInRepo reads several files within one byte budget. When the budget runs
+// out, continue_with holds the params that read the remainder.
+func (a *DataActions) ReadFilesInRepo(ctx context.Context, params ReadFilesParams) (ReadFilesResult, error) {
+	requests := make([]ReadFileParams, 0, len(params.Paths)+len(params.Files))
+	for _, p := range params.Paths {
+		requests = append(requests, ReadFileParams{Path: p})
+	}
+	requests = append(requests, params.Files...)
+	if len(requests) == 0 {
 		return ReadFilesResult{
 			Results: []ReadFileResult{{Error: "no paths provided"}},
-		}
+		}, nil
 	}
 
-	results := make([]ReadFileResult, 0, len(params.Paths))
-	for _, p := range params.Paths {
-		res := a.ReadFileInRepo(ReadFileParams{Path: p})
+	budget := params.MaxBytes
+	if budget <= 0 {
+		budget = defaultReadBudgetBytes
+	}
+
+	results := make([]ReadFileResult, 0, len(requests))
+	var remaining []ReadFileParams
+	for i, req := range requests {
+		if budget <= 0 {
+			remaining = append(remaining, requests[i:]...)
+			break
+		}
+		res := a.readFileRange(req, budget, params.Raw)
+		budget -= len(res.Content)
 		results = append(results, res)
+		if res.Truncated {
+			next := req
+			next.StartLine = res.NextStartLine
+			remaining = append(remaining, next)
+			remaining = append(remaining, requests[i+1:]...)
+			break
+		}
 	}
 
-	return ReadFilesResult{Results: results}
+	out := ReadFilesResult{Results: results}
+	if len(remaining) > 0 {
+		out.Truncated = true
+		out.ContinueWith = &ReadFilesParams{Files: remaining, MaxBytes: params.MaxBytes, Raw: params.Raw}
+	}
+	return out, nil
 }
 
-func (a *DataActions) GetPWD() (map[string]interface{}, error) {
-	dir, err := os.Getwd()
+// goSymbolLineRange finds the 1-based line range of a top-level declaration in a Go
+// file, including its doc comment. symbol is "Name" or "Type.Method".
+func goSymbolLineRange(filename string, src []byte, symbol string) (int, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
 	if err != nil {
-		log.Fatalf("Error getting current directory: %v", err)
+		return 0, 0, fmt.Errorf("failed to parse %s: %w", filepath.Base(filename), err)
+	}
+	recv, name := "", symbol
+	if i := strings.LastIndex(symbol, "."); i >= 0 {
+		recv = strings.Trim(symbol[:i], "(*)")
+		name = symbol[i+1:]
+	}
+	span := func(doc *ast.CommentGroup, node ast.Node) (int, int, error) {
+		from := node.Pos()
+		if doc != nil {
+			from = doc.Pos()
+		}
+		return fset.Position(from).Line, fset.Position(node.End()).Line, nil
 	}
 
-	fmt.Println("GetPWD_GetPWD ", dir)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name == name && receiverTypeName(d) == recv {
+				return span(d.Doc, d)
+			}
+		case *ast.GenDecl:
+			if recv != "" {
+				continue
+			}
+			for _, spec := range d.Specs {
+				var names []*ast.Ident
+				var doc *ast.CommentGroup
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names, doc = []*ast.Ident{s.Name}, s.Doc
+				case *ast.ValueSpec:
+					names, doc = s.Names, s.Doc
+				}
+				for _, id := range names {
+					if id.Name != name {
+						continue
+					}
+					if !d.Lparen.IsValid() {
+						return span(d.Doc, d)
+					}
+					return span(doc, spec)
+				}
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("symbol %q not found in %s", symbol, filepath.Base(filename))
+}
 
+// receiverTypeName returns "T" for methods on T or *T (including generic T[K]), or "" for functions.
+func receiverTypeName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		expr = t.X
+	case *ast.IndexListExpr:
+		expr = t.X
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func (a *DataActions) GetPWD(ctx context.Context, _ NoParams) (map[string]interface{}, error) {
+	dir, err := a.workspaceRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace root: %w", err)
+	}
 	return map[string]interface{}{
 		"success": true,
 		"result":  dir,
@@ -0,0 +1,61 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadFilesInRepo_RangesSymbolsAndBudget(t *testing.T) {
+	root := t.TempDir()
+	var long strings.Builder
+	for i := 1; i <= 200; i++ {
+		long.WriteString("line of text\n")
+	}
+	writeTestTree(t, root, map[string]string{
+		"svc/svc.go": `package svc
+
+type Service struct{}
+
+// Run does the work.
+func (s *Service) Run() error {
+	return nil
+}
+
+func Run() {}
+`,
+		"big.txt": long.String(),
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+
+	res, _ := a.ReadFilesInRepo(t.Context(), ReadFilesParams{Files: []ReadFileParams{
+		{Path: "svc/svc.go", Symbol: "Service.Run"},
+		{Path: "svc/svc.go", StartLine: 3, EndLine: 3},
+	}})
+	if res.Truncated || len(res.Results) != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	method := res.Results[0]
+	if method.Error != "" || method.StartLine != 5 || method.EndLine != 8 || !strings.HasPrefix(method.Content, "    5 | // Run does the work.\n") {
+		t.Errorf("unexpected symbol read: %+v", method)
+	}
+	if got := res.Results[1].Content; got != "    3 | type Service struct{}\n" {
+		t.Errorf("unexpected range read: %q", got)
+	}
+
+	res, _ = a.ReadFilesInRepo(t.Context(), ReadFilesParams{Paths: []string{"big.txt", "svc/svc.go"}, MaxBytes: 500, Raw: true})
+	if !res.Truncated || res.ContinueWith == nil || len(res.Results) != 1 {
+		t.Fatalf("expected the budget to truncate the first file, got %+v", res)
+	}
+	first := res.Results[0]
+	if !first.Truncated || first.EndLine != 38 || first.NextStartLine != 39 || first.TotalLines != 200 {
+		t.Errorf("unexpected truncated read: start=%d end=%d next=%d total=%d", first.StartLine, first.EndLine, first.NextStartLine, first.TotalLines)
+	}
+	cont := res.ContinueWith.Files
+	if len(cont) != 2 || cont[0].StartLine != 39 || cont[1].Path != "svc/svc.go" {
+		t.Errorf("unexpected continuation: %+v", cont)
+	}
+
+	if r := a.ReadFileInRepo(ReadFileParams{Path: "../outside.txt"}); r.Error == "" {
+		t.Errorf("expected paths outside the workspace to be rejected")
+	}
+}
@@ -0,0 +1,210 @@
+package actions
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one parsed line of a .gitignore file.
+type ignoreRule struct {
+	base     string // directory of the .gitignore, slash-separated and relative to the root ("" for root)
+	re       *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern contains a slash, so it is matched against the path relative to base
+}
+
+// gitIgnore evaluates .gitignore rules collected while walking a directory tree.
+// Rules from deeper directories are appended later and therefore take precedence.
+type gitIgnore struct {
+	rules []ignoreRule
+}
+
+// newGitIgnore returns a matcher seeded with the root .gitignore, if any.
+func newGitIgnore(root string) *gitIgnore {
+	g := &gitIgnore{}
+	g.loadDir(root, "")
+	return g
+}
+
+// loadDir reads <root>/<relDir>/.gitignore and appends its rules.
+func (g *gitIgnore) loadDir(root, relDir string) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(relDir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), relDir); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+}
+
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	re, err := regexp.Compile("^" + globToRegex(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// Ignored reports whether rel (slash-separated, relative to the root) is ignored.
+func (g *gitIgnore) Ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, r.base+"/")
+		}
+		if !r.anchored {
+			target = path.Base(target)
+		}
+		if r.re.MatchString(target) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// walkRespectingGitIgnore walks start (a directory inside root) depth-first, skipping
+// .git and anything matched by the .gitignore files between root and the visited path.
+// fn receives slash-separated paths relative to root and may return filepath.SkipDir.
+func walkRespectingGitIgnore(root, start string, fn func(rel string, d fs.DirEntry) error) error {
+	ignore := newGitIgnore(root)
+	if startRel, err := filepath.Rel(root, start); err == nil && startRel != "." {
+		parts := strings.Split(filepath.ToSlash(startRel), "/")
+		for i := 1; i <= len(parts); i++ {
+			ignore.loadDir(root, strings.Join(parts[:i], "/"))
+		}
+	}
+	return filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p == start {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, p)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if ignore.Ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			ignore.loadDir(root, rel)
+		}
+		return fn(rel, d)
+	})
+}
+
+// matchGlob matches a user-supplied glob against a slash-separated relative path.
+// Globs without a slash match the base name, like .gitignore patterns.
+func matchGlob(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	target := rel
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(rel)
+	}
+	re, err := regexp.Compile("^" + globToRegex(pattern) + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(target)
+}
+
+// matchAnyGlob reports whether rel matches at least one pattern.
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegex converts a gitignore-style glob (supporting *, ?, [..] and **) to a regex body.
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end <= 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,494 @@
+package actions
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// goPackagesLoadMode type-checks dependencies from source (NeedDeps) instead of reading
+// export data, so the index keeps working when the toolchain and x/tools versions drift.
+const goPackagesLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+	packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps
+
+// GoListDeclarationsParams selects the packages whose declarations should be listed.
+type GoListDeclarationsParams struct {
+	Package      string `json:"package" default:"./..." desc:"Package pattern, e.g. ./astra/sources/psql/dao"`
+	ExportedOnly bool   `json:"exported_only,omitempty" desc:"Skip unexported declarations"`
+}
+
+// GoDeclaration describes one package-level declaration or method.
+type GoDeclaration struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"` // func, method, type, var, const
+	Exported  bool   `json:"exported"`
+	Signature string `json:"signature"`
+	Location  string `json:"location"` // file:line
+}
+
+// GoPackageDeclarations groups declarations by package.
+type GoPackageDeclarations struct {
+	Package      string          `json:"package"`
+	Name         string          `json:"name"`
+	Declarations []GoDeclaration `json:"declarations"`
+}
+
+type GoListDeclarationsResult struct {
+	Packages []GoPackageDeclarations `json:"packages"`
+	Warnings []string                `json:"warnings,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+// GoSymbolParams identifies a symbol such as "SaveMessage", "ChatMessageDAO.SaveMessage"
+// or "dao.ChatMessageDAO.SaveMessage".
+type GoSymbolParams struct {
+	Symbol  string `json:"symbol" required:"true" desc:"Symbol name, optionally qualified by type and package"`
+	Package string `json:"package,omitempty" default:"./..." desc:"Package pattern to search"`
+}
+
+// GoSymbolLocation is a resolved symbol definition.
+type GoSymbolLocation struct {
+	Symbol    string `json:"symbol"`
+	Kind      string `json:"kind"`
+	Signature string `json:"signature"`
+	Location  string `json:"location"` // file:line:column
+}
+
+type GoFindDefinitionResult struct {
+	Definitions []GoSymbolLocation `json:"definitions"`
+	Warnings    []string           `json:"warnings,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// GoReference is a single use of a symbol.
+type GoReference struct {
+	Location string `json:"location"` // file:line:column
+	Text     string `json:"text"`     // Source line containing the reference
+}
+
+type GoFindReferencesResult struct {
+	Definitions []GoSymbolLocation `json:"definitions"`
+	References  []GoReference      `json:"references"`
+	Warnings    []string           `json:"warnings,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// GoMethod is an entry of a type's method set.
+type GoMethod struct {
+	Name            string `json:"name"`
+	Signature       string `json:"signature"`
+	PointerReceiver bool   `json:"pointer_receiver"`
+	Location        string `json:"location"`
+}
+
+type GoTypeInfoResult struct {
+	Type            string             `json:"type"`
+	Kind            string             `json:"kind"` // struct, interface, alias, ...
+	Location        string             `json:"location"`
+	Underlying      string             `json:"underlying"`
+	Methods         []GoMethod         `json:"methods"`
+	Implementations []GoSymbolLocation `json:"implementations,omitempty"` // Only for interfaces
+	Warnings        []string           `json:"warnings,omitempty"`
+	Error           string             `json:"error,omitempty"`
+}
+
+// goIndex holds loaded packages and helpers to render positions relative to the workspace.
+type goIndex struct {
+	root     string
+	pkgs     []*packages.Package
+	fset     *token.FileSet
+	warnings []string
+}
+
+func (a *DataActions) loadGoIndex(ctx context.Context, pattern string) (*goIndex, error) {
+	if pattern == "" {
+		pattern = "./..."
+	}
+	if err := checkPackagePattern(pattern); err != nil {
+		return nil, err
+	}
+	root, err := a.workspaceRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace root: %w", err)
+	}
+	fset := token.NewFileSet()
+	cfg := &packages.Config{Context: ctx, Mode: goPackagesLoadMode, Dir: root, Env: a.filteredEnv(), Fset: fset}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages %s: %w", pattern, err)
+	}
+	idx := &goIndex{root: root, pkgs: pkgs, fset: fset}
+	for _, p := range pkgs {
+		for _, e := range p.Errors {
+			idx.warnings = append(idx.warnings, e.Error())
+		}
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages matched %s", pattern)
+	}
+	return idx, nil
+}
+
+func (idx *goIndex) position(pos token.Pos, withColumn bool) string {
+	p := idx.fset.Position(pos)
+	if !p.IsValid() {
+		return ""
+	}
+	file := p.Filename
+	if rel, err := filepath.Rel(idx.root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		file = filepath.ToSlash(rel)
+	}
+	if withColumn {
+		return fmt.Sprintf("%s:%d:%d", file, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d", file, p.Line)
+}
+
+func objectKind(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.TypeName:
+		return "type"
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		if o.IsField() {
+			return "field"
+		}
+		return "var"
+	}
+	return "other"
+}
+
+func objectSignature(obj types.Object) string {
+	qualifier := func(p *types.Package) string {
+		if obj.Pkg() != nil && p.Path() == obj.Pkg().Path() {
+			return ""
+		}
+		return p.Name()
+	}
+	if tn, ok := obj.(*types.TypeName); ok {
+		return "type " + tn.Name() + " " + types.TypeString(tn.Type().Underlying(), qualifier)
+	}
+	return types.ObjectString(obj, qualifier)
+}
+
+// namedTypeOf returns the named type behind a type name, if any.
+func namedTypeOf(obj types.Object) *types.Named {
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, _ := types.Unalias(tn.Type()).(*types.Named)
+	return named
+}
+
+// ListGoDeclarations lists package-level declarations and methods for the matched packages.
+func (a *DataActions) ListGoDeclarations(ctx context.Context, params GoListDeclarationsParams) (GoListDeclarationsResult, error) {
+	idx, err := a.loadGoIndex(ctx, params.Package)
+	if err != nil {
+		return GoListDeclarationsResult{Error: err.Error()}, nil
+	}
+	result := GoListDeclarationsResult{Warnings: idx.warnings}
+	for _, pkg := range idx.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		pd := GoPackageDeclarations{Package: pkg.PkgPath, Name: pkg.Name}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if !params.ExportedOnly || obj.Exported() {
+				pd.Declarations = append(pd.Declarations, GoDeclaration{
+					Name:      obj.Name(),
+					Kind:      objectKind(obj),
+					Exported:  obj.Exported(),
+					Signature: objectSignature(obj),
+					Location:  idx.position(obj.Pos(), false),
+				})
+			}
+			named := namedTypeOf(obj)
+			if named == nil {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				m := named.Method(i)
+				if params.ExportedOnly && !m.Exported() {
+					continue
+				}
+				pd.Declarations = append(pd.Declarations, GoDeclaration{
+					Name:      obj.Name() + "." + m.Name(),
+					Kind:      "method",
+					Exported:  m.Exported(),
+					Signature: objectSignature(m),
+					Location:  idx.position(m.Pos(), false),
+				})
+			}
+		}
+		result.Packages = append(result.Packages, pd)
+	}
+	return result, nil
+}
+
+// symbolNames returns the spellings a symbol can be referred to by.
+func symbolNames(pkgName, typeName, name string) []string {
+	if typeName == "" {
+		return []string{name, pkgName + "." + name}
+	}
+	return []string{name, typeName + "." + name, pkgName + "." + typeName + "." + name}
+}
+
+// resolveSymbol finds objects (package-level, methods and struct fields) matching symbol.
+func (idx *goIndex) resolveSymbol(symbol string) []types.Object {
+	var found []types.Object
+	matches := func(names []string) bool {
+		for _, n := range names {
+			if n == symbol {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pkg := range idx.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if matches(symbolNames(pkg.Name, "", name)) {
+				found = append(found, obj)
+			}
+			named := namedTypeOf(obj)
+			if named == nil {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				m := named.Method(i)
+				if matches(symbolNames(pkg.Name, name, m.Name())) {
+					found = append(found, m)
+				}
+			}
+			switch u := named.Underlying().(type) {
+			case *types.Struct:
+				for i := 0; i < u.NumFields(); i++ {
+					f := u.Field(i)
+					if matches(symbolNames(pkg.Name, name, f.Name())) {
+						found = append(found, f)
+					}
+				}
+			case *types.Interface:
+				for i := 0; i < u.NumExplicitMethods(); i++ {
+					m := u.ExplicitMethod(i)
+					if matches(symbolNames(pkg.Name, name, m.Name())) {
+						found = append(found, m)
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+func (idx *goIndex) symbolLocation(obj types.Object) GoSymbolLocation {
+	return GoSymbolLocation{
+		Symbol:    obj.Name(),
+		Kind:      objectKind(obj),
+		Signature: objectSignature(obj),
+		Location:  idx.position(obj.Pos(), true),
+	}
+}
+
+// FindGoDefinition returns where a symbol is declared.
+func (a *DataActions) FindGoDefinition(ctx context.Context, params GoSymbolParams) (GoFindDefinitionResult, error) {
+	if params.Symbol == "" {
+		return GoFindDefinitionResult{Error: "symbol is required"}, nil
+	}
+	idx, err := a.loadGoIndex(ctx, params.Package)
+	if err != nil {
+		return GoFindDefinitionResult{Error: err.Error()}, nil
+	}
+	result := GoFindDefinitionResult{Definitions: []GoSymbolLocation{}, Warnings: idx.warnings}
+	for _, obj := range idx.resolveSymbol(params.Symbol) {
+		result.Definitions = append(result.Definitions, idx.symbolLocation(obj))
+	}
+	if len(result.Definitions) == 0 {
+		result.Error = fmt.Sprintf("symbol %s not found", params.Symbol)
+	}
+	return result, nil
+}
+
+// FindGoReferences returns every use of a symbol across the loaded packages.
+func (a *DataActions) FindGoReferences(ctx context.Context, params GoSymbolParams) (GoFindReferencesResult, error) {
+	if params.Symbol == "" {
+		return GoFindReferencesResult{Error: "symbol is required"}, nil
+	}
+	idx, err := a.loadGoIndex(ctx, params.Package)
+	if err != nil {
+		return GoFindReferencesResult{Error: err.Error()}, nil
+	}
+	result := GoFindReferencesResult{Definitions: []GoSymbolLocation{}, References: []GoReference{}, Warnings: idx.warnings}
+
+	targets := map[string]bool{}
+	for _, obj := range idx.resolveSymbol(params.Symbol) {
+		result.Definitions = append(result.Definitions, idx.symbolLocation(obj))
+		targets[idx.position(obj.Pos(), true)] = true
+	}
+	if len(targets) == 0 {
+		result.Error = fmt.Sprintf("symbol %s not found", params.Symbol)
+		return result, nil
+	}
+
+	// Objects imported across packages may be distinct instances, so match by declaration position.
+	seen := map[string]bool{}
+	lines := newSourceLineCache()
+	for _, pkg := range idx.pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for ident, obj := range pkg.TypesInfo.Uses {
+			if obj == nil || !targets[idx.position(obj.Pos(), true)] {
+				continue
+			}
+			pos := idx.fset.Position(ident.Pos())
+			loc := idx.position(ident.Pos(), true)
+			if seen[loc] {
+				continue
+			}
+			seen[loc] = true
+			result.References = append(result.References, GoReference{
+				Location: loc,
+				Text:     strings.TrimSpace(lines.line(pos.Filename, pos.Line)),
+			})
+		}
+	}
+	sort.Slice(result.References, func(i, j int) bool {
+		return result.References[i].Location < result.References[j].Location
+	})
+	return result, nil
+}
+
+// GoTypeInfo returns a type's method set and, for interfaces, the types implementing it.
+func (a *DataActions) GoTypeInfo(ctx context.Context, params GoSymbolParams) (GoTypeInfoResult, error) {
+	if params.Symbol == "" {
+		return GoTypeInfoResult{Error: "symbol is required"}, nil
+	}
+	idx, err := a.loadGoIndex(ctx, params.Package)
+	if err != nil {
+		return GoTypeInfoResult{Error: err.Error()}, nil
+	}
+	var named *types.Named
+	var typeObj types.Object
+	for _, obj := range idx.resolveSymbol(params.Symbol) {
+		if n := namedTypeOf(obj); n != nil {
+			named, typeObj = n, obj
+			break
+		}
+	}
+	if named == nil {
+		return GoTypeInfoResult{Error: fmt.Sprintf("type %s not found", params.Symbol), Warnings: idx.warnings}, nil
+	}
+
+	result := GoTypeInfoResult{
+		Type:       typeObj.Pkg().Name() + "." + typeObj.Name(),
+		Kind:       typeKind(named.Underlying()),
+		Location:   idx.position(typeObj.Pos(), true),
+		Underlying: types.TypeString(named.Underlying(), types.RelativeTo(typeObj.Pkg())),
+		Methods:    []GoMethod{},
+		Warnings:   idx.warnings,
+	}
+
+	valueSet := types.NewMethodSet(named)
+	fullSet := types.NewMethodSet(types.NewPointer(named))
+	if types.IsInterface(named) {
+		fullSet = valueSet
+	}
+	for i := 0; i < fullSet.Len(); i++ {
+		m := fullSet.At(i).Obj()
+		result.Methods = append(result.Methods, GoMethod{
+			Name:            m.Name(),
+			Signature:       objectSignature(m),
+			PointerReceiver: valueSet.Lookup(m.Pkg(), m.Name()) == nil,
+			Location:        idx.position(m.Pos(), true),
+		})
+	}
+
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return result, nil
+	}
+	for _, pkg := range idx.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			candidate := namedTypeOf(scope.Lookup(name))
+			if candidate == nil || candidate == named || types.IsInterface(candidate) {
+				continue
+			}
+			if types.Implements(candidate, iface) || types.Implements(types.NewPointer(candidate), iface) {
+				result.Implementations = append(result.Implementations, idx.symbolLocation(candidate.Obj()))
+			}
+		}
+	}
+	return result, nil
+}
+
+func typeKind(t types.Type) string {
+	switch t.(type) {
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	case *types.Signature:
+		return "func"
+	case *types.Map:
+		return "map"
+	case *types.Slice:
+		return "slice"
+	case *types.Basic:
+		return "basic"
+	}
+	return "other"
+}
+
+// sourceLineCache reads files lazily so reference listings can show the source line.
+type sourceLineCache struct {
+	files map[string][]string
+}
+
+func newSourceLineCache() *sourceLineCache {
+	return &sourceLineCache{files: map[string][]string{}}
+}
+
+func (c *sourceLineCache) line(file string, n int) string {
+	lines, ok := c.files[file]
+	if !ok {
+		if f, err := os.Open(file); err == nil {
+			scanner := bufio.NewScanner(f)
+			scanner.Buffer(make([]byte, 64*1024), maxSearchFileBytes)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			f.Close()
+		}
+		c.files[file] = lines
+	}
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return lines[n-1]
+}
@@ -0,0 +1,98 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func newGoSymbolsTestActions(t *testing.T) *DataActions {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+type Saver interface {
+	Save(key string) error
+}
+
+type MemStore struct{ items map[string]bool }
+
+func (m *MemStore) Save(key string) error { m.items[key] = true; return nil }
+
+func (m MemStore) Len() int { return len(m.items) }
+
+func helper() {}
+`,
+		"app/app.go": `package app
+
+import "example.com/demo/store"
+
+func Run(s store.Saver) error {
+	return s.Save("a")
+}
+
+func RunMem() {
+	m := &store.MemStore{}
+	_ = m.Save("b")
+	_ = Run(m)
+}
+`,
+	})
+	return &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+}
+
+func TestGoSymbolActions(t *testing.T) {
+	a := newGoSymbolsTestActions(t)
+
+	decls, _ := a.ListGoDeclarations(t.Context(), GoListDeclarationsParams{Package: "./store"})
+	if decls.Error != "" || len(decls.Packages) != 1 {
+		t.Fatalf("unexpected declarations result: %+v", decls)
+	}
+	names := map[string]bool{}
+	for _, d := range decls.Packages[0].Declarations {
+		names[d.Name] = true
+	}
+	for _, want := range []string{"Saver", "MemStore", "MemStore.Save", "MemStore.Len", "helper"} {
+		if !names[want] {
+			t.Errorf("expected declaration %s, got %v", want, names)
+		}
+	}
+
+	def, _ := a.FindGoDefinition(t.Context(), GoSymbolParams{Symbol: "MemStore.Save"})
+	if len(def.Definitions) != 1 || !strings.HasPrefix(def.Definitions[0].Location, "store/store.go:9:") {
+		t.Errorf("unexpected definition: %+v", def)
+	}
+
+	refs, _ := a.FindGoReferences(t.Context(), GoSymbolParams{Symbol: "store.MemStore.Save"})
+	if len(refs.References) != 1 || !strings.HasPrefix(refs.References[0].Location, "app/app.go:11:") {
+		t.Errorf("unexpected references: %+v", refs.References)
+	}
+
+	info, _ := a.GoTypeInfo(t.Context(), GoSymbolParams{Symbol: "Saver"})
+	if info.Kind != "interface" || len(info.Methods) != 1 {
+		t.Fatalf("unexpected type info: %+v", info)
+	}
+	if len(info.Implementations) != 1 || info.Implementations[0].Symbol != "MemStore" {
+		t.Errorf("expected MemStore to implement Saver, got %+v", info.Implementations)
+	}
+
+	mem, _ := a.GoTypeInfo(t.Context(), GoSymbolParams{Symbol: "MemStore"})
+	for _, m := range mem.Methods {
+		if m.Name == "Save" && !m.PointerReceiver {
+			t.Errorf("expected Save to require a pointer receiver")
+		}
+		if m.Name == "Len" && m.PointerReceiver {
+			t.Errorf("expected Len to be in the value method set")
+		}
+	}
+}
+
+func TestGoSymbolActions_RejectPatternsOutsideWorkspace(t *testing.T) {
+	a := newGoSymbolsTestActions(t)
+	for _, pattern := range []string{"-toolexec=sh", "/etc", "../..."} {
+		decls, _ := a.ListGoDeclarations(t.Context(), GoListDeclarationsParams{Package: pattern})
+		if !strings.Contains(decls.Error, "invalid package pattern") {
+			t.Errorf("expected %q to be rejected, got %+v", pattern, decls)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package actions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGoTestTimeout   = 10 * time.Minute
+	goTestMaxOutputBytes   = 8 * 1024 * 1024 // test2json streams are verbose; cap generously
+	maxTestFailureOutput   = 8 * 1024        // per failing test / package
+	goTestStatusPass       = "pass"
+	goTestStatusFail       = "fail"
+	goTestStatusSkip       = "skip"
+	goTestStatusIncomplete = "incomplete"
+)
+
+var coveragePattern = regexp.MustCompile(`coverage: ([0-9.]+)% of statements`)
+
+// RunGoTestsParams selects which tests to run.
+type RunGoTestsParams struct {
+	Packages       []string `json:"packages,omitempty" default:"[\"./...\"]" desc:"Package patterns"`
+	Run            string   `json:"run,omitempty" desc:"Regexp passed to go test -run"`
+	Coverage       bool     `json:"coverage,omitempty" desc:"Add -cover and report per-package coverage"`
+	IncludePassed  bool     `json:"include_passed,omitempty" desc:"List passing tests too (failures are always listed)"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" desc:"Overall timeout in seconds (default 600)"`
+}
+
+// GoTestCase is the result of a single test.
+type GoTestCase struct {
+	Package         string  `json:"package"`
+	Name            string  `json:"name"`
+	Status          string  `json:"status"` // pass, fail, skip, incomplete
+	DurationSeconds float64 `json:"duration_seconds"`
+	Output          string  `json:"output,omitempty"` // Only for failing tests
+}
+
+// GoTestPackageResult summarises one package.
+type GoTestPackageResult struct {
+	Package         string   `json:"package"`
+	Status          string   `json:"status"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	Passed          int      `json:"passed"`
+	Failed          int      `json:"failed"`
+	Skipped         int      `json:"skipped"`
+	CoveragePercent *float64 `json:"coverage_percent,omitempty"`
+	Output          string   `json:"output,omitempty"` // Package-level output (e.g. build errors) when it failed
+}
+
+type RunGoTestsResult struct {
+	Success  bool                  `json:"success"`
+	Command  string                `json:"command"`
+	Passed   int                   `json:"passed"`
+	Failed   int                   `json:"failed"`
+	Skipped  int                   `json:"skipped"`
+	Packages []GoTestPackageResult `json:"packages"`
+	Tests    []GoTestCase          `json:"tests"`
+	Stderr   string                `json:"stderr,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// testEvent mirrors cmd/test2json's output.
+type testEvent struct {
+	Action     string  `json:"Action"`
+	Package    string  `json:"Package"`
+	ImportPath string  `json:"ImportPath"` // build-output / build-fail events
+	Test       string  `json:"Test"`
+	Elapsed    float64 `json:"Elapsed"`
+	Output     string  `json:"Output"`
+}
+
+// RunGoTests runs `go test -json` in the workspace and returns per-test results.
+func (a *DataActions) RunGoTests(ctx context.Context, params RunGoTestsParams) (RunGoTestsResult, error) {
+	pkgs := params.Packages
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+	for _, p := range pkgs {
+		if err := checkPackagePattern(p); err != nil {
+			return RunGoTestsResult{Error: err.Error()}, nil
+		}
+	}
+	dir, err := a.resolveInWorkspace(".")
+	if err != nil {
+		return RunGoTestsResult{Error: err.Error()}, nil
+	}
+
+	args := []string{"test", "-json"}
+	if params.Run != "" {
+		args = append(args, "-run", params.Run)
+	}
+	if params.Coverage {
+		args = append(args, "-cover")
+	}
+	args = append(args, pkgs...)
+
+	timeout := defaultGoTestTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	run := a.runSandboxed(ctx, sandboxCommand{
+		Binary:         "go",
+		Args:           args,
+		Dir:            dir,
+		Timeout:        timeout,
+		MaxOutputBytes: goTestMaxOutputBytes,
+	})
+
+	result := parseGoTestJSON(strings.NewReader(run.Stdout), params.IncludePassed)
+	result.Command = run.Command
+	result.Stderr = run.Stderr
+	result.Error = run.Error
+	if run.Truncated {
+		result.Error = strings.TrimSpace(result.Error + " test output exceeded the capture limit; results are incomplete")
+	}
+	result.Success = run.ExitCode == 0 && result.Failed == 0 && result.Error == ""
+	return result, nil
+}
+
+// checkPackagePattern rejects package patterns that read as flags or leave the workspace.
+func checkPackagePattern(pattern string) error {
+	if strings.HasPrefix(pattern, "-") || containsParentDir(pattern) {
+		return fmt.Errorf("invalid package pattern %q", pattern)
+	}
+	return nil
+}
+
+// containsParentDir reports whether a package pattern walks out of the workspace.
+func containsParentDir(pattern string) bool {
+	if strings.HasPrefix(pattern, "/") {
+		return true
+	}
+	for _, part := range strings.Split(pattern, "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoTestJSON folds a test2json stream into per-package and per-test results.
+func parseGoTestJSON(r io.Reader, includePassed bool) RunGoTestsResult {
+	type testState struct {
+		tc     GoTestCase
+		output strings.Builder
+	}
+	type pkgState struct {
+		res    GoTestPackageResult
+		output strings.Builder
+	}
+	tests := map[string]*testState{}
+	var testOrder []string
+	pkgs := map[string]*pkgState{}
+	var pkgOrder []string
+
+	getPkg := func(name string) *pkgState {
+		ps, ok := pkgs[name]
+		if !ok {
+			ps = &pkgState{res: GoTestPackageResult{Package: name, Status: goTestStatusIncomplete}}
+			pkgs[name] = ps
+			pkgOrder = append(pkgOrder, name)
+		}
+		return ps
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var ev testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			continue
+		}
+		pkgName := ev.Package
+		if pkgName == "" {
+			pkgName = ev.ImportPath
+		}
+		if pkgName == "" {
+			continue
+		}
+		// build-output events carry the test variant, e.g. "pkg [pkg.test]".
+		pkgName = strings.SplitN(pkgName, " ", 2)[0]
+		ps := getPkg(pkgName)
+
+		if ev.Test == "" {
+			switch ev.Action {
+			case "output", "build-output":
+				appendCapped(&ps.output, ev.Output)
+				if m := coveragePattern.FindStringSubmatch(ev.Output); m != nil {
+					if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+						ps.res.CoveragePercent = &v
+					}
+				}
+			case "build-fail":
+				ps.res.Status = goTestStatusFail
+			case goTestStatusPass, goTestStatusFail, goTestStatusSkip:
+				ps.res.Status = ev.Action
+				ps.res.DurationSeconds = ev.Elapsed
+			}
+			continue
+		}
+
+		key := pkgName + "\x00" + ev.Test
+		ts, ok := tests[key]
+		if !ok {
+			ts = &testState{tc: GoTestCase{Package: pkgName, Name: ev.Test, Status: goTestStatusIncomplete}}
+			tests[key] = ts
+			testOrder = append(testOrder, key)
+		}
+		switch ev.Action {
+		case "output":
+			appendCapped(&ts.output, ev.Output)
+		case goTestStatusPass, goTestStatusFail, goTestStatusSkip:
+			ts.tc.Status = ev.Action
+			ts.tc.DurationSeconds = ev.Elapsed
+		}
+	}
+
+	result := RunGoTestsResult{Packages: []GoTestPackageResult{}, Tests: []GoTestCase{}}
+	for _, key := range testOrder {
+		ts := tests[key]
+		ps := getPkg(ts.tc.Package)
+		switch ts.tc.Status {
+		case goTestStatusPass:
+			ps.res.Passed++
+			result.Passed++
+		case goTestStatusSkip:
+			ps.res.Skipped++
+			result.Skipped++
+		default:
+			ps.res.Failed++
+			result.Failed++
+		}
+		if ts.tc.Status == goTestStatusFail || ts.tc.Status == goTestStatusIncomplete {
+			ts.tc.Output = ts.output.String()
+		} else if !includePassed {
+			continue
+		}
+		result.Tests = append(result.Tests, ts.tc)
+	}
+	for _, name := range pkgOrder {
+		ps := pkgs[name]
+		if ps.res.Status == goTestStatusFail && ps.res.Failed == 0 {
+			// Build failures and panics outside a test surface only at package level.
+			ps.res.Output = ps.output.String()
+		}
+		result.Packages = append(result.Packages, ps.res)
+	}
+	sort.SliceStable(result.Tests, func(i, j int) bool {
+		return result.Tests[i].Status == goTestStatusFail && result.Tests[j].Status != goTestStatusFail
+	})
+	return result
+}
+
+func appendCapped(sb *strings.Builder, s string) {
+	if sb.Len() >= maxTestFailureOutput {
+		return
+	}
+	if sb.Len()+len(s) > maxTestFailureOutput {
+		s = s[:maxTestFailureOutput-sb.Len()] + "\n... (output truncated)\n"
+	}
+	sb.WriteString(s)
+}
@@ -0,0 +1,59 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGoTestJSON(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"Action":"start","Package":"example.com/demo/ok"}`,
+		`{"Action":"run","Package":"example.com/demo/ok","Test":"TestPass"}`,
+		`{"Action":"output","Package":"example.com/demo/ok","Test":"TestPass","Output":"=== RUN   TestPass\n"}`,
+		`{"Action":"pass","Package":"example.com/demo/ok","Test":"TestPass","Elapsed":0.01}`,
+		`{"Action":"run","Package":"example.com/demo/ok","Test":"TestSkip"}`,
+		`{"Action":"skip","Package":"example.com/demo/ok","Test":"TestSkip","Elapsed":0}`,
+		`{"Action":"run","Package":"example.com/demo/ok","Test":"TestFail"}`,
+		`{"Action":"output","Package":"example.com/demo/ok","Test":"TestFail","Output":"    ok_test.go:9: boom\n"}`,
+		`{"Action":"fail","Package":"example.com/demo/ok","Test":"TestFail","Elapsed":0.02}`,
+		`{"Action":"output","Package":"example.com/demo/ok","Output":"coverage: 62.5% of statements\n"}`,
+		`{"Action":"fail","Package":"example.com/demo/ok","Elapsed":0.3}`,
+		`{"ImportPath":"example.com/demo/broken [example.com/demo/broken.test]","Action":"build-output","Output":"broken.go:3:1: syntax error\n"}`,
+		`{"ImportPath":"example.com/demo/broken [example.com/demo/broken.test]","Action":"build-fail"}`,
+		`{"Action":"output","Package":"example.com/demo/broken","Output":"FAIL\texample.com/demo/broken [build failed]\n"}`,
+		`{"Action":"fail","Package":"example.com/demo/broken","Elapsed":0}`,
+		`not json`,
+	}, "\n")
+
+	res := parseGoTestJSON(strings.NewReader(stream), false)
+	if res.Passed != 1 || res.Failed != 1 || res.Skipped != 1 {
+		t.Fatalf("unexpected counts: %+v", res)
+	}
+	if len(res.Tests) != 1 || res.Tests[0].Name != "TestFail" || !strings.Contains(res.Tests[0].Output, "boom") {
+		t.Fatalf("expected only the failing test with its output, got %+v", res.Tests)
+	}
+	if len(res.Packages) != 2 {
+		t.Fatalf("expected 2 packages, got %+v", res.Packages)
+	}
+	ok, broken := res.Packages[0], res.Packages[1]
+	if ok.CoveragePercent == nil || *ok.CoveragePercent != 62.5 || ok.Output != "" {
+		t.Errorf("unexpected package result: %+v", ok)
+	}
+	if broken.Package != "example.com/demo/broken" || broken.Status != goTestStatusFail || !strings.Contains(broken.Output, "syntax error") {
+		t.Errorf("expected build failure output on broken package, got %+v", broken)
+	}
+
+	all := parseGoTestJSON(strings.NewReader(stream), true)
+	if len(all.Tests) != 3 || all.Tests[0].Status != goTestStatusFail {
+		t.Errorf("expected all tests with failures first, got %+v", all.Tests)
+	}
+}
+
+func TestRunGoTests_RejectsPatternsOutsideWorkspace(t *testing.T) {
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+	for _, p := range []string{"../other/...", "/etc", "-exec=sh"} {
+		if res, _ := a.RunGoTests(t.Context(), RunGoTestsParams{Packages: []string{p}}); res.Error == "" {
+			t.Errorf("expected %q to be rejected", p)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package actions
+
+import (
+	"astra/astra/sources/psql/models"
+	"astra/astra/utils/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxRecordedResult caps the result stored with an execution; bigger results keep a preview.
+const maxRecordedResult = 64 << 10
+
+// executionRecorder persists execution records; *dao.ActionExecutionDAO is the real one.
+type executionRecorder interface {
+	CreateActionExecution(ctx context.Context, exec *models.ActionExecution) error
+}
+
+// executionTrace collects what happens inside one call for its execution record.
+type executionTrace struct {
+	mu       sync.Mutex
+	params   json.RawMessage
+	approval string
+	approver string
+}
+
+type executionTraceKey struct{}
+
+func executionTraceFromContext(ctx context.Context) *executionTrace {
+	trace, _ := ctx.Value(executionTraceKey{}).(*executionTrace)
+	return trace
+}
+
+// setParams notes the params the action actually ran with.
+func (t *executionTrace) setParams(raw json.RawMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.params = raw
+}
+
+// noteApproval records an approval decision; one rejection marks the whole call rejected.
+func (t *executionTrace) noteApproval(approved bool, approver string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.approval == "rejected" {
+		return
+	}
+	t.approval, t.approver = "approved", approver
+	if !approved {
+		t.approval = "rejected"
+	}
+}
+
+// approverName names who answers approval requests: the user on the run's channel, or
+// nobody when no gate is set and requests are denied outright.
+func (a *DataActions) approverName() string {
+	if a.approvalGate == nil {
+		return ""
+	}
+	if a.Channel == "" {
+		return fmt.Sprintf("user %d", a.UserID)
+	}
+	return fmt.Sprintf("user %d via %s", a.UserID, a.Channel)
+}
+
+// recordExecution stores the outcome of a call. Failing to store it is logged, never
+// returned: the action has already run.
+func (a *DataActions) recordExecution(ctx context.Context, info ActionInfo, trace *executionTrace, params json.RawMessage, started time.Time, result map[string]interface{}, err error) {
+	if a.executions == nil {
+		return
+	}
+	trace.mu.Lock()
+	if trace.params != nil {
+		params = trace.params
+	}
+	exec := &models.ActionExecution{
+		RunID:      info.RunID,
+		SessionID:  info.SessionID,
+		UserID:     info.UserID,
+		Agent:      info.Agent,
+		Channel:    info.Channel,
+		Action:     info.Action,
+		Params:     params,
+		Status:     models.ExecutionSucceeded,
+		DurationMs: time.Since(started).Milliseconds(),
+		Approval:   trace.approval,
+		Approver:   trace.approver,
+		CreatedAt:  started,
+	}
+	trace.mu.Unlock()
+
+	var policyErr *PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		exec.Status, exec.Error = models.ExecutionDenied, err.Error()
+	case err != nil:
+		exec.Status, exec.Error = models.ExecutionFailed, err.Error()
+	default:
+		exec.Result = recordedResult(result)
+		if msg := resultFailure(result); msg != "" {
+			exec.Status, exec.Error = models.ExecutionFailed, msg
+		}
+	}
+
+	// Record cancelled runs too; the caller's deadline may be what ended them.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := a.executions.CreateActionExecution(writeCtx, exec); err != nil {
+		logging.ErrorLogger.Error("failed to record action execution", zap.String("action", info.Action), zap.Error(err))
+	}
+}
+
+// resultFailure returns why a result that came back without an error still reports failure.
+func resultFailure(result map[string]interface{}) string {
+	if msg, ok := result["error"].(string); ok && msg != "" {
+		return msg
+	}
+	if ok, present := result["success"].(bool); present && !ok {
+		return "action reported success: false"
+	}
+	return ""
+}
+
+func recordedResult(result map[string]interface{}) json.RawMessage {
+	if result == nil {
+		return nil
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil
+	}
+	if len(b) <= maxRecordedResult {
+		return b
+	}
+	preview, _ := json.Marshal(map[string]interface{}{
+		"truncated": true,
+		"bytes":     len(b),
+		"preview":   strings.ToValidUTF8(string(b[:maxRecordedResult]), ""),
+	})
+	return preview
+}
@@ -0,0 +1,57 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"astra/astra/sources/psql/models"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+type recordedExecutions []*models.ActionExecution
+
+func (r *recordedExecutions) CreateActionExecution(_ context.Context, exec *models.ActionExecution) error {
+	*r = append(*r, exec)
+	return nil
+}
+
+func TestExecuteAction_RecordsExecutions(t *testing.T) {
+	var recorded recordedExecutions
+	a := &DataActions{actions: make(map[string]ActionSpec), UserID: 3, SessionID: "s1", Channel: ChannelCLI, Workspace: t.TempDir(), executions: &recorded}
+	type params struct {
+		Path  string `json:"path"`
+		Lines int    `json:"lines" default:"20"`
+	}
+	Register(a, ActionSpec{Name: "read"}, func(_ context.Context, p params) (map[string]interface{}, error) {
+		if p.Path == "missing" {
+			return map[string]interface{}{"error": "no such file"}, nil
+		}
+		return map[string]interface{}{"lines": p.Lines}, nil
+	})
+	a.SetApprovalGate(func(ApprovalRequest) bool { return false })
+	if err := a.SetPolicy(configs.PolicyConfig{Rules: []configs.PolicyRule{{RequireApproval: []string{"read"}, Users: []int{4}}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRunID(t.Context(), "run-1")
+	_, _ = a.ExecuteActionContext(ctx, "read", map[string]interface{}{"path": "main.go"})
+	_, _ = a.ExecuteActionContext(ctx, "read", map[string]interface{}{"path": "missing"})
+	a.UserID = 4
+	_, _ = a.ExecuteActionContext(ctx, "read", map[string]interface{}{"path": "main.go"})
+
+	if len(recorded) != 3 {
+		t.Fatalf("expected 3 executions, got %d", len(recorded))
+	}
+	ok := recorded[0]
+	var p map[string]interface{}
+	_ = json.Unmarshal(ok.Params, &p)
+	if ok.Status != models.ExecutionSucceeded || ok.RunID != "run-1" || ok.SessionID != "s1" || ok.Channel != ChannelCLI || p["lines"] != float64(20) {
+		t.Errorf("expected a success recorded with validated params, got %+v (params %s)", ok, ok.Params)
+	}
+	if failed := recorded[1]; failed.Status != models.ExecutionFailed || failed.Error != "no such file" {
+		t.Errorf("expected the result error to mark the call failed, got %+v", failed)
+	}
+	if denied := recorded[2]; denied.Status != models.ExecutionDenied || denied.Approval != "rejected" || denied.Approver != "user 4 via cli" || denied.UserID != 4 {
+		t.Errorf("expected the rejected approval to be recorded, got %+v", denied)
+	}
+}
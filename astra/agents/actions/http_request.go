@@ -0,0 +1,294 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHTTPRequestTimeout   = 30 * time.Second
+	defaultMaxHTTPResponseBytes = 64 * 1024
+	maxHTTPRequestBodyBytes     = 1 << 20
+	redactedSecret              = "[REDACTED]"
+)
+
+// secretRefPattern matches a secret reference in a header value, e.g. {{secrets.api_token}}.
+var secretRefPattern = regexp.MustCompile(`\{\{\s*secrets\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// HTTPRequestParams describes one HTTP call.
+type HTTPRequestParams struct {
+	Method         string            `json:"method,omitempty" enum:"GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS" default:"GET" desc:"HTTP method"`
+	URL            string            `json:"url" required:"true" desc:"Absolute http(s) URL, e.g. http://localhost:8000/health"`
+	Headers        map[string]string `json:"headers,omitempty" desc:"Request headers; reference configured secrets as {{secrets.NAME}}"`
+	Body           json.RawMessage   `json:"body,omitempty" desc:"Request body: strings are sent as is, any other JSON value is sent as JSON"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty" desc:"Timeout in seconds, capped by the agent's configured timeout"`
+}
+
+// HTTPRequestResult holds the response. JSON responses are decoded into JSON; anything
+// else is returned as text in Body. Secret values are redacted from the response.
+type HTTPRequestResult struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Status      int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	JSON        interface{}       `json:"json,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	Truncated   bool              `json:"truncated,omitempty"`
+	DurationMs  int64             `json:"duration_ms"`
+	Approved    bool              `json:"approved_by_user,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// SetHTTPRequestPolicy applies the agent's http_request configuration and lists the
+// allowed hosts and secret names in the action's details for the planner.
+func (a *DataActions) SetHTTPRequestPolicy(cfg configs.HTTPRequestConfig) {
+	a.httpPolicy = cfg
+	spec, ok := a.actions["http_request"]
+	if !ok {
+		return
+	}
+	spec.Details = httpRequestDetails + a.describeHTTPPolicy()
+	a.actions[spec.Name] = spec
+}
+
+func (a *DataActions) describeHTTPPolicy() string {
+	hosts := "none"
+	if len(a.httpPolicy.AllowedHosts) > 0 {
+		hosts = strings.Join(a.httpPolicy.AllowedHosts, ", ")
+	}
+	names := make([]string, 0, len(a.httpPolicy.Secrets))
+	for name := range a.httpPolicy.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	secrets := "none"
+	if len(names) > 0 {
+		secrets = strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("\n\t\t\t**Allowed hosts:** %s\n\t\t\t**Available secrets:** %s\n", hosts, secrets)
+}
+
+// HTTPRequest performs an HTTP call. Hosts outside the allowlist need user approval and
+// never receive secrets; redirects are returned instead of followed.
+func (a *DataActions) HTTPRequest(ctx context.Context, p HTTPRequestParams) (HTTPRequestResult, error) {
+	method := strings.ToUpper(strings.TrimSpace(p.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	res := HTTPRequestResult{Method: method, URL: p.URL}
+	fail := func(format string, args ...interface{}) HTTPRequestResult {
+		res.Error = fmt.Sprintf(format, args...)
+		return res
+	}
+
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fail("invalid url %q: an absolute http(s) URL is required", p.URL), nil
+	}
+	if u.User != nil {
+		return fail("credentials in the url are not allowed; use a header with {{secrets.NAME}}"), nil
+	}
+
+	body, contentType, err := httpRequestBody(p.Body)
+	if err != nil {
+		return fail("%v", err), nil
+	}
+
+	allowed := a.httpHostAllowed(u)
+	headers := make(http.Header, len(p.Headers))
+	for k, v := range p.Headers {
+		expanded, err := a.expandSecrets(v, allowed)
+		if err != nil {
+			return fail("header %s: %v", k, err), nil
+		}
+		headers.Set(k, expanded)
+	}
+	if contentType != "" && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", contentType)
+	}
+
+	if !allowed {
+		res.Approved = a.requestApproval(ctx, ApprovalRequest{
+			Action: "http_request",
+			Reason: fmt.Sprintf("host %q is not in the http_request allowlist", u.Host),
+			Params: map[string]interface{}{"method": method, "url": u.String(), "headers": p.Headers},
+		})
+		if !res.Approved {
+			return fail("request denied: host %q is not in the http_request allowlist", u.Host), nil
+		}
+	}
+
+	timeout := defaultHTTPRequestTimeout
+	if a.httpPolicy.TimeoutSeconds > 0 {
+		timeout = time.Duration(a.httpPolicy.TimeoutSeconds) * time.Second
+	}
+	if p.TimeoutSeconds > 0 && time.Duration(p.TimeoutSeconds)*time.Second < timeout {
+		timeout = time.Duration(p.TimeoutSeconds) * time.Second
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return fail("%v", err), nil
+	}
+	req.Header = headers
+
+	client := &http.Client{
+		Timeout:       timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	res.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return fail("%s", a.redactSecrets(fmt.Sprintf("request failed: %v", err))), nil
+	}
+	defer resp.Body.Close()
+
+	limit := a.httpPolicy.MaxResponseBytes
+	if limit <= 0 {
+		limit = defaultMaxHTTPResponseBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	res.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return fail("failed to read response: %v", err), nil
+	}
+	if len(data) > limit {
+		data, res.Truncated = data[:limit], true
+	}
+
+	res.Status = resp.StatusCode
+	res.ContentType = resp.Header.Get("Content-Type")
+	res.Headers = make(map[string]string, len(resp.Header))
+	for k, v := range resp.Header {
+		res.Headers[k] = a.redactSecrets(strings.Join(v, ", "))
+	}
+	text := a.redactSecrets(string(data))
+	var parsed interface{}
+	if !res.Truncated && isJSONContent(res.ContentType, data) && json.Unmarshal([]byte(text), &parsed) == nil {
+		res.JSON = parsed
+	} else {
+		res.Body = text
+	}
+	return res, nil
+}
+
+// httpRequestBody encodes the body param and returns the content type it implies.
+func httpRequestBody(raw json.RawMessage) ([]byte, string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, "", nil
+	}
+	if len(raw) > maxHTTPRequestBodyBytes {
+		return nil, "", fmt.Errorf("request body exceeds %d bytes", maxHTTPRequestBodyBytes)
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []byte(s), "text/plain; charset=utf-8", nil
+	}
+	return raw, "application/json", nil
+}
+
+func isJSONContent(contentType string, data []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(data)
+	return contentType == "" && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// httpHostAllowed reports whether u's host matches an allowlist entry.
+func (a *DataActions) httpHostAllowed(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	for _, entry := range a.httpPolicy.AllowedHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		entryHost, entryPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			entryHost, entryPort = strings.Trim(entry, "[]"), ""
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryHost == host || (strings.HasPrefix(entryHost, "*.") && strings.HasSuffix(host, entryHost[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandSecrets replaces {{secrets.NAME}} references with configured secret values.
+// Secrets are only released to allowlisted hosts.
+func (a *DataActions) expandSecrets(value string, allowed bool) (string, error) {
+	var err error
+	expanded := secretRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := secretRefPattern.FindStringSubmatch(ref)[1]
+		secret, ok := a.httpPolicy.Secrets[name]
+		switch {
+		case err != nil:
+		case !ok:
+			err = fmt.Errorf("unknown secret %q", name)
+		case !allowed:
+			err = fmt.Errorf("secret %q can only be sent to allowlisted hosts", name)
+		default:
+			return os.ExpandEnv(secret)
+		}
+		return ref
+	})
+	return expanded, err
+}
+
+// redactSecrets hides any configured secret value that appears in s, e.g. in an echo endpoint.
+func (a *DataActions) redactSecrets(s string) string {
+	for _, secret := range a.httpPolicy.Secrets {
+		if v := os.ExpandEnv(secret); v != "" {
+			s = strings.ReplaceAll(s, v, redactedSecret)
+		}
+	}
+	return s
+}
+
+const httpRequestDetails = `
+			# 🌐 Astra HTTP Request Action
+
+			Calls an HTTP API, e.g. to verify endpoints of the service you just changed.
+
+			- Hosts in the agent's allowlist are called directly; any other host needs user
+			  approval. Redirects are returned, not followed.
+			- Authenticate with configured secrets by name: "Authorization": "Bearer {{secrets.NAME}}".
+			  You never see secret values; they are only sent to allowlisted hosts and are
+			  redacted from responses.
+			- "body" may be any JSON value (sent as application/json) or a string (sent as is).
+			- JSON responses are decoded into "json"; other responses are returned as text in
+			  "body". Responses are size-limited; "truncated" is set when cut.
+
+			**Usage Example**
+			{
+				"action": "http_request",
+				"action_params": {
+					"method": "POST",
+					"url": "http://localhost:8000/notes/",
+					"headers": {"Authorization": "Bearer {{secrets.astra_token}}"},
+					"body": {"title": "hello", "content": "from astra"}
+				}
+			}
+
+			**Output**
+			{ "method": "POST", "url": "...", "status": 201, "headers": {...}, "json": {...}, "duration_ms": 12 }
+`
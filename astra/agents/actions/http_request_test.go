@@ -0,0 +1,73 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHTTPRequest_AllowlistSecretsAndJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": r.Header.Get("Authorization"), "body": body})
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+	a.SetHTTPRequestPolicy(configs.HTTPRequestConfig{
+		AllowedHosts: []string{u.Host},
+		Secrets:      map[string]string{"token": "s3cret"},
+	})
+
+	res, _ := a.HTTPRequest(t.Context(), HTTPRequestParams{
+		Method:  "POST",
+		URL:     srv.URL + "/echo",
+		Headers: map[string]string{"Authorization": "Bearer {{secrets.token}}"},
+		Body:    json.RawMessage(`{"title":"hi"}`),
+	})
+	if res.Error != "" || res.Status != http.StatusOK {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	got, _ := res.JSON.(map[string]interface{})
+	if got["auth"] != "Bearer [REDACTED]" || got["body"].(map[string]interface{})["title"] != "hi" {
+		t.Errorf("expected a decoded JSON echo with the secret redacted, got %v", res.JSON)
+	}
+
+	if res, _ := a.HTTPRequest(t.Context(), HTTPRequestParams{URL: srv.URL, Headers: map[string]string{"X": "{{secrets.missing}}"}}); !strings.Contains(res.Error, "unknown secret") {
+		t.Errorf("expected unknown secrets to be rejected, got %+v", res)
+	}
+
+	other := "http://localhost:1/"
+	if res, _ := a.HTTPRequest(t.Context(), HTTPRequestParams{URL: other}); !strings.Contains(res.Error, "denied") {
+		t.Errorf("expected non-allowlisted hosts to be denied without approval, got %+v", res)
+	}
+	a.SetApprovalGate(func(ApprovalRequest) bool { return true })
+	if res, _ := a.HTTPRequest(t.Context(), HTTPRequestParams{URL: other, Headers: map[string]string{"Authorization": "{{secrets.token}}"}}); !strings.Contains(res.Error, "only be sent to allowlisted hosts") {
+		t.Errorf("expected secrets to stay away from non-allowlisted hosts, got %+v", res)
+	}
+}
+
+func TestHTTPHostAllowed(t *testing.T) {
+	a := &DataActions{httpPolicy: configs.HTTPRequestConfig{AllowedHosts: []string{"localhost:8000", "api.internal", "*.example.com"}}}
+	cases := map[string]bool{
+		"http://localhost:8000/x":      true,
+		"http://localhost:8001/x":      false,
+		"http://api.internal:9000/":    true,
+		"https://svc.example.com/":     true,
+		"https://example.com.evil.io/": false,
+		"https://evilexample.com/":     false,
+	}
+	for raw, want := range cases {
+		u, _ := url.Parse(raw)
+		if got := a.httpHostAllowed(u); got != want {
+			t.Errorf("httpHostAllowed(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package actions
 
 import (
+	"astra/astra/services/knowledge"
 	"astra/astra/sources/psql/models"
 	"context"
 	"fmt"
@@ -10,49 +11,70 @@ import (
 )
 
 type CreateLongTermKnowledgeParams struct {
-	KnowledgeType string `json:"knowledge_type"`
-	KnowledgeBlob string `json:"knowledge_blob"`
+	KnowledgeType string  `json:"knowledge_type" required:"true" desc:"Category, e.g. user_preference or project_fact"`
+	KnowledgeBlob string  `json:"knowledge_blob" required:"true" desc:"The knowledge to remember"`
+	Source        string  `json:"source,omitempty" desc:"URL or file the knowledge came from"`
+	Confidence    float64 `json:"confidence,omitempty" default:"1" desc:"How sure you are, from 0 to 1"`
+	OnDuplicate   string  `json:"on_duplicate,omitempty" enum:"merge,reject,allow" default:"merge" desc:"What to do when a near-identical entry of the same type exists"`
 }
 
 type UpdateLongTermKnowledgeParams struct {
-	Id      string                 `json:"id"`
-	Updates map[string]interface{} `json:"updates"`
+	Id            string   `json:"id" required:"true" desc:"Knowledge ID (uuid)"`
+	KnowledgeType *string  `json:"knowledge_type,omitempty" desc:"New category"`
+	KnowledgeBlob *string  `json:"knowledge_blob,omitempty" desc:"New text, replacing the old one"`
+	Source        *string  `json:"source,omitempty" desc:"New source URL or file"`
+	Confidence    *float64 `json:"confidence,omitempty" desc:"New confidence, from 0 to 1"`
+}
+
+type DeleteLongTermKnowledgeParams struct {
+	Id string `json:"id" required:"true" desc:"Knowledge ID (uuid)"`
 }
 
 type GetAllLongTermKnowledgeByTypeParams struct {
-	KnowledgeType string `json:"knowledge_type"`
+	KnowledgeType string `json:"knowledge_type" required:"true" desc:"Category to fetch"`
 }
 
-func (a *DataActions) CreateLongTermKnowledgeAction(p CreateLongTermKnowledgeParams) error {
+func (a *DataActions) CreateLongTermKnowledgeAction(ctx context.Context, p CreateLongTermKnowledgeParams) (*knowledge.CreateResult, error) {
 	ltk := models.LongTermKnowledge{
 		UserID:        a.UserID,
 		KnowledgeType: p.KnowledgeType,
 		KnowledgeBlob: p.KnowledgeBlob,
+		SessionID:     a.SessionID,
+		Source:        p.Source,
+		Confidence:    p.Confidence,
 	}
-	ctx := context.Background()
-	return a.longTermKnowledgeDao.CreateLongTermKnowledge(ctx, &ltk)
+	return a.knowledge.Create(ctx, &ltk, knowledge.DuplicatePolicy(p.OnDuplicate))
+}
+
+func (a *DataActions) UpdateLongTermKnowledgeAction(ctx context.Context, p UpdateLongTermKnowledgeParams) (*models.LongTermKnowledge, error) {
+	id, err := uuid.Parse(p.Id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uuid: %w", err)
+	}
+	return a.knowledge.Update(ctx, a.UserID, id, knowledge.Update{
+		KnowledgeType: p.KnowledgeType,
+		KnowledgeBlob: p.KnowledgeBlob,
+		Source:        p.Source,
+		Confidence:    p.Confidence,
+	})
 }
 
-func (a *DataActions) UpdateLongTermKnowledgeAction(p UpdateLongTermKnowledgeParams) error {
+func (a *DataActions) DeleteLongTermKnowledgeAction(ctx context.Context, p DeleteLongTermKnowledgeParams) (*models.LongTermKnowledge, error) {
 	id, err := uuid.Parse(p.Id)
 	if err != nil {
-		return fmt.Errorf("invalid uuid: %w", err)
+		return nil, fmt.Errorf("invalid uuid: %w", err)
 	}
-	ctx := context.Background()
-	return a.longTermKnowledgeDao.UpdateLongTermKnowledge(ctx, id, p.Updates)
+	return a.knowledge.Delete(ctx, a.UserID, id)
 }
 
-func (a *DataActions) GetAllLongTermKnowledgeForUserAction() ([]models.LongTermKnowledge, error) {
-	ctx := context.Background()
+func (a *DataActions) GetAllLongTermKnowledgeForUserAction(ctx context.Context, _ NoParams) ([]models.LongTermKnowledge, error) {
 	return a.longTermKnowledgeDao.GetAllLongTermKnowledgeByUser(ctx, a.UserID)
 }
 
-func (a *DataActions) GetAllLongTermKnowledgeForUserByTypeAction(p GetAllLongTermKnowledgeByTypeParams) ([]models.LongTermKnowledge, error) {
-	ctx := context.Background()
+func (a *DataActions) GetAllLongTermKnowledgeForUserByTypeAction(ctx context.Context, p GetAllLongTermKnowledgeByTypeParams) ([]models.LongTermKnowledge, error) {
 	return a.longTermKnowledgeDao.GetLongTermKnowledgeByKnowledgeType(ctx, a.UserID, p.KnowledgeType)
 }
 
-func (a *DataActions) GetAllKnowledgeTypesForUser() ([]string, error) {
-	ctx := context.Background()
+func (a *DataActions) GetAllKnowledgeTypesForUser(ctx context.Context, _ NoParams) ([]string, error) {
 	return a.longTermKnowledgeDao.GetDistinctKnowledgeTypes(ctx, a.UserID)
 }
@@ -0,0 +1,341 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"astra/astra/services/mcp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMCPCallTimeout = 60 * time.Second
+	mcpActionPrefix       = "mcp_"
+)
+
+var nonIdentChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// mcpServerPool keeps one connection per configured MCP server for the whole process.
+// Servers are started once (StartMCPServers) and shared by every agent. A connection
+// whose process died (or whose config changed) is restarted on next use.
+type mcpServerPool struct {
+	mu      sync.Mutex
+	entries map[string]*mcpPoolEntry
+}
+
+// mcpPoolEntry serializes connects to one server without blocking the others.
+type mcpPoolEntry struct {
+	mu     sync.Mutex
+	server *mcpServer
+}
+
+type mcpServer struct {
+	cfg    configs.MCPServerConfig
+	env    []string
+	client *mcp.Client
+	tools  []mcp.Tool
+}
+
+var mcpPool = &mcpServerPool{entries: make(map[string]*mcpPoolEntry)}
+
+func (p *mcpServerPool) entry(name string) *mcpPoolEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[name]
+	if !ok {
+		e = &mcpPoolEntry{}
+		p.entries[name] = e
+	}
+	return e
+}
+
+// get returns the connected server, connecting (or reconnecting) it when needed.
+func (p *mcpServerPool) get(ctx context.Context, cfg configs.MCPServerConfig, env []string) (*mcpServer, error) {
+	e := p.entry(cfg.Name)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if s := e.server; s != nil {
+		if s.client.Alive() && reflect.DeepEqual(s.cfg, cfg) && slices.Equal(s.env, env) {
+			return s, nil
+		}
+		_ = s.client.Close()
+		e.server = nil
+	}
+
+	client, err := connectMCPServer(ctx, cfg, env)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("list tools of %s: %w", cfg.Name, err)
+	}
+	e.server = &mcpServer{cfg: cfg, env: env, client: client, tools: tools}
+	return e.server, nil
+}
+
+// running returns the server if it is connected, without connecting it.
+func (p *mcpServerPool) running(name string) *mcpServer {
+	e := p.entry(name)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.server == nil || !e.server.client.Alive() {
+		return nil
+	}
+	return e.server
+}
+
+func (p *mcpServerPool) closeAll() {
+	p.mu.Lock()
+	entries := p.entries
+	p.entries = make(map[string]*mcpPoolEntry)
+	p.mu.Unlock()
+	for _, e := range entries {
+		e.mu.Lock()
+		if e.server != nil {
+			_ = e.server.client.Close()
+			e.server = nil
+		}
+		e.mu.Unlock()
+	}
+}
+
+func connectMCPServer(ctx context.Context, cfg configs.MCPServerConfig, env []string) (*mcp.Client, error) {
+	var client *mcp.Client
+	switch {
+	case cfg.Command != "" && cfg.URL != "":
+		return nil, fmt.Errorf("mcp server %s: set either command or url, not both", cfg.Name)
+	case cfg.Command != "":
+		c, err := mcp.NewStdioClient(cfg.Name, cfg.Command, cfg.Args, env, cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		client = c
+	case cfg.URL != "":
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers[k] = os.ExpandEnv(v)
+		}
+		client = mcp.NewHTTPClient(cfg.Name, cfg.URL, headers)
+	default:
+		return nil, fmt.Errorf("mcp server %s: command or url is required", cfg.Name)
+	}
+	if _, err := client.Initialize(ctx); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// prepareMCPServer resolves a stdio server's relative dir against the workspace and
+// builds its environment from the run_command passthrough list plus the server's own
+// env, so API keys in Astra's environment never reach it.
+func (a *DataActions) prepareMCPServer(cfg configs.MCPServerConfig) (configs.MCPServerConfig, []string, error) {
+	if cfg.Name == "" {
+		return cfg, nil, errors.New("mcp server without a name")
+	}
+	if cfg.Command == "" {
+		return cfg, nil, nil
+	}
+	if !filepath.IsAbs(cfg.Dir) {
+		dir, err := a.resolveInWorkspace(cfg.Dir)
+		if err != nil {
+			return cfg, nil, fmt.Errorf("mcp server %s: %w", cfg.Name, err)
+		}
+		cfg.Dir = dir
+	}
+	env := a.filteredEnv()
+	keys := make([]string, 0, len(cfg.Env))
+	for k := range cfg.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+os.ExpandEnv(cfg.Env[k]))
+	}
+	return cfg, env, nil
+}
+
+// StartMCPServers connects the MCP servers of the agent config in parallel. Call it
+// once at startup; agents then register the tools of the running servers. Relative
+// stdio dirs are resolved against the current directory, the default workspace.
+func StartMCPServers(ctx context.Context, cfg *configs.AgentConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	a := &DataActions{}
+	a.SetCommandPolicy(cfg.RunCommand)
+	var wg sync.WaitGroup
+	errs := make([]error, len(cfg.MCPServers))
+	for i, server := range cfg.MCPServers {
+		if server.Disabled {
+			continue
+		}
+		server, env, err := a.prepareMCPServer(server)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = mcpPool.get(ctx, server, env)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// CloseMCPServers stops every MCP server process started by this process.
+// Call it on shutdown.
+func CloseMCPServers() {
+	mcpPool.closeAll()
+}
+
+// MCPToolInfo describes a tool imported from an MCP server.
+type MCPToolInfo struct {
+	Server      string `json:"server"`
+	Action      string `json:"action"` // Name the tool is registered under
+	Tool        string `json:"tool"`
+	Description string `json:"description"`
+}
+
+// ListMCPTools starts the configured servers and lists the tools they would
+// contribute as actions. Servers that fail are reported in the joined error.
+func ListMCPTools(ctx context.Context, cfg *configs.AgentConfig) ([]MCPToolInfo, error) {
+	err := StartMCPServers(ctx, cfg)
+	var infos []MCPToolInfo
+	for _, server := range cfg.MCPServers {
+		s := mcpPool.running(server.Name)
+		if server.Disabled || s == nil {
+			continue
+		}
+		for _, tool := range s.tools {
+			infos = append(infos, MCPToolInfo{
+				Server:      server.Name,
+				Action:      mcpActionName(server.Name, tool.Name),
+				Tool:        tool.Name,
+				Description: tool.Description,
+			})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Action < infos[j].Action })
+	return infos, err
+}
+
+// RegisterMCPTools registers the tools of each configured, running MCP server as
+// actions named mcp_<server>_<tool>, with the tool's input schema as params. Calls are
+// forwarded through tools/call. Servers are not connected here; see StartMCPServers.
+func (a *DataActions) RegisterMCPTools(servers []configs.MCPServerConfig) ([]string, error) {
+	var names []string
+	var errs []error
+	for _, cfg := range servers {
+		if cfg.Disabled {
+			continue
+		}
+		cfg, env, err := a.prepareMCPServer(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s := mcpPool.running(cfg.Name)
+		if s == nil {
+			errs = append(errs, fmt.Errorf("mcp server %s is not running", cfg.Name))
+			continue
+		}
+		for _, tool := range s.tools {
+			name := mcpActionName(cfg.Name, tool.Name)
+			if _, exists := a.actions[name]; exists {
+				errs = append(errs, fmt.Errorf("mcp tool %s of %s: action %q is already registered", tool.Name, cfg.Name, name))
+				continue
+			}
+			toolName := tool.Name
+			Register(a, ActionSpec{
+				Name:          name,
+				Description:   fmt.Sprintf("[MCP %s] %s", cfg.Name, tool.Description),
+				Details:       fmt.Sprintf("Tool %q imported from MCP server %q. Params follow the tool's JSON input schema.", tool.Name, cfg.Name),
+				Params:        tool.InputSchema,
+				ContentSource: ContentMCP,
+				// Astra cannot tell what a tool does on the server's side.
+				Effects: []string{EffectExecute, EffectNetwork},
+			}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+				return a.callMCPTool(ctx, cfg, env, toolName, args), nil
+			})
+			names = append(names, name)
+		}
+	}
+	return names, errors.Join(errs...)
+}
+
+// callMCPTool forwards a call, reconnecting once if the server went away in between.
+func (a *DataActions) callMCPTool(ctx context.Context, cfg configs.MCPServerConfig, env []string, tool string, args map[string]interface{}) map[string]interface{} {
+	timeout := defaultMCPCallTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var res *mcp.CallToolResult
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var s *mcpServer
+		if s, err = mcpPool.get(ctx, cfg, env); err != nil {
+			break
+		}
+		if res, err = s.client.CallTool(ctx, tool, args); !errors.Is(err, mcp.ErrClosed) {
+			break
+		}
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": fmt.Sprintf("mcp %s/%s: %v", cfg.Name, tool, err)}
+	}
+	return mcpResultToMap(res)
+}
+
+// mcpResultToMap converts a tool result into the usual action result shape.
+func mcpResultToMap(res *mcp.CallToolResult) map[string]interface{} {
+	parts := make([]string, 0, len(res.Content))
+	for _, c := range res.Content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "image", "audio":
+			parts = append(parts, fmt.Sprintf("[%s %s, %d bytes base64 omitted]", c.Type, c.MimeType, len(c.Data)))
+		case "resource":
+			if c.Resource != nil && c.Resource.Text != "" {
+				parts = append(parts, c.Resource.Text)
+			} else if c.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[resource %s]", c.Resource.URI))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content]", c.Type))
+		}
+	}
+	content := strings.Join(parts, "\n")
+	out := map[string]interface{}{"success": !res.IsError, "content": content}
+	if res.StructuredContent != nil {
+		out["structured_content"] = res.StructuredContent
+	}
+	if res.IsError {
+		out["error"] = content
+	}
+	return out
+}
+
+func mcpActionName(server, tool string) string {
+	clean := func(s string) string {
+		return strings.Trim(nonIdentChars.ReplaceAllString(strings.ToLower(s), "_"), "_")
+	}
+	return mcpActionPrefix + clean(server) + "_" + clean(tool)
+}
@@ -0,0 +1,211 @@
+package actions
+
+import (
+	"astra/astra/services/mcp"
+	"astra/astra/sources/psql/dao"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	mcpApprovalTimeout   = 5 * time.Minute
+	noteURIPrefix        = "astra://notes/"
+	knowledgeURIPrefix   = "astra://knowledge/"
+	resourceNameMaxRunes = 60
+)
+
+// mcpHiddenActions are driven by BaseAgent's own loop and mean nothing to an MCP client.
+var mcpHiddenActions = map[string]bool{
+	"ask_follow_up_questions_to_user": true,
+	"think_aloud_reasoning":           true,
+}
+
+// NewMCPServer exposes the registry as an MCP server. Every action except agent-internal
+// ones and tools imported from other MCP servers becomes a tool; notes and long-term
+// knowledge become resources. Calls go through ExecuteAction, so the workspace sandbox
+// and command policy apply as for the built-in agent. Approvals are asked through MCP
+// elicitation and denied when the client cannot ask the user.
+func (a *DataActions) NewMCPServer(name, version string) *mcp.Server {
+	srv := mcp.NewServer(name, version)
+	srv.Instructions = fmt.Sprintf("Astra actions confined to the workspace %s.", a.Workspace)
+
+	specs := a.ListActions()
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	for _, spec := range specs {
+		if spec.Fn == nil || mcpHiddenActions[spec.Name] || strings.HasPrefix(spec.Name, mcpActionPrefix) {
+			continue
+		}
+		actionName := spec.Name
+		srv.AddTool(mcp.Tool{
+			Name:        spec.Name,
+			Description: strings.TrimSpace(dedent(spec.Description) + "\n\n" + dedent(spec.Details)),
+			InputSchema: spec.Schema,
+		}, func(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResult, error) {
+			return actionResultToMCP(a.ExecuteActionContext(ctx, actionName, args)), nil
+		})
+	}
+	if a.db != nil {
+		srv.SetResources(&memoryResources{
+			userID:       a.UserID,
+			notes:        dao.NewNoteDAO(a.db),
+			knowledgeDAO: dao.NewLongTermKnowledgeDAO(a.db),
+		})
+	}
+
+	a.SetApprovalGate(func(req ApprovalRequest) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), mcpApprovalTimeout)
+		defer cancel()
+		msg := fmt.Sprintf("Astra wants to run %s: %s", req.Action, req.Reason)
+		if len(req.Params) > 0 {
+			b, _ := json.Marshal(req.Params)
+			msg += "\n" + string(b)
+		}
+		res, err := srv.Elicit(ctx, msg, map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"approve": map[string]interface{}{"type": "boolean", "description": "Allow this call"},
+			},
+			"required": []string{"approve"},
+		})
+		if err != nil {
+			return false // No client prompt available: deny, as with no gate at all.
+		}
+		return res.Action == "accept" && res.Content["approve"] == true
+	})
+	return srv
+}
+
+// actionResultToMCP renders an ExecuteAction outcome as a tool result. Results that
+// report success=false or a non-empty error are flagged as tool errors.
+func actionResultToMCP(res map[string]interface{}, err error) *mcp.CallToolResult {
+	if err != nil {
+		out := &mcp.CallToolResult{IsError: true, Content: []mcp.Content{{Type: "text", Text: err.Error()}}}
+		var paramsErr *ParamsError
+		if errors.As(err, &paramsErr) {
+			out.StructuredContent = map[string]interface{}{"error": err.Error(), "fields": paramsErr.Fields}
+		}
+		return out
+	}
+	b, _ := json.MarshalIndent(res, "", "  ")
+	out := &mcp.CallToolResult{Content: []mcp.Content{{Type: "text", Text: string(b)}}}
+	if res != nil {
+		out.StructuredContent = res
+	}
+	if ok, present := res["success"].(bool); present && !ok {
+		out.IsError = true
+	}
+	if msg, _ := res["error"].(string); msg != "" {
+		out.IsError = true
+	}
+	return out
+}
+
+// dedent strips the tab indentation the registry's raw-string docs carry.
+func dedent(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimLeft(line, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// memoryResources serves the user's notes and long-term knowledge as read-only resources.
+type memoryResources struct {
+	userID       int
+	notes        *dao.NoteDAO
+	knowledgeDAO *dao.LongTermKnowledgeDAO
+}
+
+func (m *memoryResources) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	notes, err := m.notes.GetAllNotesByUser(ctx, m.userID)
+	if err != nil {
+		return nil, fmt.Errorf("list notes: %w", err)
+	}
+	knowledge, err := m.knowledgeDAO.GetAllLongTermKnowledgeByUser(ctx, m.userID)
+	if err != nil {
+		return nil, fmt.Errorf("list knowledge: %w", err)
+	}
+	resources := make([]mcp.Resource, 0, len(notes)+len(knowledge))
+	for _, n := range notes {
+		title := n.Title
+		if title == "" {
+			title = shortLabel(n.Content)
+		}
+		desc := "Note"
+		if n.Favourite {
+			desc = "Favourite note"
+		}
+		resources = append(resources, mcp.Resource{
+			URI:         noteURIPrefix + n.ID.String(),
+			Name:        title,
+			Description: fmt.Sprintf("%s, updated %s", desc, n.UpdatedAt.Format(time.RFC3339)),
+			MimeType:    "text/markdown",
+		})
+	}
+	for _, k := range knowledge {
+		resources = append(resources, mcp.Resource{
+			URI:         knowledgeURIPrefix + k.ID.String(),
+			Name:        fmt.Sprintf("[%s] %s", k.KnowledgeType, shortLabel(k.KnowledgeBlob)),
+			Description: fmt.Sprintf("Long-term knowledge of type %s", k.KnowledgeType),
+			MimeType:    "text/plain",
+		})
+	}
+	return resources, nil
+}
+
+func (m *memoryResources) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	notFound := &mcp.RPCError{Code: mcp.CodeInvalidParams, Message: "resource not found: " + uri}
+	switch {
+	case strings.HasPrefix(uri, noteURIPrefix):
+		id, err := uuid.Parse(strings.TrimPrefix(uri, noteURIPrefix))
+		if err != nil {
+			return nil, notFound
+		}
+		n, err := m.notes.GetNoteByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil || n.UserID != m.userID {
+			return nil, notFound
+		}
+		text := n.Content
+		if n.Title != "" {
+			text = "# " + n.Title + "\n\n" + text
+		}
+		return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{{URI: uri, MimeType: "text/markdown", Text: text}}}, nil
+
+	case strings.HasPrefix(uri, knowledgeURIPrefix):
+		id, err := uuid.Parse(strings.TrimPrefix(uri, knowledgeURIPrefix))
+		if err != nil {
+			return nil, notFound
+		}
+		k, err := m.knowledgeDAO.GetLongTermKnowledgeByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if k == nil || k.UserID != m.userID {
+			return nil, notFound
+		}
+		return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{{URI: uri, MimeType: "text/plain", Text: k.KnowledgeBlob}}}, nil
+	}
+	return nil, notFound
+}
+
+// shortLabel is the first line of s, cut to resourceNameMaxRunes runes.
+func shortLabel(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if r := []rune(s); len(r) > resourceNameMaxRunes {
+		s = string(r[:resourceNameMaxRunes]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,30 @@
+package actions
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestActionResultToMCP(t *testing.T) {
+	if res := actionResultToMCP(map[string]interface{}{"success": true, "files": 2}, nil); res.IsError || res.StructuredContent == nil {
+		t.Errorf("expected a successful structured result, got %+v", res)
+	}
+	if res := actionResultToMCP(map[string]interface{}{"success": false, "error": "denied"}, nil); !res.IsError {
+		t.Errorf("expected success=false to be a tool error")
+	}
+	if res := actionResultToMCP(nil, errors.New("action not found: x")); !res.IsError || res.Content[0].Text != "action not found: x" {
+		t.Errorf("expected ExecuteAction errors to become tool errors, got %+v", res)
+	}
+	paramsErr := &ParamsError{Action: "x", Fields: []FieldError{{Field: "path", Message: "is required"}}}
+	if res := actionResultToMCP(nil, paramsErr); !res.IsError || res.StructuredContent == nil {
+		t.Errorf("expected field errors as structured content, got %+v", res)
+	}
+}
+
+func TestNewMCPServer_DeniesApprovalWithoutElicitation(t *testing.T) {
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+	a.NewMCPServer("astra", "test")
+	if a.requestApproval(t.Context(), ApprovalRequest{Action: "run_command", Reason: "not allowlisted"}) {
+		t.Errorf("expected approvals to be denied when the client cannot ask the user")
+	}
+}
@@ -0,0 +1,102 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"astra/astra/services/mcp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConnectMCPServers_RegistersAndForwardsTools(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg mcp.Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		if len(msg.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		var result interface{}
+		switch msg.Method {
+		case "initialize":
+			result = mcp.InitializeResult{ProtocolVersion: mcp.ProtocolVersion, ServerInfo: mcp.Implementation{Name: "docs"}}
+		case "tools/list":
+			result = mcp.ListToolsResult{Tools: []mcp.Tool{{
+				Name:        "search-docs",
+				Description: "Searches docs",
+				InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}}},
+			}}}
+		case "tools/call":
+			var p mcp.CallToolParams
+			_ = json.Unmarshal(msg.Params, &p)
+			if p.Arguments["q"] == "" {
+				result = mcp.CallToolResult{IsError: true, Content: []mcp.Content{{Type: "text", Text: "q is required"}}}
+			} else {
+				result = mcp.CallToolResult{
+					Content:           []mcp.Content{{Type: "text", Text: "found: " + p.Arguments["q"].(string)}},
+					StructuredContent: map[string]interface{}{"hits": 1},
+				}
+			}
+		}
+		b, _ := json.Marshal(result)
+		_ = json.NewEncoder(w).Encode(mcp.Message{JSONRPC: "2.0", ID: msg.ID, Result: b})
+	}))
+	defer srv.Close()
+	defer CloseMCPServers()
+
+	servers := []configs.MCPServerConfig{
+		{Name: "Docs", URL: srv.URL},
+		{Name: "off", Command: "does-not-exist", Disabled: true},
+	}
+	if err := StartMCPServers(context.Background(), &configs.AgentConfig{MCPServers: servers}); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+	names, err := a.RegisterMCPTools(servers)
+	if err != nil || len(names) != 1 || names[0] != "mcp_docs_search_docs" {
+		t.Fatalf("unexpected registration: %v (%v)", names, err)
+	}
+	if spec, _ := a.GetAction("mcp_docs_search_docs"); spec.Params.(map[string]interface{})["type"] != "object" {
+		t.Errorf("expected the tool input schema as params, got %+v", spec.Params)
+	}
+
+	res, err := a.ExecuteAction("mcp_docs_search_docs", map[string]interface{}{"q": "routing"})
+	if err != nil || res["success"] != true || res["content"] != "found: routing" {
+		t.Errorf("unexpected tool result: %v (%v)", res, err)
+	}
+	if sc, _ := res["structured_content"].(map[string]interface{}); sc["hits"] != float64(1) {
+		t.Errorf("expected structured content, got %v", res["structured_content"])
+	}
+	res, _ = a.ExecuteAction("mcp_docs_search_docs", map[string]interface{}{"q": ""})
+	if res["success"] != false || res["error"] != "q is required" {
+		t.Errorf("expected tool error to map to success=false, got %v", res)
+	}
+}
+
+func TestPrepareMCPServer_EnvAndDir(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("DOCS_TOKEN", "tok")
+	a := &DataActions{Workspace: t.TempDir()}
+	a.SetCommandPolicy(configs.RunCommandConfig{EnvPassthrough: []string{"PATH"}})
+
+	cfg, env, err := a.prepareMCPServer(configs.MCPServerConfig{
+		Name: "docs", Command: "docs-server", Dir: "tools", Env: map[string]string{"TOKEN": "${DOCS_TOKEN}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dir != filepath.Join(a.Workspace, "tools") {
+		t.Errorf("expected dir resolved against the workspace, got %s", cfg.Dir)
+	}
+	joined := strings.Join(env, "\n")
+	if strings.Contains(joined, "OPENAI_API_KEY") || !strings.Contains(joined, "TOKEN=tok") {
+		t.Errorf("expected only passthrough and configured env, got %v", env)
+	}
+	if _, _, err := a.prepareMCPServer(configs.MCPServerConfig{Name: "x", Command: "x", Dir: "../.."}); err == nil {
+		t.Error("expected a dir outside the workspace to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package actions
+
+import (
+	"astra/astra/services/memory"
+	"context"
+	"errors"
+	"strings"
+)
+
+const (
+	defaultMemoryResults = 5
+	maxMemoryResults     = 20
+)
+
+type SemanticSearchMemoryParams struct {
+	Query   string   `json:"query" required:"true" desc:"What to look for, in natural language"`
+	Sources []string `json:"sources,omitempty" enum:"knowledge,note,session" desc:"Limit the search to these memory sources (default: all)"`
+	Limit   int      `json:"limit,omitempty" default:"5" desc:"Maximum number of results (max 20)"`
+}
+
+type SemanticSearchMemoryResult struct {
+	Results []memory.Hit `json:"results"`
+	Count   int          `json:"count"`
+}
+
+// SetMemory installs the semantic memory used by semantic_search_memory.
+func (a *DataActions) SetMemory(m *memory.Service) {
+	a.memory = m
+}
+
+func (a *DataActions) SemanticSearchMemoryAction(ctx context.Context, p SemanticSearchMemoryParams) (SemanticSearchMemoryResult, error) {
+	if a.memory == nil {
+		return SemanticSearchMemoryResult{}, errors.New("semantic memory is not configured")
+	}
+	if strings.TrimSpace(p.Query) == "" {
+		return SemanticSearchMemoryResult{}, errors.New("query must not be empty")
+	}
+	limit := p.Limit
+	if limit <= 0 {
+		limit = defaultMemoryResults
+	}
+	if limit > maxMemoryResults {
+		limit = maxMemoryResults
+	}
+	hits, err := a.memory.Search(ctx, a.UserID, p.Query, limit, p.Sources, 0)
+	if err != nil {
+		return SemanticSearchMemoryResult{}, err
+	}
+	return SemanticSearchMemoryResult{Results: hits, Count: len(hits)}, nil
+}
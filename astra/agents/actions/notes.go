@@ -0,0 +1,169 @@
+package actions
+
+import (
+	"astra/astra/sources/psql/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultNotesLimit = 50
+	maxNotesLimit     = 200
+)
+
+var errNoteNotFound = errors.New("note not found")
+
+type CreateNoteParams struct {
+	Title     string `json:"title,omitempty" desc:"Short title of the note"`
+	Content   string `json:"content" required:"true" desc:"Note text (markdown)"`
+	Favourite bool   `json:"favourite,omitempty" desc:"Mark the note as a favourite"`
+}
+
+type UpdateNoteParams struct {
+	ID      string  `json:"id" required:"true" desc:"Note ID (uuid)"`
+	Title   *string `json:"title,omitempty" desc:"New title"`
+	Content *string `json:"content,omitempty" desc:"New content, replacing the old one"`
+}
+
+type FavouriteNoteParams struct {
+	ID        string `json:"id" required:"true" desc:"Note ID (uuid)"`
+	Favourite *bool  `json:"favourite,omitempty" default:"true" desc:"true to favourite, false to unfavourite"`
+}
+
+type ListNotesParams struct {
+	FavouritesOnly bool `json:"favourites_only,omitempty" desc:"Only list favourite notes"`
+	Limit          int  `json:"limit,omitempty" default:"50" desc:"Maximum number of notes (max 200)"`
+}
+
+type SearchNotesParams struct {
+	Query          string `json:"query" required:"true" desc:"Words that must all appear in the title or content"`
+	FavouritesOnly bool   `json:"favourites_only,omitempty" desc:"Only search favourite notes"`
+	Limit          int    `json:"limit,omitempty" default:"20" desc:"Maximum number of notes (max 200)"`
+}
+
+type DeleteNoteParams struct {
+	ID string `json:"id" required:"true" desc:"Note ID (uuid)"`
+}
+
+type NotesResult struct {
+	Notes []models.Note `json:"notes"`
+	Count int           `json:"count"`
+}
+
+// noteRegistrations lists the note actions; their docs live in configs/actions/notes.
+func (a *DataActions) noteRegistrations() []yamlActionRegistration {
+	return []yamlActionRegistration{
+		yamlAction("create_note", a.CreateNoteAction),
+		yamlAction("update_note", a.UpdateNoteAction),
+		yamlAction("favourite_note", a.FavouriteNoteAction),
+		yamlAction("list_notes", a.ListNotesAction),
+		yamlAction("search_notes", a.SearchNotesAction),
+		yamlAction("delete_note", a.DeleteNoteAction),
+	}
+}
+
+func (a *DataActions) CreateNoteAction(ctx context.Context, p CreateNoteParams) (*models.Note, error) {
+	if strings.TrimSpace(p.Content) == "" {
+		return nil, errors.New("content must not be empty")
+	}
+	note := &models.Note{
+		UserID:    a.UserID,
+		Title:     p.Title,
+		Content:   p.Content,
+		Favourite: p.Favourite,
+	}
+	if err := a.noteDao.CreateNote(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (a *DataActions) UpdateNoteAction(ctx context.Context, p UpdateNoteParams) (*models.Note, error) {
+	updates := map[string]interface{}{}
+	if p.Title != nil {
+		updates["title"] = *p.Title
+	}
+	if p.Content != nil {
+		if strings.TrimSpace(*p.Content) == "" {
+			return nil, errors.New("content must not be empty")
+		}
+		updates["content"] = *p.Content
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("nothing to update: pass title and/or content")
+	}
+	return a.updateOwnNote(ctx, p.ID, updates)
+}
+
+func (a *DataActions) FavouriteNoteAction(ctx context.Context, p FavouriteNoteParams) (*models.Note, error) {
+	favourite := p.Favourite == nil || *p.Favourite
+	return a.updateOwnNote(ctx, p.ID, map[string]interface{}{"favourite": favourite})
+}
+
+func (a *DataActions) ListNotesAction(ctx context.Context, p ListNotesParams) (NotesResult, error) {
+	return a.findNotes(ctx, "", p.FavouritesOnly, p.Limit)
+}
+
+func (a *DataActions) SearchNotesAction(ctx context.Context, p SearchNotesParams) (NotesResult, error) {
+	if strings.TrimSpace(p.Query) == "" {
+		return NotesResult{}, errors.New("query must not be empty")
+	}
+	return a.findNotes(ctx, p.Query, p.FavouritesOnly, p.Limit)
+}
+
+func (a *DataActions) DeleteNoteAction(ctx context.Context, p DeleteNoteParams) (*models.Note, error) {
+	note, err := a.ownNote(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.noteDao.DeleteNote(ctx, note.ID); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (a *DataActions) findNotes(ctx context.Context, query string, favouritesOnly bool, limit int) (NotesResult, error) {
+	if limit <= 0 {
+		limit = defaultNotesLimit
+	}
+	if limit > maxNotesLimit {
+		limit = maxNotesLimit
+	}
+	notes, err := a.noteDao.SearchNotesByUser(ctx, a.UserID, query, favouritesOnly, limit)
+	if err != nil {
+		return NotesResult{}, err
+	}
+	return NotesResult{Notes: notes, Count: len(notes)}, nil
+}
+
+// ownNote loads a note of the current user. Notes of other users are reported as
+// not found so their existence is not revealed.
+func (a *DataActions) ownNote(ctx context.Context, rawID string) (*models.Note, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note id: %w", err)
+	}
+	note, err := a.noteDao.GetNoteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil || note.UserID != a.UserID {
+		return nil, errNoteNotFound
+	}
+	return note, nil
+}
+
+func (a *DataActions) updateOwnNote(ctx context.Context, rawID string, updates map[string]interface{}) (*models.Note, error) {
+	note, err := a.ownNote(ctx, rawID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.noteDao.UpdateNote(ctx, note.ID, updates); err != nil {
+		return nil, err
+	}
+	return a.noteDao.GetNoteByID(ctx, note.ID)
+}
@@ -0,0 +1,32 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"testing"
+)
+
+func TestNoteRegistrations_HaveYAML(t *testing.T) {
+	yamlCfgs, err := configs.LoadActionsYAMLInDir("../configs/actions/notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &DataActions{actions: make(map[string]ActionSpec)}
+	a.registerYAMLBacked(yamlCfgs, a.noteRegistrations())
+	for _, reg := range a.noteRegistrations() {
+		spec, ok := a.GetAction(reg.key)
+		if !ok || spec.Description == "" || spec.Schema["properties"] == nil {
+			t.Errorf("note action %s is not registered with docs and schema: %+v", reg.key, spec)
+		}
+	}
+	// Note YAMLs only document Go-backed actions; the declarative loader skips them.
+	if names, err := a.LoadDeclarativeActions("../configs/actions/notes"); err != nil || len(names) != 0 {
+		t.Errorf("expected note YAMLs to be skipped by the declarative loader, got %v (%v)", names, err)
+	}
+}
+
+func TestFavouriteNoteParams_DefaultsToTrue(t *testing.T) {
+	decoded := map[string]interface{}{"id": "8f14e45f-ea5b-4c7b-9a1e-0d5b2c3f8a11"}
+	if errs := validateParams(paramsSchema(FavouriteNoteParams{}), decoded, ""); len(errs) != 0 || decoded["favourite"] != true {
+		t.Errorf("expected favourite to default to true, got %v (%v)", decoded, errs)
+	}
+}
@@ -0,0 +1,291 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"astra/astra/utils/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Channels an agent run can come from; policy rules select on them.
+const (
+	ChannelWeb = "web"
+	ChannelCLI = "cli"
+	ChannelMCP = "mcp"
+)
+
+// PolicyError is returned instead of running an action the policy forbids, so the
+// planner can tell a denial from a failure and pick another way.
+type PolicyError struct {
+	Action string `json:"action"`
+	Rule   string `json:"rule,omitempty"` // Rule that decided; empty when the default did
+	Param  string `json:"param,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *PolicyError) Error() string {
+	if e.Param != "" {
+		return fmt.Sprintf("action %s denied by policy: %s: %s", e.Action, e.Param, e.Reason)
+	}
+	return fmt.Sprintf("action %s denied by policy: %s", e.Action, e.Reason)
+}
+
+// actionPolicy is the compiled form of configs.PolicyConfig.
+type actionPolicy struct {
+	defaultDeny       bool
+	rules             []policyRule
+	untrustedApproval []string // nil gates actions by their Effects
+	invalid           error    // set when the config did not compile; every call is denied
+}
+
+type policyRule struct {
+	configs.PolicyRule
+	params []policyParamRule
+}
+
+type policyParamRule struct {
+	configs.PolicyParamRule
+	action  string // glob
+	path    []string
+	pattern *regexp.Regexp
+}
+
+// SetPolicy installs the action policy. An invalid policy is reported and denies every
+// call until a valid one is set, so a typo does not open up the registry.
+func (a *DataActions) SetPolicy(cfg configs.PolicyConfig) error {
+	p, err := compilePolicy(cfg)
+	if err != nil {
+		a.policy = &actionPolicy{defaultDeny: true, invalid: err}
+		return err
+	}
+	a.policy = p
+	return nil
+}
+
+func compilePolicy(cfg configs.PolicyConfig) (*actionPolicy, error) {
+	p := &actionPolicy{}
+	switch cfg.Default {
+	case "", "allow":
+	case "deny":
+		p.defaultDeny = true
+	default:
+		return nil, fmt.Errorf("default must be allow or deny, got %q", cfg.Default)
+	}
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		for _, glob := range slices.Concat(r.Sessions, r.Allow, r.Deny, r.RequireApproval) {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid glob %q", r.Name, glob)
+			}
+		}
+		rule := policyRule{PolicyRule: r}
+		for action, params := range r.Params {
+			if _, err := path.Match(action, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid glob %q", r.Name, action)
+			}
+			for param, pr := range params {
+				compiled := policyParamRule{PolicyParamRule: pr, action: action, path: strings.Split(param, ".")}
+				if pr.Pattern != "" {
+					re, err := regexp.Compile("^(?:" + pr.Pattern + ")$")
+					if err != nil {
+						return nil, fmt.Errorf("%s: %s.%s: %w", r.Name, action, param, err)
+					}
+					compiled.pattern = re
+				}
+				rule.params = append(rule.params, compiled)
+			}
+		}
+		// Map order is random; keep denials reproducible.
+		slices.SortFunc(rule.params, func(x, y policyParamRule) int {
+			return strings.Compare(x.action+"\x00"+strings.Join(x.path, "."), y.action+"\x00"+strings.Join(y.path, "."))
+		})
+		p.rules = append(p.rules, rule)
+	}
+	if approval := cfg.Untrusted.RequireApproval; approval != nil {
+		for _, glob := range approval {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("untrusted: invalid glob %q", glob)
+			}
+		}
+		p.untrustedApproval = approval
+	}
+	return p, nil
+}
+
+func (r *policyRule) applies(info ActionInfo) bool {
+	return (len(r.Users) == 0 || slices.Contains(r.Users, info.UserID)) &&
+		(len(r.Agents) == 0 || slices.ContainsFunc(r.Agents, func(agent string) bool { return strings.EqualFold(agent, info.Agent) })) &&
+		(len(r.Sessions) == 0 || matchActionGlob(r.Sessions, info.SessionID)) &&
+		(len(r.Channels) == 0 || slices.Contains(r.Channels, info.Channel))
+}
+
+// allows reports whether info.Action may be called at all, and the rule that decided.
+func (p *actionPolicy) allows(info ActionInfo) (bool, string) {
+	if p.invalid != nil {
+		return false, ""
+	}
+	for _, r := range p.rules {
+		if !r.applies(info) {
+			continue
+		}
+		if matchActionGlob(r.Deny, info.Action) {
+			return false, r.Name
+		}
+		if matchActionGlob(r.Allow, info.Action) {
+			return true, r.Name
+		}
+	}
+	return !p.defaultDeny, ""
+}
+
+// check returns the denial of a call, or the rules that require approving it.
+func (p *actionPolicy) check(info ActionInfo, params map[string]interface{}) (*PolicyError, []string) {
+	if p.invalid != nil {
+		return &PolicyError{Action: info.Action, Reason: "the action policy is invalid: " + p.invalid.Error()}, nil
+	}
+	if ok, rule := p.allows(info); !ok {
+		return &PolicyError{
+			Action: info.Action,
+			Rule:   rule,
+			Reason: fmt.Sprintf("not allowed for user %d, agent %q, channel %q; do not retry it, use another action or tell the user", info.UserID, info.Agent, info.Channel),
+		}, nil
+	}
+
+	var generic interface{} = map[string]interface{}{}
+	if b, err := json.Marshal(params); err == nil {
+		_ = json.Unmarshal(b, &generic)
+	}
+	var approvals []string
+	for _, r := range p.rules {
+		if !r.applies(info) {
+			continue
+		}
+		for _, pr := range r.params {
+			if !matchActionGlob([]string{pr.action}, info.Action) {
+				continue
+			}
+			if reason := pr.violation(paramValues(generic, pr.path)); reason != "" {
+				return &PolicyError{Action: info.Action, Rule: r.Name, Param: strings.Join(pr.path, "."), Reason: reason}, nil
+			}
+		}
+		if matchActionGlob(r.RequireApproval, info.Action) {
+			approvals = append(approvals, r.Name)
+		}
+	}
+	return nil, approvals
+}
+
+func (pr *policyParamRule) violation(values []interface{}) string {
+	if pr.Forbidden && len(values) > 0 {
+		return "must not be set"
+	}
+	for _, v := range values {
+		if len(pr.Enum) > 0 && !slices.Contains(pr.Enum, fmt.Sprint(v)) {
+			return fmt.Sprintf("%v is not one of %s", v, strings.Join(pr.Enum, ", "))
+		}
+		if pr.pattern != nil {
+			s, ok := v.(string)
+			if !ok || !pr.pattern.MatchString(s) {
+				return fmt.Sprintf("%v does not match %s", v, pr.Pattern)
+			}
+		}
+		if pr.Max != nil {
+			if n, ok := v.(float64); ok && n > *pr.Max {
+				return fmt.Sprintf("%v exceeds the maximum of %v", v, *pr.Max)
+			}
+		}
+	}
+	return ""
+}
+
+// paramValues collects the values at a dotted path, descending into every list element.
+func paramValues(v interface{}, path []string) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		var out []interface{}
+		for _, item := range list {
+			out = append(out, paramValues(item, path)...)
+		}
+		return out
+	}
+	if len(path) == 0 {
+		if v == nil {
+			return nil
+		}
+		return []interface{}{v}
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		return paramValues(m[path[0]], path[1:])
+	}
+	return nil
+}
+
+func matchActionGlob(globs []string, name string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// enforcePolicy denies the call or asks for the approvals the policy requires.
+// Denials and approval decisions are written to the audit log.
+func (a *DataActions) enforcePolicy(ctx context.Context, info ActionInfo, params map[string]interface{}) error {
+	if a.policy == nil {
+		return nil
+	}
+	denial, approvals := a.policy.check(info, params)
+	if denial != nil {
+		auditPolicyDecision(info, "denied", denial.Rule, denial.Error())
+		return denial
+	}
+	if len(approvals) == 0 {
+		return nil
+	}
+	rules := strings.Join(approvals, ", ")
+	if !a.requestApproval(ctx, ApprovalRequest{
+		Action: info.Action,
+		Reason: fmt.Sprintf("the action policy (%s) requires approval", rules),
+		Params: params,
+	}) {
+		denial = &PolicyError{Action: info.Action, Rule: approvals[0], Reason: "the approval the policy requires was not given"}
+		auditPolicyDecision(info, "approval_denied", rules, denial.Error())
+		return denial
+	}
+	auditPolicyDecision(info, "approved", rules, "")
+	return nil
+}
+
+// policyHides reports whether the policy forbids name outright, so it is not offered.
+func (a *DataActions) policyHides(name string) bool {
+	if a.policy == nil {
+		return false
+	}
+	ok, _ := a.policy.allows(a.actionInfo(name))
+	return !ok
+}
+
+func auditPolicyDecision(info ActionInfo, decision, rule, reason string) {
+	if logging.AuditLogger == nil {
+		return
+	}
+	logging.AuditLogger.Info("action policy",
+		zap.String("decision", decision),
+		zap.String("action", info.Action),
+		zap.Int("user_id", info.UserID),
+		zap.String("agent", info.Agent),
+		zap.String("session_id", info.SessionID),
+		zap.String("channel", info.Channel),
+		zap.String("rule", rule),
+		zap.String("reason", reason),
+	)
+}
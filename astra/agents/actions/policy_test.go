@@ -0,0 +1,98 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"context"
+	"errors"
+	"testing"
+)
+
+func newPolicyTestActions(t *testing.T, cfg configs.PolicyConfig) *DataActions {
+	t.Helper()
+	a := &DataActions{actions: make(map[string]ActionSpec), UserID: 2, Channel: ChannelWeb, Workspace: t.TempDir()}
+	echo := func(_ context.Context, p map[string]interface{}) (map[string]interface{}, error) { return p, nil }
+	for _, name := range []string{"read_files_in_this_repo", "run_command", "db_query", "http_request"} {
+		Register(a, ActionSpec{Name: name}, echo)
+	}
+	if err := a.SetPolicy(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestPolicy_RulesDecideInOrder(t *testing.T) {
+	max := 50.0
+	a := newPolicyTestActions(t, configs.PolicyConfig{Rules: []configs.PolicyRule{
+		{Name: "admin", Users: []int{1}, Allow: []string{"*"}},
+		{Name: "web", Channels: []string{ChannelWeb}, Deny: []string{"run_command", "db_*"}, RequireApproval: []string{"http_request"},
+			Params: map[string]map[string]configs.PolicyParamRule{
+				"read_files_in_this_repo": {"files.path": {Pattern: `astra/.*`}, "max_lines": {Max: &max}},
+			}},
+	}})
+
+	_, err := a.ExecuteAction("run_command", map[string]interface{}{"command": "rm"})
+	var denial *PolicyError
+	if !errors.As(err, &denial) || denial.Rule != "web" {
+		t.Fatalf("expected a denial by the web rule, got %v", err)
+	}
+	if _, err := a.ExecuteAction("read_files_in_this_repo", map[string]interface{}{
+		"files": []map[string]interface{}{{"path": "astra/main.go"}, {"path": "/etc/passwd"}},
+	}); !errors.As(err, &denial) || denial.Param != "files.path" {
+		t.Errorf("expected the second path to be denied, got %v", err)
+	}
+	if _, err := a.ExecuteAction("read_files_in_this_repo", map[string]interface{}{"max_lines": 51}); !errors.As(err, &denial) || denial.Param != "max_lines" {
+		t.Errorf("expected max_lines to be capped, got %v", err)
+	}
+	if _, err := a.ExecuteAction("read_files_in_this_repo", map[string]interface{}{"files": []map[string]interface{}{{"path": "astra/a.go"}}}); err != nil {
+		t.Errorf("expected an allowed call, got %v", err)
+	}
+
+	// Approval is asked through the gate; without one the call is denied.
+	if _, err := a.ExecuteAction("http_request", nil); !errors.As(err, &denial) {
+		t.Errorf("expected a denial without approval, got %v", err)
+	}
+	var asked ApprovalRequest
+	a.SetApprovalGate(func(req ApprovalRequest) bool { asked = req; return true })
+	if _, err := a.ExecuteAction("http_request", nil); err != nil || asked.Action != "http_request" {
+		t.Errorf("expected an approved call, got %v (%+v)", err, asked)
+	}
+
+	names := map[string]bool{}
+	for _, spec := range a.ListActions() {
+		names[spec.Name] = true
+	}
+	if names["run_command"] || names["db_query"] || !names["read_files_in_this_repo"] {
+		t.Errorf("denied actions should not be listed: %v", names)
+	}
+
+	a.UserID = 1
+	if _, err := a.ExecuteAction("run_command", nil); err != nil {
+		t.Errorf("expected the admin rule to allow run_command, got %v", err)
+	}
+}
+
+func TestPolicy_InvalidPolicyDeniesEverything(t *testing.T) {
+	a := &DataActions{actions: make(map[string]ActionSpec)}
+	Register(a, ActionSpec{Name: "pwd"}, func(context.Context, NoParams) (string, error) { return "/", nil })
+	if err := a.SetPolicy(configs.PolicyConfig{Default: "maybe"}); err == nil {
+		t.Fatal("expected an invalid default to be rejected")
+	}
+	var denial *PolicyError
+	if _, err := a.ExecuteAction("pwd", nil); !errors.As(err, &denial) {
+		t.Errorf("expected calls to be denied under an invalid policy, got %v", err)
+	}
+}
+
+func TestPolicy_AgentNameComesFromConfig(t *testing.T) {
+	a := newPolicyTestActions(t, configs.PolicyConfig{})
+	a.AgentName = "other"
+	if _, err := a.ApplyAgentConfig(&configs.AgentConfig{AgentName: "Astra", Policy: configs.PolicyConfig{Rules: []configs.PolicyRule{
+		{Name: "astra", Agents: []string{"astra"}, Deny: []string{"run_command"}},
+	}}}); err != nil {
+		t.Fatal(err)
+	}
+	var denial *PolicyError
+	if _, err := a.ExecuteAction("run_command", nil); !errors.As(err, &denial) || denial.Rule != "astra" {
+		t.Errorf("expected the agent rule to deny run_command, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package actions
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// maxStreamedLines caps the output lines streamed per action call; the full (capped)
+// output is still part of the action's result.
+const maxStreamedLines = 2000
+
+const (
+	progressStarted = "started"
+	progressDone    = "done"
+	progressFailed  = "failed"
+)
+
+// ActionOutput is a live output line or progress update of a running action. It is
+// sent while the action runs; the action's result is returned as usual.
+type ActionOutput struct {
+	Action  string   `json:"action"`
+	Stream  string   `json:"stream,omitempty"` // stdout or stderr, for output lines
+	Line    string   `json:"line,omitempty"`
+	Percent *float64 `json:"percent,omitempty"` // 0-100
+	Item    string   `json:"item,omitempty"`    // What the update is about, e.g. a URL or command
+	Status  string   `json:"status,omitempty"`  // started, done or failed
+	Message string   `json:"message,omitempty"`
+}
+
+// OutputSink receives the live output of actions. It may be called from several
+// goroutines of one action.
+type OutputSink func(ActionOutput)
+
+// outputState holds the sink and the action it currently reports for.
+type outputState struct {
+	mu     sync.Mutex
+	sink   OutputSink
+	action string
+	lines  int
+}
+
+// SetOutputSink installs the sink for live action output; nil stops streaming.
+func (a *DataActions) SetOutputSink(sink OutputSink) {
+	a.output.mu.Lock()
+	defer a.output.mu.Unlock()
+	a.output.sink = sink
+}
+
+// beginActionOutput attributes output to the named action until the next call.
+func (a *DataActions) beginActionOutput(action string) {
+	a.output.mu.Lock()
+	defer a.output.mu.Unlock()
+	a.output.action = action
+	a.output.lines = 0
+}
+
+// streaming reports whether anybody listens to action output.
+func (a *DataActions) streaming() bool {
+	a.output.mu.Lock()
+	defer a.output.mu.Unlock()
+	return a.output.sink != nil
+}
+
+func (a *DataActions) emitOutput(out ActionOutput) {
+	a.output.mu.Lock()
+	sink := a.output.sink
+	out.Action = a.output.action
+	if out.Line != "" {
+		a.output.lines++
+		switch {
+		case a.output.lines == maxStreamedLines+1:
+			out = ActionOutput{Action: out.Action, Message: "further output is not streamed"}
+		case a.output.lines > maxStreamedLines:
+			sink = nil
+		}
+	}
+	a.output.mu.Unlock()
+	if sink != nil {
+		sink(out)
+	}
+}
+
+// reportProgress sends a progress update for item; done of total items are finished.
+func (a *DataActions) reportProgress(done, total int, item, status, message string) {
+	out := ActionOutput{Item: item, Status: status, Message: message}
+	if total > 0 {
+		pct := float64(done) * 100 / float64(total)
+		out.Percent = &pct
+	}
+	a.emitOutput(out)
+}
+
+// lineEmitter is an io.Writer that sends every complete line written to it as action output.
+type lineEmitter struct {
+	a       *DataActions
+	stream  string
+	partial []byte
+}
+
+func (w *lineEmitter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush sends a trailing line without newline.
+func (w *lineEmitter) Flush() {
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *lineEmitter) emit(line string) {
+	line = ansiPattern.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+	// Progress bars redraw with \r; only the last state of the line is worth showing.
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	w.a.emitOutput(ActionOutput{Stream: w.stream, Line: line})
+}
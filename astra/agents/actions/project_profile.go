@@ -0,0 +1,396 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	phaseBuild  = "build"
+	phaseTest   = "test"
+	phaseLint   = "lint"
+	phaseFormat = "format"
+)
+
+// makeTargetPattern matches a rule line of a Makefile, e.g. "test: deps".
+var makeTargetPattern = regexp.MustCompile(`(?m)^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+
+// skippedProjectDirs are never scanned for manifests.
+var skippedProjectDirs = map[string]bool{"node_modules": true, "vendor": true, "target": true, "dist": true, "build": true}
+
+// ProjectLanguage is one language found in one project root, with the commands of each phase.
+type ProjectLanguage struct {
+	Name       string     `json:"name"` // go, node, python, rust or make
+	Root       string     `json:"root"` // Relative to the workspace
+	Manifest   string     `json:"manifest,omitempty"`
+	Build      [][]string `json:"build,omitempty"`
+	Test       [][]string `json:"test,omitempty"`
+	Lint       [][]string `json:"lint,omitempty"`
+	Format     [][]string `json:"format,omitempty"`
+	Configured []string   `json:"configured,omitempty"` // Phases whose commands come from .astra.yaml
+}
+
+// ProjectProfile is the output of project_profile.
+type ProjectProfile struct {
+	Languages  []ProjectLanguage `json:"languages"`
+	ConfigFile string            `json:"config_file,omitempty"` // Set when .astra.yaml was applied
+	Error      string            `json:"error,omitempty"`
+}
+
+// ProjectPhaseParams selects which languages a project action runs for.
+type ProjectPhaseParams struct {
+	Root     string `json:"root,omitempty" desc:"Only run for languages in this root, e.g. frontend"`
+	Language string `json:"language,omitempty" enum:"go,node,python,rust,make" desc:"Only run for this language"`
+}
+
+// LintProjectParams adds an optional format pass to lint_project.
+type LintProjectParams struct {
+	ProjectPhaseParams
+	Format bool `json:"format,omitempty" desc:"Run the format commands before linting"`
+}
+
+// ProjectRun is the validation result of one language.
+type ProjectRun struct {
+	Language string `json:"language"`
+	ValidationResult
+}
+
+// ProjectPhaseResult is the output of build_project, test_project and lint_project.
+type ProjectPhaseResult struct {
+	Phase   string       `json:"phase"`
+	Success bool         `json:"success"`
+	Runs    []ProjectRun `json:"runs"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// commands returns the commands of phase.
+func (l *ProjectLanguage) commands(phase string) [][]string {
+	switch phase {
+	case phaseBuild:
+		return l.Build
+	case phaseTest:
+		return l.Test
+	case phaseLint:
+		return l.Lint
+	case phaseFormat:
+		return l.Format
+	}
+	return nil
+}
+
+func (l *ProjectLanguage) setCommands(phase string, cmds [][]string) {
+	switch phase {
+	case phaseBuild:
+		l.Build = cmds
+	case phaseTest:
+		l.Test = cmds
+	case phaseLint:
+		l.Lint = cmds
+	case phaseFormat:
+		l.Format = cmds
+	}
+}
+
+func (l *ProjectLanguage) configured(phase string) bool {
+	for _, p := range l.Configured {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectProfile detects the languages of the workspace and applies .astra.yaml.
+func (a *DataActions) ProjectProfile(ctx context.Context, _ NoParams) (ProjectProfile, error) {
+	ws, err := a.workspaceRoot()
+	if err != nil {
+		return ProjectProfile{Languages: []ProjectLanguage{}, Error: fmt.Sprintf("failed to get workspace root: %v", err)}, nil
+	}
+	profile := ProjectProfile{Languages: detectProjectLanguages(ws)}
+	cfg, err := configs.LoadWorkspaceConfig(ws)
+	if err != nil {
+		profile.Error = fmt.Sprintf("failed to load %s: %v", configs.WorkspaceConfigFile, err)
+		return profile, nil
+	}
+	if len(cfg.Project.Languages) > 0 {
+		profile.Languages = applyProjectConfig(profile.Languages, cfg.Project.Languages)
+		profile.ConfigFile = configs.WorkspaceConfigFile
+	}
+	return profile, nil
+}
+
+// BuildProject runs the build commands of every detected language.
+func (a *DataActions) BuildProject(ctx context.Context, p ProjectPhaseParams) (ProjectPhaseResult, error) {
+	return a.runProjectPhases(ctx, p, phaseBuild), nil
+}
+
+// TestProject runs the test commands of every detected language.
+func (a *DataActions) TestProject(ctx context.Context, p ProjectPhaseParams) (ProjectPhaseResult, error) {
+	return a.runProjectPhases(ctx, p, phaseTest), nil
+}
+
+// LintProject runs the lint commands of every detected language, optionally formatting first.
+func (a *DataActions) LintProject(ctx context.Context, p LintProjectParams) (ProjectPhaseResult, error) {
+	if p.Format {
+		return a.runProjectPhases(ctx, p.ProjectPhaseParams, phaseFormat, phaseLint), nil
+	}
+	return a.runProjectPhases(ctx, p.ProjectPhaseParams, phaseLint), nil
+}
+
+// runProjectPhases runs the commands of phases for each selected language, in one
+// validation run per language. The result is named after the last phase.
+func (a *DataActions) runProjectPhases(ctx context.Context, p ProjectPhaseParams, phases ...string) ProjectPhaseResult {
+	res := ProjectPhaseResult{Phase: phases[len(phases)-1], Success: true, Runs: []ProjectRun{}}
+	profile, _ := a.ProjectProfile(ctx, NoParams{})
+	if profile.Error != "" {
+		res.Success, res.Error = false, profile.Error
+		return res
+	}
+	root := filepath.ToSlash(filepath.Clean(p.Root))
+	for _, lang := range profile.Languages {
+		if (p.Root != "" && lang.Root != root) || (p.Language != "" && lang.Name != p.Language) {
+			continue
+		}
+		var cmds [][]string
+		var unapproved []string
+		for _, phase := range phases {
+			for _, cmd := range lang.commands(phase) {
+				if len(cmd) == 0 {
+					continue
+				}
+				if lang.configured(phase) {
+					if reason := a.commandPolicyViolation(cmd[0], cmd[1:]); reason != "" {
+						unapproved = append(unapproved, fmt.Sprintf("%s (%s)", formatCommandLine(cmd[0], cmd[1:]), reason))
+					}
+				}
+				cmds = append(cmds, cmd)
+			}
+		}
+		if len(cmds) == 0 {
+			continue
+		}
+
+		run := ProjectRun{Language: lang.Name}
+		if len(unapproved) > 0 && !a.requestApproval(ctx, ApprovalRequest{
+			Action: "project_" + res.Phase,
+			Reason: fmt.Sprintf("%s configures commands outside the run_command allowlist: %s", configs.WorkspaceConfigFile, strings.Join(unapproved, "; ")),
+			Params: map[string]interface{}{"language": lang.Name, "root": lang.Root, "commands": cmds},
+		}) {
+			run.ValidationResult = ValidationResult{
+				Root:  lang.Root,
+				Error: fmt.Sprintf("commands denied: %s", strings.Join(unapproved, "; ")),
+			}
+		} else {
+			run.ValidationResult = a.runValidation(ctx, lang.Root, cmds)
+		}
+		if !run.Success {
+			res.Success = false
+		}
+		res.Runs = append(res.Runs, run)
+	}
+	if len(res.Runs) == 0 {
+		res.Success = false
+		res.Error = fmt.Sprintf("no %s commands found for this project; add them to %s", res.Phase, configs.WorkspaceConfigFile)
+	}
+	return res
+}
+
+// detectProjectLanguages looks for manifests in ws and its direct subdirectories.
+func detectProjectLanguages(ws string) []ProjectLanguage {
+	roots := []string{"."}
+	if entries, err := os.ReadDir(ws); err == nil {
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && !skippedProjectDirs[e.Name()] {
+				roots = append(roots, e.Name())
+			}
+		}
+	}
+	langs := []ProjectLanguage{}
+	for _, root := range roots {
+		langs = append(langs, detectRootLanguages(filepath.Join(ws, root), root)...)
+	}
+	return langs
+}
+
+// detectRootLanguages returns the languages whose manifest is in dir. Makefile targets
+// take over the matching phases of the other languages in the same root.
+func detectRootLanguages(dir, root string) []ProjectLanguage {
+	var langs []ProjectLanguage
+	if fileExists(filepath.Join(dir, "go.mod")) {
+		langs = append(langs, ProjectLanguage{
+			Name:     "go",
+			Root:     root,
+			Manifest: "go.mod",
+			Build:    [][]string{{"go", "build", "./..."}},
+			Test:     [][]string{{"go", "test", "./..."}},
+			Lint:     [][]string{{"go", "vet", "./..."}},
+			Format:   [][]string{{"gofmt", "-l", "-w", "."}},
+		})
+	}
+	if l, ok := detectNode(dir, root); ok {
+		langs = append(langs, l)
+	}
+	if l, ok := detectPython(dir, root); ok {
+		langs = append(langs, l)
+	}
+	if fileExists(filepath.Join(dir, "Cargo.toml")) {
+		langs = append(langs, ProjectLanguage{
+			Name:     "rust",
+			Root:     root,
+			Manifest: "Cargo.toml",
+			Build:    [][]string{{"cargo", "build"}},
+			Test:     [][]string{{"cargo", "test"}},
+			Lint:     [][]string{{"cargo", "clippy"}},
+			Format:   [][]string{{"cargo", "fmt"}},
+		})
+	}
+	if l, ok := detectMake(dir, root); ok {
+		for i := range langs {
+			for _, phase := range []string{phaseBuild, phaseTest, phaseLint, phaseFormat} {
+				if len(l.commands(phase)) > 0 {
+					langs[i].setCommands(phase, nil)
+				}
+			}
+		}
+		langs = append(langs, l)
+	}
+	return langs
+}
+
+func detectNode(dir, root string) (ProjectLanguage, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ProjectLanguage{}, false
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	_ = json.Unmarshal(data, &pkg) // A broken package.json still marks a node project
+
+	pm := "npm"
+	switch {
+	case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+		pm = "pnpm"
+	case fileExists(filepath.Join(dir, "yarn.lock")):
+		pm = "yarn"
+	}
+	script := func(names ...string) [][]string {
+		for _, name := range names {
+			if _, ok := pkg.Scripts[name]; ok {
+				return [][]string{{pm, "run", name}}
+			}
+		}
+		return nil
+	}
+	return ProjectLanguage{
+		Name:     "node",
+		Root:     root,
+		Manifest: "package.json",
+		Build:    script("build"),
+		Test:     script("test"),
+		Lint:     script("lint"),
+		Format:   script("format", "fmt"),
+	}, true
+}
+
+func detectPython(dir, root string) (ProjectLanguage, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		return ProjectLanguage{}, false
+	}
+	l := ProjectLanguage{
+		Name:     "python",
+		Root:     root,
+		Manifest: "pyproject.toml",
+		Build:    [][]string{{"python3", "-m", "compileall", "-q", "."}},
+		Test:     [][]string{{"python3", "-m", "pytest"}},
+	}
+	text := string(data)
+	switch {
+	case strings.Contains(text, "ruff"):
+		l.Lint = [][]string{{"ruff", "check", "."}}
+		l.Format = [][]string{{"ruff", "format", "."}}
+	case strings.Contains(text, "flake8"):
+		l.Lint = [][]string{{"python3", "-m", "flake8"}}
+	}
+	if l.Format == nil && strings.Contains(text, "black") {
+		l.Format = [][]string{{"black", "."}}
+	}
+	return l, true
+}
+
+func detectMake(dir, root string) (ProjectLanguage, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "Makefile"))
+	if err != nil {
+		return ProjectLanguage{}, false
+	}
+	targets := map[string]bool{}
+	for _, m := range makeTargetPattern.FindAllStringSubmatch(string(data), -1) {
+		targets[m[1]] = true
+	}
+	target := func(names ...string) [][]string {
+		for _, name := range names {
+			if targets[name] {
+				return [][]string{{"make", name}}
+			}
+		}
+		return nil
+	}
+	l := ProjectLanguage{
+		Name:     "make",
+		Root:     root,
+		Manifest: "Makefile",
+		Build:    target("build"),
+		Test:     target("test"),
+		Lint:     target("lint", "vet"),
+		Format:   target("fmt", "format"),
+	}
+	if l.Build == nil && l.Test == nil && l.Lint == nil && l.Format == nil {
+		return ProjectLanguage{}, false
+	}
+	return l, true
+}
+
+// applyProjectConfig merges .astra.yaml entries into the detected languages.
+func applyProjectConfig(langs []ProjectLanguage, overrides []configs.ProjectLanguageConfig) []ProjectLanguage {
+	for _, o := range overrides {
+		root := filepath.ToSlash(filepath.Clean(o.Root))
+		idx := -1
+		for i := range langs {
+			if langs[i].Name == o.Name && langs[i].Root == root {
+				idx = i
+				break
+			}
+		}
+		if o.Disabled {
+			if idx >= 0 {
+				langs = append(langs[:idx], langs[idx+1:]...)
+			}
+			continue
+		}
+		if idx < 0 {
+			langs = append(langs, ProjectLanguage{Name: o.Name, Root: root})
+			idx = len(langs) - 1
+		}
+		for phase, cmds := range map[string][][]string{phaseBuild: o.Build, phaseTest: o.Test, phaseLint: o.Lint, phaseFormat: o.Format} {
+			if len(cmds) > 0 {
+				langs[idx].setCommands(phase, cmds)
+				langs[idx].Configured = append(langs[idx].Configured, phase)
+			}
+		}
+		sort.Strings(langs[idx].Configured)
+	}
+	return langs
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectProfile_DetectsAndAppliesConfig(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"go.mod":                "module example.com/demo\n\ngo 1.21\n",
+		"Makefile":              "GO ?= go\n\ntest: deps\n\tgo test ./...\n\ndeps:\n\tgo mod download\n",
+		"frontend/package.json": `{"scripts": {"build": "vite build", "lint": "eslint ."}}`,
+		"frontend/yarn.lock":    "",
+		"tools/pyproject.toml":  "[tool.ruff]\nline-length = 100\n",
+		"node_modules/x/go.mod": "module x\n",
+		".astra.yaml": `project:
+  languages:
+    - name: go
+      lint: [["golangci-lint", "run"]]
+    - name: python
+      root: tools
+      disabled: true
+`,
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+
+	profile, _ := a.ProjectProfile(t.Context(), NoParams{})
+	if profile.Error != "" || profile.ConfigFile != ".astra.yaml" {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	byKey := map[string]ProjectLanguage{}
+	for _, l := range profile.Languages {
+		byKey[l.Name+"@"+l.Root] = l
+	}
+	if len(byKey) != 3 {
+		t.Fatalf("expected go, make and node, got %+v", profile.Languages)
+	}
+
+	goLang := byKey["go@."]
+	if goLang.Test != nil {
+		t.Errorf("make test target should replace go test, got %v", goLang.Test)
+	}
+	if !reflect.DeepEqual(goLang.Lint, [][]string{{"golangci-lint", "run"}}) || !reflect.DeepEqual(goLang.Configured, []string{"lint"}) {
+		t.Errorf("lint override not applied: %+v", goLang)
+	}
+	if got := byKey["make@."].Test; !reflect.DeepEqual(got, [][]string{{"make", "test"}}) {
+		t.Errorf("make test = %v", got)
+	}
+	node := byKey["node@frontend"]
+	if !reflect.DeepEqual(node.Build, [][]string{{"yarn", "run", "build"}}) || node.Test != nil {
+		t.Errorf("unexpected node commands: %+v", node)
+	}
+}
+
+func TestRunProjectPhases_DeniesUnlistedConfiguredCommands(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"go.mod":      "module example.com/demo\n\ngo 1.21\n",
+		".astra.yaml": "project:\n  languages:\n    - name: go\n      build: [[\"astra-missing-tool-xyz\"]]\n",
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+
+	res, _ := a.BuildProject(t.Context(), ProjectPhaseParams{})
+	if res.Success || len(res.Runs) != 1 || res.Runs[0].Error == "" || len(res.Runs[0].Steps) != 0 {
+		t.Fatalf("expected the configured command to be denied, got %+v", res)
+	}
+}
@@ -0,0 +1,181 @@
+package actions
+
+import (
+	"astra/astra/utils/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Handler is the type-erased form of a registered action: it decodes params and runs
+// the action. Register builds it from a typed function.
+type Handler func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// NoParams is the params type of actions that take none; whatever the caller sends is ignored.
+type NoParams struct{}
+
+// ActionInfo describes the call an action runs in. ExecuteActionContext stores it in the
+// context handed to the action.
+type ActionInfo struct {
+	Action    string
+	RunID     string // Set by WithRunID; groups the calls of one processed query
+	UserID    int
+	Agent     string
+	SessionID string
+	Channel   string // web, cli or mcp
+	Workspace string
+}
+
+type actionInfoKey struct{}
+
+// WithActionInfo returns a copy of ctx carrying info.
+func WithActionInfo(ctx context.Context, info ActionInfo) context.Context {
+	return context.WithValue(ctx, actionInfoKey{}, info)
+}
+
+// ActionInfoFromContext returns the call info stored by ExecuteActionContext.
+func ActionInfoFromContext(ctx context.Context) (ActionInfo, bool) {
+	info, ok := ctx.Value(actionInfoKey{}).(ActionInfo)
+	return info, ok
+}
+
+// Register adds an action implemented by fn. Params (and from it the schema) default to
+// P; struct params are validated against the schema before fn is called, so the caller
+// gets every field problem at once. Map params are validated by the action itself.
+func Register[P, R any](a *DataActions, spec ActionSpec, fn func(context.Context, P) (R, error)) {
+	var zero P
+	if spec.Params == nil {
+		spec.Params = zero
+	}
+	if spec.Schema == nil {
+		spec.Schema = paramsSchema(spec.Params)
+	}
+	t := derefType(reflect.TypeOf(&zero).Elem())
+	noParams := t.Kind() == reflect.Struct && t.NumField() == 0
+	validate := t.Kind() == reflect.Struct && !noParams
+	name, schema := spec.Name, spec.Schema
+
+	spec.Fn = func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var p P
+		if noParams || len(raw) == 0 {
+			return fn(ctx, p)
+		}
+		trace := executionTraceFromContext(ctx)
+		if validate {
+			var decoded interface{}
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				return nil, fmt.Errorf("failed to decode params: %w", err)
+			}
+			if decoded == nil {
+				decoded = map[string]interface{}{}
+			}
+			if fieldErrs := validateParams(schema, decoded, ""); len(fieldErrs) > 0 {
+				return nil, &ParamsError{Action: name, Fields: fieldErrs}
+			}
+			// Re-encode with defaults filled in.
+			var err error
+			if raw, err = json.Marshal(decoded); err != nil {
+				return nil, fmt.Errorf("failed to marshal params: %w", err)
+			}
+		}
+		if trace != nil {
+			trace.setParams(raw)
+		}
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal params into %T: %w", p, err)
+		}
+		return fn(ctx, p)
+	}
+	a.register(spec)
+}
+
+// register adds an action spec to the registry, deriving its schema from Params.
+func (a *DataActions) register(spec ActionSpec) {
+	if spec.Schema == nil {
+		spec.Schema = paramsSchema(spec.Params)
+	}
+	a.actions[spec.Name] = spec
+}
+
+// ExecuteAction runs a registered action without a deadline; see ExecuteActionContext.
+func (a *DataActions) ExecuteAction(name string, rawParams map[string]interface{}) (map[string]interface{}, error) {
+	return a.ExecuteActionContext(context.Background(), name, rawParams)
+}
+
+// ExecuteActionContext executes a registered action by name using the provided params (map).
+// Calls the action policy forbids fail with a *PolicyError; the action gets ctx extended
+// with its ActionInfo. It returns the action's result as a map[string]interface{} or an
+// error; a panicking action is reported as an error. Every call that reaches the policy
+// is recorded in the execution history.
+func (a *DataActions) ExecuteActionContext(ctx context.Context, name string, rawParams map[string]interface{}) (result map[string]interface{}, err error) {
+	spec, ok := a.actions[name]
+	if !ok {
+		return nil, fmt.Errorf("action not found: %s", name)
+	}
+	if spec.Fn == nil {
+		return nil, fmt.Errorf("no function registered for action: %s", name)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	paramBytes, err := json.Marshal(rawParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+
+	info := a.actionInfo(name)
+	info.RunID = runIDFromContext(ctx)
+	trace := &executionTrace{}
+	ctx = context.WithValue(WithActionInfo(ctx, info), executionTraceKey{}, trace)
+	started := time.Now()
+	defer func() {
+		a.recordExecution(ctx, info, trace, paramBytes, started, result, err)
+	}()
+
+	if err := a.enforcePolicy(ctx, info, rawParams); err != nil {
+		return nil, err
+	}
+	if err := a.enforceContainment(ctx, info, rawParams); err != nil {
+		return nil, err
+	}
+	a.beginActionOutput(name)
+
+	out, err := callAction(ctx, spec, paramBytes)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, nil
+	}
+
+	// marshal then unmarshal into map for simplicity
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal action result: %w", err)
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		// If result isn't a JSON object (could be a primitive), wrap it
+		return map[string]interface{}{"result": out}, nil
+	}
+	return result, nil
+}
+
+func (a *DataActions) actionInfo(name string) ActionInfo {
+	return ActionInfo{Action: name, UserID: a.UserID, Agent: a.AgentName, SessionID: a.SessionID, Channel: a.Channel, Workspace: a.Workspace}
+}
+
+// callAction runs the action's handler, turning a panic into an error.
+func callAction(ctx context.Context, spec ActionSpec, params json.RawMessage) (out interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.ErrorLogger.Error("action panicked", zap.String("action", spec.Name), zap.Any("recover", r), zap.ByteString("stack", debug.Stack()))
+			out, err = nil, fmt.Errorf("action %s panicked: %v", spec.Name, r)
+		}
+	}()
+	return spec.Fn(ctx, params)
+}
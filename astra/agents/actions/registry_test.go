@@ -0,0 +1,37 @@
+package actions
+
+import (
+	"astra/astra/utils/logging"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegister_PassesActionInfoAndRecoversPanics(t *testing.T) {
+	logging.InitLogger()
+	a := &DataActions{actions: make(map[string]ActionSpec), UserID: 7, SessionID: "s1", Workspace: t.TempDir()}
+	Register(a, ActionSpec{Name: "whoami"}, func(ctx context.Context, _ NoParams) (ActionInfo, error) {
+		info, _ := ActionInfoFromContext(ctx)
+		return info, nil
+	})
+	Register(a, ActionSpec{Name: "boom"}, func(ctx context.Context, p RunCommandParams) (RunCommandResult, error) {
+		panic("boom: " + p.Command)
+	})
+
+	res, err := a.ExecuteAction("whoami", map[string]interface{}{"ignored": true})
+	if err != nil || res["Action"] != "whoami" || res["UserID"] != float64(7) || res["SessionID"] != "s1" {
+		t.Errorf("unexpected action info: %v (%v)", res, err)
+	}
+
+	_, err = a.ExecuteAction("boom", map[string]interface{}{"command": "go"})
+	if err == nil || !strings.Contains(err.Error(), "action boom panicked: boom: go") {
+		t.Errorf("expected the panic to be returned as an error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := a.ExecuteActionContext(ctx, "whoami", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled context to stop the call, got %v", err)
+	}
+}
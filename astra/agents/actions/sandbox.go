@@ -0,0 +1,314 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCommandTimeout = 60 * time.Second
+	defaultMaxOutputBytes = 64 * 1024
+)
+
+// defaultEnvPassthrough is used when the agent config does not list any variables.
+var defaultEnvPassthrough = []string{"PATH", "HOME", "USER", "LANG", "TMPDIR"}
+
+// secretEnvPattern matches variable names that must never reach a child process,
+// even when they are listed in env_passthrough.
+var secretEnvPattern = regexp.MustCompile(`(?i)(KEY|SECRET|TOKEN|PASSWORD|PASSWD|CREDENTIAL|PRIVATE|DSN|AUTH)`)
+
+// RunCommandParams defines a single command invocation.
+type RunCommandParams struct {
+	Command        string   `json:"command" required:"true" desc:"Binary name, e.g. go"`
+	Args           []string `json:"args" desc:"Arguments passed verbatim (no shell)"`
+	Dir            string   `json:"dir,omitempty" desc:"Working directory relative to the workspace"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty" desc:"Timeout in seconds, capped by the agent's configured timeout"`
+}
+
+// RunCommandResult holds the outcome of a sandboxed command.
+type RunCommandResult struct {
+	Command   string `json:"command"`
+	Dir       string `json:"dir"`
+	ExitCode  int    `json:"exit_code"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	TimedOut  bool   `json:"timed_out,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Approved  bool   `json:"approved_by_user,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// sandboxCommand is a fully resolved command ready for runSandboxed.
+type sandboxCommand struct {
+	Binary         string
+	Args           []string
+	Dir            string // absolute, already confined to the workspace
+	Timeout        time.Duration
+	MaxOutputBytes int  // overrides the policy cap when > 0
+	Stream         bool // send output lines to the output sink while the command runs
+}
+
+// SetCommandPolicy applies the agent's run_command configuration.
+func (a *DataActions) SetCommandPolicy(cfg configs.RunCommandConfig) {
+	a.commandPolicy = cfg
+}
+
+// RunCommand runs an allowlisted binary inside the workspace. Commands outside the
+// allowlist are sent to the approval gate instead of being rejected outright.
+func (a *DataActions) RunCommand(ctx context.Context, params RunCommandParams) (RunCommandResult, error) {
+	if strings.TrimSpace(params.Command) == "" {
+		return RunCommandResult{ExitCode: -1, Error: "command is required"}, nil
+	}
+	dir, err := a.resolveInWorkspace(params.Dir)
+	if err != nil {
+		return RunCommandResult{Command: params.Command, ExitCode: -1, Error: err.Error()}, nil
+	}
+
+	approved := false
+	if reason := a.commandPolicyViolation(params.Command, params.Args); reason != "" {
+		approved = a.requestApproval(ctx, ApprovalRequest{
+			Action: "run_command",
+			Reason: reason,
+			Params: map[string]interface{}{"command": params.Command, "args": params.Args, "dir": dir},
+		})
+		if !approved {
+			return RunCommandResult{
+				Command:  formatCommandLine(params.Command, params.Args),
+				Dir:      dir,
+				ExitCode: -1,
+				Error:    "command denied: " + reason,
+			}, nil
+		}
+	}
+
+	timeout := a.commandTimeout()
+	if params.TimeoutSeconds > 0 && time.Duration(params.TimeoutSeconds)*time.Second < timeout {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+
+	res := a.runSandboxed(ctx, sandboxCommand{
+		Binary:  params.Command,
+		Args:    params.Args,
+		Dir:     dir,
+		Timeout: timeout,
+		Stream:  true,
+	})
+	res.Approved = approved
+	return res, nil
+}
+
+// commandPolicyViolation returns a human-readable reason when the command is not
+// covered by the allowlist, or "" when it is allowed.
+func (a *DataActions) commandPolicyViolation(binary string, args []string) string {
+	if strings.ContainsAny(binary, `/\`) {
+		return fmt.Sprintf("binary %q must be a bare name resolved from PATH", binary)
+	}
+	for _, rule := range a.commandPolicy.Allowlist {
+		if rule.Binary != binary {
+			continue
+		}
+		for _, arg := range args {
+			if reason := pathArgViolation(arg); reason != "" {
+				return reason
+			}
+		}
+		rest, ok := matchSubcommand(args, rule.Subcommands)
+		if !ok {
+			return fmt.Sprintf("subcommand %q is not allowed for %s", strings.Join(args, " "), binary)
+		}
+		for _, arg := range rest {
+			if !argAllowed(arg, rule.Args) {
+				return fmt.Sprintf("argument %q is not allowed for %s", arg, binary)
+			}
+		}
+		return ""
+	}
+	return fmt.Sprintf("binary %q is not in the allowlist", binary)
+}
+
+// matchSubcommand returns the arguments following the first subcommand that args
+// starts with. With no subcommands configured every argument is returned as is.
+func matchSubcommand(args, subcommands []string) ([]string, bool) {
+	if len(subcommands) == 0 {
+		return args, true
+	}
+	for _, sub := range subcommands {
+		words := strings.Fields(sub)
+		if len(words) <= len(args) && slices.Equal(words, args[:len(words)]) {
+			return args[len(words):], true
+		}
+	}
+	return nil, false
+}
+
+// pathArgViolation rejects arguments that name a location outside the workspace,
+// whether on their own or as a flag value such as --output=/tmp/x.
+func pathArgViolation(arg string) string {
+	value := arg
+	if i := strings.IndexByte(arg, '='); i >= 0 && strings.HasPrefix(arg, "-") {
+		value = arg[i+1:]
+	}
+	if filepath.IsAbs(value) || strings.HasPrefix(value, "~") {
+		return fmt.Sprintf("argument %q is an absolute path", arg)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(value), "/") {
+		if part == ".." {
+			return fmt.Sprintf("argument %q points outside the workspace", arg)
+		}
+	}
+	return ""
+}
+
+// argAllowed reports whether arg fully matches at least one of the patterns.
+func argAllowed(arg string, patterns []string) bool {
+	for _, p := range patterns {
+		re, err := regexp.Compile(`^(?:` + p + `)$`)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *DataActions) commandTimeout() time.Duration {
+	if a.commandPolicy.TimeoutSeconds > 0 {
+		return time.Duration(a.commandPolicy.TimeoutSeconds) * time.Second
+	}
+	return defaultCommandTimeout
+}
+
+func (a *DataActions) maxOutputBytes() int {
+	if a.commandPolicy.MaxOutputBytes > 0 {
+		return a.commandPolicy.MaxOutputBytes
+	}
+	return defaultMaxOutputBytes
+}
+
+// runSandboxed executes a resolved command with a timeout, a filtered environment
+// and capped stdout/stderr. It never invokes a shell.
+func (a *DataActions) runSandboxed(ctx context.Context, sc sandboxCommand) RunCommandResult {
+	res := RunCommandResult{Command: formatCommandLine(sc.Binary, sc.Args), Dir: sc.Dir}
+
+	if _, err := exec.LookPath(sc.Binary); err != nil {
+		res.ExitCode = -1
+		res.Error = fmt.Sprintf("%s is not installed or not in PATH", sc.Binary)
+		return res
+	}
+
+	timeout := sc.Timeout
+	if timeout <= 0 {
+		timeout = a.commandTimeout()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	limit := a.maxOutputBytes()
+	if sc.MaxOutputBytes > 0 {
+		limit = sc.MaxOutputBytes
+	}
+	stdout := &cappedBuffer{limit: limit}
+	stderr := &cappedBuffer{limit: limit}
+
+	cmd := exec.CommandContext(ctx, sc.Binary, sc.Args...)
+	cmd.Dir = sc.Dir
+	cmd.Env = a.filteredEnv()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = 5 * time.Second
+	if sc.Stream && a.streaming() {
+		outLines := &lineEmitter{a: a, stream: "stdout"}
+		errLines := &lineEmitter{a: a, stream: "stderr"}
+		cmd.Stdout = io.MultiWriter(stdout, outLines)
+		cmd.Stderr = io.MultiWriter(stderr, errLines)
+		defer outLines.Flush()
+		defer errLines.Flush()
+	}
+
+	err := cmd.Run()
+	res.Stdout = stdout.String()
+	res.Stderr = stderr.String()
+	res.Truncated = stdout.truncated || stderr.truncated
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		res.TimedOut = true
+		res.ExitCode = -1
+		res.Error = fmt.Sprintf("command timed out after %s", timeout)
+		return res
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		res.ExitCode = 0
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	default:
+		res.ExitCode = -1
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// filteredEnv builds the child environment from the passthrough list, dropping
+// anything that looks like a secret.
+func (a *DataActions) filteredEnv() []string {
+	allowed := a.commandPolicy.EnvPassthrough
+	if len(allowed) == 0 {
+		allowed = defaultEnvPassthrough
+	}
+	env := make([]string, 0, len(allowed))
+	for _, name := range allowed {
+		if secretEnvPattern.MatchString(name) {
+			continue
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+func formatCommandLine(binary string, args []string) string {
+	return strings.TrimSpace(binary + " " + strings.Join(args, " "))
+}
+
+// cappedBuffer keeps at most limit bytes and records whether anything was dropped.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	remaining := c.limit - c.buf.Len()
+	if remaining <= 0 {
+		c.truncated = len(p) > 0 || c.truncated
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		c.buf.Write(p[:remaining])
+		c.truncated = true
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+func (c *cappedBuffer) String() string {
+	if c.truncated {
+		return c.buf.String() + "\n... (output truncated)"
+	}
+	return c.buf.String()
+}
@@ -0,0 +1,164 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newSandboxTestActions(t *testing.T) *DataActions {
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+	a.SetCommandPolicy(configs.RunCommandConfig{
+		Allowlist: []configs.CommandRule{
+			{Binary: "go", Args: []string{"version", "env", "GOROOT"}},
+		},
+		TimeoutSeconds: 30,
+		MaxOutputBytes: 16,
+		EnvPassthrough: []string{"PATH", "HOME", "OPENAI_API_KEY"},
+	})
+	return a
+}
+
+func TestRunCommand_AllowlistedCommand(t *testing.T) {
+	a := newSandboxTestActions(t)
+	res, _ := a.RunCommand(t.Context(), RunCommandParams{Command: "go", Args: []string{"version"}})
+	if res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	if res.ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", res.ExitCode)
+	}
+	if !res.Truncated || !strings.HasPrefix(res.Stdout, "go version") {
+		t.Errorf("expected truncated stdout starting with 'go version', got %q", res.Stdout)
+	}
+}
+
+func TestRunCommand_DeniedWithoutApproval(t *testing.T) {
+	a := newSandboxTestActions(t)
+	res, _ := a.RunCommand(t.Context(), RunCommandParams{Command: "go", Args: []string{"run", "main.go"}})
+	if res.ExitCode != -1 || !strings.Contains(res.Error, "command denied") {
+		t.Errorf("expected denial, got %+v", res)
+	}
+}
+
+func TestRunCommand_ApprovalGate(t *testing.T) {
+	a := newSandboxTestActions(t)
+	var asked ApprovalRequest
+	a.SetApprovalGate(func(req ApprovalRequest) bool {
+		asked = req
+		return true
+	})
+	res, _ := a.RunCommand(t.Context(), RunCommandParams{Command: "go", Args: []string{"help"}})
+	if asked.Action != "run_command" {
+		t.Fatalf("expected approval request for run_command, got %+v", asked)
+	}
+	if !res.Approved || res.Error != "" {
+		t.Errorf("expected approved run, got %+v", res)
+	}
+}
+
+func TestRunCommand_DirOutsideWorkspace(t *testing.T) {
+	a := newSandboxTestActions(t)
+	res, _ := a.RunCommand(t.Context(), RunCommandParams{Command: "go", Args: []string{"version"}, Dir: "../.."})
+	if !strings.Contains(res.Error, "outside the workspace") {
+		t.Errorf("expected workspace confinement error, got %+v", res)
+	}
+}
+
+func TestFilteredEnv_DropsSecrets(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	a := newSandboxTestActions(t)
+	for _, kv := range a.filteredEnv() {
+		if strings.HasPrefix(kv, "OPENAI_API_KEY=") {
+			t.Fatalf("secret leaked into child env: %s", kv)
+		}
+	}
+}
+
+func TestRunCommand_StreamsOutputLines(t *testing.T) {
+	a := newSandboxTestActions(t)
+	var got []ActionOutput
+	a.SetOutputSink(func(out ActionOutput) { got = append(got, out) })
+	a.beginActionOutput("run_command")
+
+	res, _ := a.RunCommand(t.Context(), RunCommandParams{Command: "go", Args: []string{"version"}})
+	if res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	// The result is capped at 16 bytes; the streamed line is not.
+	if len(got) != 1 || got[0].Action != "run_command" || got[0].Stream != "stdout" ||
+		!strings.HasPrefix(got[0].Line, "go version") || len(got[0].Line) <= 16 {
+		t.Errorf("unexpected streamed output: %+v", got)
+	}
+}
+
+func TestLineEmitter_CapsStreamedLines(t *testing.T) {
+	a := &DataActions{}
+	lines := 0
+	var last ActionOutput
+	a.SetOutputSink(func(out ActionOutput) {
+		lines++
+		last = out
+	})
+	w := &lineEmitter{a: a, stream: "stdout"}
+	w.Write([]byte(strings.Repeat("line\r\n", maxStreamedLines+10) + "\x1b[32mtail"))
+	w.Flush()
+	if lines != maxStreamedLines+1 || last.Message != "further output is not streamed" {
+		t.Errorf("expected %d lines and a cap notice, got %d, last %+v", maxStreamedLines+1, lines, last)
+	}
+}
+
+func TestCommandPolicyViolation_SubcommandsAndPaths(t *testing.T) {
+	a := &DataActions{}
+	a.SetCommandPolicy(configs.RunCommandConfig{
+		Allowlist: []configs.CommandRule{
+			{Binary: "go", Subcommands: []string{"build", "mod tidy"}, Args: []string{"-v", "[a-zA-Z0-9_.][a-zA-Z0-9_./-]*"}},
+			{Binary: "git", Subcommands: []string{"diff"}, Args: []string{"--stat", "[a-zA-Z0-9_.][a-zA-Z0-9_./~^-]*"}},
+		},
+	})
+	allowed := [][]string{
+		{"go", "build", "-v", "./..."},
+		{"go", "mod", "tidy"},
+		{"git", "diff", "main..HEAD"},
+	}
+	for _, cmd := range allowed {
+		if reason := a.commandPolicyViolation(cmd[0], cmd[1:]); reason != "" {
+			t.Errorf("expected %v to be allowed, got %q", cmd, reason)
+		}
+	}
+	denied := [][]string{
+		{"go", "run", "main.go"},
+		{"go", "mod", "download"},
+		{"go", "build", "-toolexec=x"},
+		{"go", "build", "/etc"},
+		{"go", "build", "../other"},
+		{"git", "diff", "--output=/tmp/x"},
+		{"git", "diff", "~/x"},
+	}
+	for _, cmd := range denied {
+		if reason := a.commandPolicyViolation(cmd[0], cmd[1:]); reason == "" {
+			t.Errorf("expected %v to be denied", cmd)
+		}
+	}
+}
+
+func TestResolveInWorkspace_RejectsSymlinkEscape(t *testing.T) {
+	a := &DataActions{Workspace: t.TempDir()}
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(a.Workspace, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "missing"), filepath.Join(a.Workspace, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"link", "link/new/file.go", "dangling"} {
+		if _, err := a.resolveInWorkspace(p); err == nil {
+			t.Errorf("expected %s to be rejected", p)
+		}
+	}
+	if _, err := a.resolveInWorkspace("new/dir/file.go"); err != nil {
+		t.Errorf("expected a new path inside the workspace to resolve, got %v", err)
+	}
+}
@@ -0,0 +1,465 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+const defaultScaffoldTemplatesDir = ".astra/templates/scaffold"
+
+//go:embed templates/scaffold/*.go.tmpl
+var defaultScaffoldTemplates embed.FS
+
+var (
+	scaffoldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	goModulePattern     = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	daoCtorLinePattern  = regexp.MustCompile(`^\s*\w+ := dao\.New\w+\(.*\)\s*$`)
+	ctrlCtorLinePattern = regexp.MustCompile(`^\s*\w+ := controllers\.New\w+\(.*\)\s*$`)
+	mountLinePattern    = regexp.MustCompile(`^\s*r\.Mount\("`)
+	migrateLinePattern  = regexp.MustCompile(`^\s*&models\.\w+\{\},\s*$`)
+)
+
+// scaffoldFieldTypes maps a field type to its Go type and gorm column type.
+var scaffoldFieldTypes = map[string][2]string{
+	"string": {"string", "type:varchar(255)"},
+	"text":   {"string", "type:text"},
+	"int":    {"int", ""},
+	"float":  {"float64", ""},
+	"bool":   {"bool", "default:false"},
+	"time":   {"time.Time", ""},
+	"uuid":   {"uuid.UUID", "type:uuid"},
+}
+
+// scaffoldReservedFields are generated for every resource.
+var scaffoldReservedFields = map[string]bool{"id": true, "user_id": true, "user": true, "created_at": true, "updated_at": true}
+
+// scaffoldReservedVars would clash with keywords or identifiers used by the templates
+// when used as a resource variable name.
+var scaffoldReservedVars = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true,
+	"goto": true, "if": true, "import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true, "switch": true, "type": true,
+	"var": true, "c": true, "r": true, "w": true, "id": true, "err": true, "req": true, "res": true,
+	"ctx": true, "cfg": true, "db": true, "dao": true, "models": true, "controllers": true,
+	"routes": true, "config": true, "middlewares": true, "uuid": true, "time": true, "errors": true,
+	"json": true, "http": true, "chi": true, "gorm": true, "context": true, "updates": true, "userID": true,
+}
+
+// ScaffoldField is one column of a scaffolded resource.
+type ScaffoldField struct {
+	Name     string `json:"name" required:"true" desc:"Column name in snake_case, e.g. due_date"`
+	Type     string `json:"type" required:"true" enum:"string,text,int,float,bool,time,uuid" desc:"Column type"`
+	Required bool   `json:"required,omitempty" desc:"NOT NULL, and required when creating"`
+}
+
+type ScaffoldResourceParams struct {
+	Name    string          `json:"name" required:"true" desc:"Singular resource name in snake_case, e.g. project_task"`
+	Fields  []ScaffoldField `json:"fields" required:"true" desc:"Columns besides id, user_id and timestamps"`
+	Unowned bool            `json:"unowned,omitempty" desc:"Do not tie rows to the authenticated user (no user_id column or ownership checks)"`
+	DryRun  bool            `json:"dry_run,omitempty" desc:"Only return the diff; do not write any file"`
+}
+
+// ScaffoldResourceResult lists the files written and the diff of every change.
+type ScaffoldResourceResult struct {
+	Created  []string `json:"created"`
+	Modified []string `json:"modified"`
+	Diff     string   `json:"diff"`
+	DryRun   bool     `json:"dry_run,omitempty"`
+	Warnings []string `json:"warnings,omitempty"` // Wiring that has to be done by hand
+	Error    string   `json:"error,omitempty"`
+}
+
+// scaffoldData is what the templates are rendered with.
+type scaffoldData struct {
+	Module      string // Import path of the backend root, e.g. astra/astra
+	Dir         string // Backend root relative to the workspace with a trailing slash, e.g. astra/
+	Name        string // ProjectTask
+	Plural      string // ProjectTasks
+	Snake       string // project_task
+	Table       string // project_tasks
+	Route       string // /project_tasks
+	Var         string // projectTask
+	VarPlural   string // projectTasks
+	Receiver    string // p
+	Human       string // project task
+	HumanPlural string // project tasks
+	Owned       bool
+	NeedsTime   bool
+	Fields      []scaffoldFieldData
+}
+
+type scaffoldFieldData struct {
+	Name     string // DueDate
+	Column   string // due_date
+	GoType   string
+	GormTag  string
+	Required bool
+}
+
+// scaffoldFile is one file the scaffold creates or changes.
+type scaffoldFile struct {
+	rel     string // Relative to the workspace
+	before  string
+	after   string
+	created bool
+}
+
+// ScaffoldResource generates the model, DAO, controller and route of a new resource,
+// mounts the routes in main.go and adds the model to AutoMigrate.
+func (a *DataActions) ScaffoldResource(ctx context.Context, p ScaffoldResourceParams) (ScaffoldResourceResult, error) {
+	res := ScaffoldResourceResult{Created: []string{}, Modified: []string{}, DryRun: p.DryRun}
+	fail := func(format string, args ...interface{}) ScaffoldResourceResult {
+		res.Error = fmt.Sprintf(format, args...)
+		return res
+	}
+
+	ws, err := a.workspaceRoot()
+	if err != nil {
+		return fail("failed to get workspace root: %v", err), nil
+	}
+	cfg, err := configs.LoadWorkspaceConfig(ws)
+	if err != nil {
+		return fail("failed to load %s: %v", configs.WorkspaceConfigFile, err), nil
+	}
+	backend := cfg.Scaffold.BackendRoot
+	if backend == "" {
+		if backend = detectBackendRoot(ws); backend == "" {
+			return fail("no backend with sources/psql/models found; set scaffold.backend_root in %s", configs.WorkspaceConfigFile), nil
+		}
+	}
+	backend = filepath.ToSlash(filepath.Clean(backend))
+	if _, err := a.resolveInWorkspace(backend); err != nil {
+		return fail("%v", err), nil
+	}
+	module, err := backendImportPath(ws, backend)
+	if err != nil {
+		return fail("%v", err), nil
+	}
+	data, err := newScaffoldData(p, module)
+	if err != nil {
+		return fail("%v", err), nil
+	}
+	if backend != "." {
+		data.Dir = backend + "/"
+	}
+	tmpls, err := loadScaffoldTemplates(filepath.Join(ws, templatesDirOrDefault(cfg.Scaffold.TemplatesDir)))
+	if err != nil {
+		return fail("%v", err), nil
+	}
+
+	var files []scaffoldFile
+	for _, layer := range []struct{ tmpl, rel string }{
+		{"model", "sources/psql/models/" + data.Snake + ".go"},
+		{"dao", "sources/psql/dao/dao." + data.Snake + ".go"},
+		{"controller", "controllers/" + data.Snake + ".go"},
+		{"route", "routes/" + data.Snake + ".go"},
+	} {
+		rel := path.Join(backend, layer.rel)
+		if fileExists(filepath.Join(ws, rel)) {
+			return fail("%s already exists", rel), nil
+		}
+		var buf bytes.Buffer
+		if err := tmpls.ExecuteTemplate(&buf, layer.tmpl+".go.tmpl", data); err != nil {
+			return fail("rendering %s template: %v", layer.tmpl, err), nil
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fail("%s template produced invalid Go: %v", layer.tmpl, err), nil
+		}
+		files = append(files, scaffoldFile{rel: rel, after: string(src), created: true})
+	}
+
+	wirings := []struct {
+		rel  string
+		wire func(string, *scaffoldData) (string, error)
+	}{
+		{path.Join(backend, "main.go"), wireScaffoldMain},
+		{path.Join(backend, "sources/psql/database.go"), wireScaffoldMigration},
+	}
+	for _, w := range wirings {
+		before, err := os.ReadFile(filepath.Join(ws, w.rel))
+		if err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("%s: %v", w.rel, err))
+			continue
+		}
+		after, err := w.wire(string(before), data)
+		if err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("%s: %v", w.rel, err))
+			continue
+		}
+		files = append(files, scaffoldFile{rel: w.rel, before: string(before), after: after})
+	}
+
+	var diff strings.Builder
+	for _, f := range files {
+		diff.WriteString(unifiedDiff(f.rel, f.before, f.after))
+		if f.created {
+			res.Created = append(res.Created, f.rel)
+		} else {
+			res.Modified = append(res.Modified, f.rel)
+		}
+	}
+	res.Diff = diff.String()
+	if p.DryRun {
+		return res, nil
+	}
+	for _, f := range files {
+		abs := filepath.Join(ws, filepath.FromSlash(f.rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0755); err != nil {
+			return fail("failed to create directory for %s: %v", f.rel, err), nil
+		}
+		if err := os.WriteFile(abs, []byte(f.after), 0644); err != nil {
+			return fail("failed to write %s: %v", f.rel, err), nil
+		}
+	}
+	return res, nil
+}
+
+func newScaffoldData(p ScaffoldResourceParams, module string) (*scaffoldData, error) {
+	if !scaffoldNamePattern.MatchString(p.Name) {
+		return nil, fmt.Errorf("invalid resource name %q: use singular snake_case, e.g. project_task", p.Name)
+	}
+	if len(p.Fields) == 0 {
+		return nil, fmt.Errorf("at least one field is required")
+	}
+	name := pascalCase(p.Name)
+	words := strings.Split(p.Name, "_")
+	pluralSnake := strings.Join(append(words[:len(words)-1:len(words)-1], pluralize(words[len(words)-1])), "_")
+	d := &scaffoldData{
+		Module:      module,
+		Name:        name,
+		Plural:      pascalCase(pluralSnake),
+		Snake:       p.Name,
+		Table:       pluralSnake,
+		Route:       "/" + pluralSnake,
+		Var:         lowerFirst(name),
+		VarPlural:   lowerFirst(pascalCase(pluralSnake)),
+		Receiver:    p.Name[:1],
+		Human:       strings.Join(words, " "),
+		HumanPlural: strings.ReplaceAll(pluralSnake, "_", " "),
+		Owned:       !p.Unowned,
+	}
+	if scaffoldReservedVars[d.Var] || scaffoldReservedVars[d.VarPlural] {
+		return nil, fmt.Errorf("resource name %q clashes with a Go keyword or generated identifier", p.Name)
+	}
+	seen := map[string]bool{}
+	for _, f := range p.Fields {
+		if !scaffoldNamePattern.MatchString(f.Name) {
+			return nil, fmt.Errorf("invalid field name %q: use snake_case", f.Name)
+		}
+		if scaffoldReservedFields[f.Name] || seen[f.Name] {
+			return nil, fmt.Errorf("field %q is reserved or duplicated", f.Name)
+		}
+		seen[f.Name] = true
+		t, ok := scaffoldFieldTypes[f.Type]
+		if !ok {
+			return nil, fmt.Errorf("field %q has unknown type %q", f.Name, f.Type)
+		}
+		var tags []string
+		if t[1] != "" {
+			tags = append(tags, t[1])
+		}
+		if f.Required || f.Type == "bool" {
+			tags = append(tags, "not null")
+		}
+		if f.Type == "time" {
+			d.NeedsTime = true
+		}
+		d.Fields = append(d.Fields, scaffoldFieldData{
+			Name:     pascalCase(f.Name),
+			Column:   f.Name,
+			GoType:   t[0],
+			GormTag:  strings.Join(tags, ";"),
+			Required: f.Required,
+		})
+	}
+	return d, nil
+}
+
+// loadScaffoldTemplates returns the built-in templates, replaced by any file of the same
+// name in dir.
+func loadScaffoldTemplates(dir string) (*template.Template, error) {
+	tmpls, err := template.ParseFS(defaultScaffoldTemplates, "templates/scaffold/*.go.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	custom, err := filepath.Glob(filepath.Join(dir, "*.go.tmpl"))
+	if err != nil || len(custom) == 0 {
+		return tmpls, nil
+	}
+	if tmpls, err = tmpls.ParseFiles(custom...); err != nil {
+		return nil, fmt.Errorf("parsing custom scaffold templates: %w", err)
+	}
+	return tmpls, nil
+}
+
+func templatesDirOrDefault(dir string) string {
+	if dir == "" {
+		return defaultScaffoldTemplatesDir
+	}
+	return dir
+}
+
+// detectBackendRoot returns the workspace or first-level directory holding sources/psql/models.
+func detectBackendRoot(ws string) string {
+	candidates := []string{"."}
+	if entries, err := os.ReadDir(ws); err == nil {
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && !skippedProjectDirs[e.Name()] {
+				candidates = append(candidates, e.Name())
+			}
+		}
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(filepath.Join(ws, c, "sources", "psql", "models")); err == nil && info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// backendImportPath derives the import path of backend from the closest go.mod at or
+// above it within the workspace.
+func backendImportPath(ws, backend string) (string, error) {
+	dir := backend
+	for {
+		data, err := os.ReadFile(filepath.Join(ws, dir, "go.mod"))
+		if err == nil {
+			m := goModulePattern.FindSubmatch(data)
+			if m == nil {
+				return "", fmt.Errorf("%s has no module line", path.Join(dir, "go.mod"))
+			}
+			rel := strings.TrimPrefix(strings.TrimPrefix(backend, dir), "/")
+			if dir == "." {
+				rel = strings.TrimPrefix(backend, ".")
+			}
+			return strings.TrimSuffix(path.Join(string(m[1]), rel), "/"), nil
+		}
+		if dir == "." {
+			return "", fmt.Errorf("no go.mod found for %s", backend)
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// wireScaffoldMain creates the DAO and controller next to the existing ones and mounts
+// the routes at the end of the first block of r.Mount calls.
+func wireScaffoldMain(src string, d *scaffoldData) (string, error) {
+	if strings.Contains(src, fmt.Sprintf("r.Mount(%q", d.Route)) {
+		return "", fmt.Errorf("%s is already mounted", d.Route)
+	}
+	lines := strings.Split(src, "\n")
+	var err error
+	lines, err = insertAfterLast(lines, daoCtorLinePattern, -1,
+		fmt.Sprintf("%sDAO := dao.New%sDAO(db.DB)", d.Var, d.Name))
+	if err != nil {
+		return "", fmt.Errorf("no dao constructor found: %w", err)
+	}
+	lines, err = insertAfterLast(lines, ctrlCtorLinePattern, -1,
+		fmt.Sprintf("%sCtrl := controllers.New%sController(%sDAO)", d.Var, d.Name, d.Var))
+	if err != nil {
+		return "", fmt.Errorf("no controller constructor found: %w", err)
+	}
+	first := -1
+	for i, l := range lines {
+		if mountLinePattern.MatchString(l) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "", fmt.Errorf("no r.Mount call found")
+	}
+	end := first
+	for end+1 < len(lines) && mountLinePattern.MatchString(lines[end+1]) {
+		end++
+	}
+	lines, _ = insertAfterLast(lines, mountLinePattern, end+1,
+		fmt.Sprintf("r.Mount(%q, routes.%sRoutes(%sCtrl, cfg))", d.Route, d.Name, d.Var))
+	return strings.Join(lines, "\n"), nil
+}
+
+// wireScaffoldMigration adds the model to the AutoMigrate call.
+func wireScaffoldMigration(src string, d *scaffoldData) (string, error) {
+	entry := fmt.Sprintf("&models.%s{},", d.Name)
+	if strings.Contains(src, entry) {
+		return "", fmt.Errorf("%s is already migrated", d.Name)
+	}
+	lines := strings.Split(src, "\n")
+	start := -1
+	for i, l := range lines {
+		if strings.Contains(l, "AutoMigrate(") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "", fmt.Errorf("no AutoMigrate call found")
+	}
+	end := start + 1
+	for end < len(lines) && migrateLinePattern.MatchString(lines[end]) {
+		end++
+	}
+	lines, err := insertAfterLast(lines, migrateLinePattern, end, entry)
+	if err != nil {
+		return "", fmt.Errorf("AutoMigrate does not list one model per line")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// insertAfterLast inserts text after the last line before limit (-1: anywhere) that
+// matches pattern, with the same indentation.
+func insertAfterLast(lines []string, pattern *regexp.Regexp, limit int, text string) ([]string, error) {
+	if limit < 0 || limit > len(lines) {
+		limit = len(lines)
+	}
+	for i := limit - 1; i >= 0; i-- {
+		if !pattern.MatchString(lines[i]) {
+			continue
+		}
+		indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+		out := make([]string, 0, len(lines)+1)
+		out = append(out, lines[:i+1]...)
+		out = append(out, indent+text)
+		return append(out, lines[i+1:]...), nil
+	}
+	return lines, fmt.Errorf("no line matches %s", pattern)
+}
+
+func pascalCase(snake string) string {
+	var sb strings.Builder
+	for _, w := range strings.Split(snake, "_") {
+		switch upper := strings.ToUpper(w); upper {
+		case "ID", "URL", "API", "HTTP", "JSON", "UUID":
+			sb.WriteString(upper)
+		default:
+			sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return sb.String()
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func pluralize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	}
+	return word + "s"
+}
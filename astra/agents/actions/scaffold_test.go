@@ -0,0 +1,101 @@
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const scaffoldTestMain = `package main
+
+func main() {
+	userDAO := dao.NewUserDAO(db.DB)
+	noteDAO := dao.NewNoteDAO(db.DB)
+	userCtrl := controllers.NewUserController(userDAO)
+	notesCtrl := controllers.NewNotesController(noteDAO)
+
+	r := chi.NewRouter()
+	r.Mount("/users", routes.UserRoutes(userCtrl, cfg))
+	r.Mount("/notes", routes.NotesRoutes(notesCtrl, cfg))
+
+	r.Mount("/health", routes.HealthRoutes(healthCtrl))
+}
+`
+
+const scaffoldTestDatabase = `package psql
+
+func migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.User{},
+		&models.Note{},
+	)
+}
+`
+
+func TestScaffoldResource(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"go.mod":                          "module example.com/app\n\ngo 1.21\n",
+		"svc/main.go":                     scaffoldTestMain,
+		"svc/sources/psql/database.go":    scaffoldTestDatabase,
+		"svc/sources/psql/models/note.go": "package models\n",
+		".astra/templates/scaffold/controller.go.tmpl": "package controllers\n\n// custom {{.Name}}\n",
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+	params := ScaffoldResourceParams{
+		Name: "project_task",
+		Fields: []ScaffoldField{
+			{Name: "title", Type: "string", Required: true},
+			{Name: "due_date", Type: "time"},
+			{Name: "done", Type: "bool"},
+		},
+		DryRun: true,
+	}
+
+	res, _ := a.ScaffoldResource(t.Context(), params)
+	if res.Error != "" || len(res.Warnings) != 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if len(res.Created) != 4 || len(res.Modified) != 2 {
+		t.Fatalf("expected 4 created and 2 modified files, got %v and %v", res.Created, res.Modified)
+	}
+	for _, want := range []string{
+		"+++ b/svc/sources/psql/models/project_task.go",
+		"+\tDueDate   time.Time `json:\"due_date\"`",
+		`+	"example.com/app/svc/sources/psql/models"`,
+		"+// custom ProjectTask",
+		`+	projectTaskDAO := dao.NewProjectTaskDAO(db.DB)`,
+		`+	projectTaskCtrl := controllers.NewProjectTaskController(projectTaskDAO)`,
+		`+	r.Mount("/project_tasks", routes.ProjectTaskRoutes(projectTaskCtrl, cfg))`,
+		"+\t\t&models.ProjectTask{},",
+	} {
+		if !strings.Contains(res.Diff, want) {
+			t.Errorf("diff is missing %q:\n%s", want, res.Diff)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "svc/controllers/project_task.go")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote files: %v", err)
+	}
+
+	params.DryRun = false
+	if res, _ := a.ScaffoldResource(t.Context(), params); res.Error != "" {
+		t.Fatalf("scaffold failed: %s", res.Error)
+	}
+	main, _ := os.ReadFile(filepath.Join(root, "svc/main.go"))
+	if !strings.Contains(string(main), "\tr.Mount(\"/notes\", routes.NotesRoutes(notesCtrl, cfg))\n\tr.Mount(\"/project_tasks\"") {
+		t.Errorf("route not mounted after the last mount of the first block:\n%s", main)
+	}
+	if res, _ := a.ScaffoldResource(t.Context(), params); !strings.Contains(res.Error, "already exists") {
+		t.Errorf("expected a second scaffold to fail, got %+v", res)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	after := "a\nb\nc\nd\nX\ne\nf\ng\nh\n"
+	want := "--- a/f.txt\n+++ b/f.txt\n@@ -2,6 +2,7 @@\n b\n c\n d\n+X\n e\n f\n g\n"
+	if got := unifiedDiff("f.txt", before, after); got != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
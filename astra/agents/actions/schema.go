@@ -0,0 +1,341 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Params structs describe themselves to the LLM through struct tags next to `json`:
+//
+//	desc:"..."       field description
+//	enum:"a,b,c"     allowed values
+//	default:"..."    value used when the field is absent (JSON for non-string fields)
+//	required:"true"  the field must be present and non-null
+//
+// paramsSchema turns them into a JSON Schema; ExecuteAction validates calls against it.
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// FieldError is a problem with one param, addressed by its JSON path (e.g. "edits[0].type").
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ParamsError is returned by ExecuteAction when params do not match the action's schema.
+type ParamsError struct {
+	Action string       `json:"action"`
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ParamsError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return fmt.Sprintf("invalid params for %s: %s", e.Action, strings.Join(parts, "; "))
+}
+
+// paramsSchema returns the JSON Schema for an ActionSpec.Params value. Maps are taken
+// to already be a schema (MCP tools); structs are reflected.
+func paramsSchema(params interface{}) map[string]interface{} {
+	if m, ok := params.(map[string]interface{}); ok && m["type"] != nil {
+		return m
+	}
+	t := reflect.TypeOf(params)
+	if t == nil || derefType(t).Kind() != reflect.Struct {
+		return map[string]interface{}{"type": "object"}
+	}
+	return typeSchema(derefType(t), map[reflect.Type]bool{})
+}
+
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	t = derefType(t)
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		props := map[string]interface{}{}
+		var required []string
+		addStructFields(t, props, &required, seen)
+		schema := map[string]interface{}{"type": "object", "properties": props, "additionalProperties": false}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// addStructFields adds the JSON-visible fields of t, flattening embedded structs the way
+// encoding/json does.
+func addStructFields(t reflect.Type, props map[string]interface{}, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			if ft := derefType(f.Type); ft.Kind() == reflect.Struct {
+				addStructFields(ft, props, required, seen)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := typeSchema(f.Type, seen)
+		if desc := f.Tag.Get("desc"); desc != "" {
+			schema["description"] = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			values := make([]interface{}, 0)
+			for _, v := range strings.Split(enum, ",") {
+				values = append(values, tagValue(f.Type, strings.TrimSpace(v)))
+			}
+			if schema["type"] == "array" {
+				schema["items"].(map[string]interface{})["enum"] = values
+			} else {
+				schema["enum"] = values
+			}
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			schema["default"] = tagValue(f.Type, def)
+		}
+		if f.Tag.Get("required") == "true" {
+			*required = append(*required, name)
+		}
+		props[name] = schema
+	}
+}
+
+// tagValue converts a tag string to the JSON value of a field of type t: strings stay
+// as they are, anything else is parsed as JSON.
+func tagValue(t reflect.Type, s string) interface{} {
+	t = derefType(t)
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && !strings.HasPrefix(s, "[") {
+		return tagValue(t.Elem(), s) // Enum values of a list apply to its items
+	}
+	if t.Kind() == reflect.String {
+		return s
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	return v
+}
+
+// validateParams checks value against schema, filling in defaults of absent fields,
+// and returns every problem found. value must be JSON-decoded (maps, slices, float64).
+func validateParams(schema map[string]interface{}, value interface{}, path string) []FieldError {
+	var errs []FieldError
+	fail := func(format string, args ...interface{}) []FieldError {
+		return append(errs, FieldError{Field: fieldPath(path), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if typ, _ := schema["type"].(string); typ != "" && !matchesType(typ, value) {
+		return fail("expected %s, got %s", typ, jsonKind(value))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(enum, value) {
+		return fail("must be one of %s", formatEnum(enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		for _, r := range stringList(schema["required"]) {
+			required[r] = true
+			if v[r] == nil {
+				errs = append(errs, FieldError{Field: joinPath(path, r), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, _ := props[k].(map[string]interface{})
+			if _, present := v[k]; !present {
+				if def, ok := prop["default"]; ok {
+					v[k] = def
+				}
+				continue
+			}
+			// Null means "not set", and so does "" for optional fields. A blank non-string
+			// is dropped (falling back to its default) so it is not decoded into a number.
+			if typ, _ := prop["type"].(string); v[k] == "" && !required[k] && typ != "" && typ != "string" {
+				delete(v, k)
+				if def, ok := prop["default"]; ok {
+					v[k] = def
+				}
+				continue
+			}
+			if v[k] == nil || (v[k] == "" && !required[k]) {
+				continue
+			}
+			errs = append(errs, validateParams(prop, v[k], joinPath(path, k))...)
+		}
+		extra := make([]string, 0)
+		for k := range v {
+			if _, known := props[k]; !known {
+				extra = append(extra, k)
+			}
+		}
+		sort.Strings(extra)
+		for _, k := range extra {
+			switch ap := schema["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					errs = append(errs, FieldError{Field: joinPath(path, k), Message: "unknown field"})
+				}
+			case map[string]interface{}:
+				if v[k] != nil {
+					errs = append(errs, validateParams(ap, v[k], joinPath(path, k))...)
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errs = append(errs, validateParams(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func matchesType(typ string, v interface{}) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func jsonKind(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		b, _ := json.Marshal(e)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func fieldPath(path string) string {
+	if path == "" {
+		return "params"
+	}
+	return path
+}
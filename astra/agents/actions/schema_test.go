@@ -0,0 +1,78 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParamsSchema_FromTags(t *testing.T) {
+	schema := paramsSchema(ApplyCodeEditsParams{})
+	if !reflect.DeepEqual(schema["required"], []string{"edits"}) || schema["additionalProperties"] != false {
+		t.Fatalf("unexpected top-level schema: %v", schema)
+	}
+	edits := schema["properties"].(map[string]interface{})["edits"].(map[string]interface{})
+	item := edits["items"].(map[string]interface{})
+	typ := item["properties"].(map[string]interface{})["type"].(map[string]interface{})
+	if typ["type"] != "string" || len(typ["enum"].([]interface{})) != 3 || typ["description"] == "" {
+		t.Errorf("unexpected edit type schema: %v", typ)
+	}
+
+	props := paramsSchema(RunGoTestsParams{})["properties"].(map[string]interface{})
+	if def := props["packages"].(map[string]interface{})["default"]; !reflect.DeepEqual(def, []interface{}{"./..."}) {
+		t.Errorf("expected a JSON default for packages, got %v", def)
+	}
+	if limit := paramsSchema(QueryWebParams{})["properties"].(map[string]interface{})["result_limit"].(map[string]interface{}); limit["type"] != "integer" || limit["default"] != 5.0 {
+		t.Errorf("unexpected result_limit schema: %v", limit)
+	}
+
+	declared := map[string]interface{}{"type": "object", "required": []string{"x"}}
+	if got := paramsSchema(declared); got["required"] == nil {
+		t.Errorf("expected map schemas to pass through, got %v", got)
+	}
+}
+
+func TestExecuteAction_ValidatesParams(t *testing.T) {
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: t.TempDir()}
+	var got QueryWebParams
+	Register(a, ActionSpec{Name: "query_web"}, func(_ context.Context, p QueryWebParams) (QueryWebResult, error) {
+		got = p
+		return QueryWebResult{}, nil
+	})
+	Register(a, ActionSpec{Name: "apply_code_edits"}, func(_ context.Context, p ApplyCodeEditsParams) (ApplyCodeEditsResult, error) {
+		return ApplyCodeEditsResult{Success: true}, nil
+	})
+
+	if _, err := a.ExecuteAction("query_web", map[string]interface{}{"queries": []string{"go"}}); err != nil || got.ResultLimit != 5 {
+		t.Errorf("expected the result_limit default to be applied, got %+v (%v)", got, err)
+	}
+	if _, err := a.ExecuteAction("query_web", map[string]interface{}{"queries": []string{"go"}, "result_limit": ""}); err != nil || got.ResultLimit != 5 {
+		t.Errorf("expected a blank result_limit to fall back to its default, got %+v (%v)", got, err)
+	}
+
+	_, err := a.ExecuteAction("query_web", map[string]interface{}{"queries": "go", "result_limit": 2.5, "word_limit": 10})
+	var paramsErr *ParamsError
+	if !errors.As(err, &paramsErr) {
+		t.Fatalf("expected a ParamsError, got %v", err)
+	}
+	want := []FieldError{
+		{Field: "queries", Message: "expected array, got string"},
+		{Field: "result_limit", Message: "expected integer, got number"},
+		{Field: "word_limit", Message: "unknown field"},
+	}
+	if !reflect.DeepEqual(paramsErr.Fields, want) {
+		t.Errorf("unexpected field errors:\n got %+v\nwant %+v", paramsErr.Fields, want)
+	}
+
+	_, err = a.ExecuteAction("apply_code_edits", map[string]interface{}{
+		"edits": []map[string]interface{}{{"type": "rewrite", "file": "a.go"}, {"type": "delete_file"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "edits[0].type: must be one of") || !strings.Contains(err.Error(), "edits[1].file: is required") {
+		t.Errorf("expected nested field errors, got %v", err)
+	}
+	if _, err := a.ExecuteAction("apply_code_edits", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "edits: is required") {
+		t.Errorf("expected missing edits to be reported, got %v", err)
+	}
+}
@@ -3,11 +3,14 @@ package actions
 import (
 	"astra/astra/services/scraper"
 	"astra/astra/utils/types"
+	"context"
+	"strings"
+	"sync"
 )
 
 type ScrapeURLsParams struct {
-	URLs      []string `json:"urls"`
-	WordLimit *int     `json:"word_limit,omitempty"`
+	URLs      []string `json:"urls" required:"true" desc:"Pages to scrape"`
+	WordLimit *int     `json:"word_limit,omitempty" desc:"Keep at most this many words of each page (default: no limit)"`
 }
 
 type ScrapeURLsResult struct {
@@ -15,8 +18,8 @@ type ScrapeURLsResult struct {
 }
 
 type QueryWebParams struct {
-	Queries     []string `json:"queries"`
-	ResultLimit int      `json:"result_limit"`
+	Queries     []string `json:"queries" required:"true" desc:"Search phrases"`
+	ResultLimit int      `json:"result_limit" default:"5" desc:"Number of results per query"`
 }
 
 type QueryWebResult struct {
@@ -24,22 +27,43 @@ type QueryWebResult struct {
 }
 
 // Action to scrape given URLs and return their text contents
-func (a *DataActions) ScrapeURLs(params ScrapeURLsParams) (ScrapeURLsResult, error) {
+func (a *DataActions) ScrapeURLs(ctx context.Context, params ScrapeURLsParams) (ScrapeURLsResult, error) {
 	s, err := scraper.NewScraper()
 	if err != nil {
 		return ScrapeURLsResult{}, err
 	}
 	defer s.Close()
 
+	if a.streaming() {
+		var mu sync.Mutex
+		done := 0
+		s.OnPage = func(page types.ScrapeResult) {
+			mu.Lock()
+			done++
+			n := done
+			mu.Unlock()
+			if page.Error != "" {
+				a.reportProgress(n, len(params.URLs), page.URL, progressFailed, page.Error)
+				return
+			}
+			a.reportProgress(n, len(params.URLs), page.URL, progressDone, "")
+		}
+	}
+
 	results, err := s.ReadMultiplePages(params.URLs, 2)
 	if err != nil {
 		return ScrapeURLsResult{}, err
 	}
+	if params.WordLimit != nil && *params.WordLimit > 0 {
+		for i := range results {
+			results[i].Content = limitWords(results[i].Content, *params.WordLimit)
+		}
+	}
 	return ScrapeURLsResult{Results: results}, nil
 }
 
 // Action to perform web search queries and return the text snippets
-func (a *DataActions) QueryWeb(params QueryWebParams) (QueryWebResult, error) {
+func (a *DataActions) QueryWeb(ctx context.Context, params QueryWebParams) (QueryWebResult, error) {
 	s, err := scraper.NewScraper()
 	if err != nil {
 		return QueryWebResult{}, err
@@ -47,8 +71,20 @@ func (a *DataActions) QueryWeb(params QueryWebParams) (QueryWebResult, error) {
 	defer s.Close()
 	queryResults := map[string]interface{}{}
 	for _, u := range params.Queries {
+		if err := ctx.Err(); err != nil {
+			return QueryWebResult{}, err
+		}
 		text, _ := s.QueryWeb(u, params.ResultLimit)
 		queryResults[u] = text
 	}
 	return QueryWebResult{Results: queryResults}, nil
 }
+
+// limitWords keeps the first n whitespace-separated words of s.
+func limitWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) <= n {
+		return s
+	}
+	return strings.Join(words[:n], " ")
+}
@@ -0,0 +1,196 @@
+package actions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultSearchMaxResults = 100
+	maxSearchContextLines   = 10
+	maxSearchFileBytes      = 1024 * 1024 // files larger than 1MB are skipped
+)
+
+// errSearchLimitReached stops the walk once enough matches were collected.
+var errSearchLimitReached = errors.New("search result limit reached")
+
+// SearchCodeParams defines a code search across the workspace.
+type SearchCodeParams struct {
+	Query         string   `json:"query" required:"true" desc:"Literal text, or a Go regexp when regex is true"`
+	Regex         bool     `json:"regex,omitempty" desc:"Treat query as a regular expression"`
+	CaseSensitive bool     `json:"case_sensitive,omitempty" desc:"Match case (default is case-insensitive)"`
+	Path          string   `json:"path,omitempty" desc:"Directory to search, relative to the workspace"`
+	Include       []string `json:"include,omitempty" desc:"Only search files matching these globs"`
+	Exclude       []string `json:"exclude,omitempty" desc:"Skip files matching these globs"`
+	ContextLines  int      `json:"context_lines,omitempty" desc:"Lines of context around each match (max 10)"`
+	MaxResults    int      `json:"max_results,omitempty" desc:"Maximum number of matches (default 100)"`
+}
+
+// SearchCodeMatch is a single matching line.
+type SearchCodeMatch struct {
+	Line    int    `json:"line"`
+	Text    string `json:"text"`
+	Context string `json:"context,omitempty"` // Numbered lines around the match, match marked with ">"
+}
+
+// SearchCodeFileResult groups matches by file.
+type SearchCodeFileResult struct {
+	File    string            `json:"file"`
+	Matches []SearchCodeMatch `json:"matches"`
+}
+
+// SearchCodeResult is the output of search_code.
+type SearchCodeResult struct {
+	Files         []SearchCodeFileResult `json:"files"`
+	TotalMatches  int                    `json:"total_matches"`
+	FilesSearched int                    `json:"files_searched"`
+	Truncated     bool                   `json:"truncated,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// SearchCode searches file contents in the workspace, respecting .gitignore.
+func (a *DataActions) SearchCode(ctx context.Context, params SearchCodeParams) (SearchCodeResult, error) {
+	if params.Query == "" {
+		return SearchCodeResult{Error: "query is required"}, nil
+	}
+	re, err := compileSearchQuery(params)
+	if err != nil {
+		return SearchCodeResult{Error: err.Error()}, nil
+	}
+	root, err := a.workspaceRoot()
+	if err != nil {
+		return SearchCodeResult{Error: fmt.Sprintf("failed to get workspace root: %v", err)}, nil
+	}
+	start, err := a.resolveInWorkspace(params.Path)
+	if err != nil {
+		return SearchCodeResult{Error: err.Error()}, nil
+	}
+
+	limit := params.MaxResults
+	if limit <= 0 {
+		limit = defaultSearchMaxResults
+	}
+	contextLines := params.ContextLines
+	if contextLines < 0 {
+		contextLines = 0
+	}
+	if contextLines > maxSearchContextLines {
+		contextLines = maxSearchContextLines
+	}
+
+	result := SearchCodeResult{Files: []SearchCodeFileResult{}}
+	walkErr := walkRespectingGitIgnore(root, start, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		if len(params.Include) > 0 && !matchAnyGlob(params.Include, rel) {
+			return nil
+		}
+		if matchAnyGlob(params.Exclude, rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxSearchFileBytes {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil || isBinary(data) {
+			return nil
+		}
+		result.FilesSearched++
+
+		matches := searchLines(data, re, contextLines, limit-result.TotalMatches)
+		if len(matches) == 0 {
+			return nil
+		}
+		result.Files = append(result.Files, SearchCodeFileResult{File: rel, Matches: matches})
+		result.TotalMatches += len(matches)
+		if result.TotalMatches >= limit {
+			result.Truncated = true
+			return errSearchLimitReached
+		}
+		return nil
+	})
+	if walkErr != nil && !errors.Is(walkErr, errSearchLimitReached) {
+		result.Error = walkErr.Error()
+	}
+	return result, nil
+}
+
+func compileSearchQuery(params SearchCodeParams) (*regexp.Regexp, error) {
+	pattern := params.Query
+	if !params.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !params.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return re, nil
+}
+
+// searchLines returns up to max matching lines (1-based line numbers) with optional context.
+func searchLines(data []byte, re *regexp.Regexp, contextLines, max int) []SearchCodeMatch {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxSearchFileBytes)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var matches []SearchCodeMatch
+	for i, line := range lines {
+		if len(matches) >= max {
+			break
+		}
+		if !re.MatchString(line) {
+			continue
+		}
+		m := SearchCodeMatch{Line: i + 1, Text: line}
+		if contextLines > 0 {
+			m.Context = numberedContext(lines, i, contextLines)
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func numberedContext(lines []string, idx, n int) string {
+	from := idx - n
+	if from < 0 {
+		from = 0
+	}
+	to := idx + n
+	if to >= len(lines) {
+		to = len(lines) - 1
+	}
+	var sb strings.Builder
+	for i := from; i <= to; i++ {
+		marker := " "
+		if i == idx {
+			marker = ">"
+		}
+		fmt.Fprintf(&sb, "%s%5d | %s\n", marker, i+1, lines[i])
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// isBinary applies git's heuristic: a NUL byte in the first 8KB means binary.
+func isBinary(data []byte) bool {
+	n := len(data)
+	if n > 8000 {
+		n = 8000
+	}
+	return bytes.IndexByte(data[:n], 0) != -1
+}
@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSearchCode_RespectsGitIgnoreAndGlobs(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		".gitignore":              "node_modules/\n*.log\n!keep.log\n",
+		"main.go":                 "package main\n\nfunc SaveMessage() {}\n",
+		"dao/dao.chat.go":         "package dao\n\n// SaveMessage persists\nfunc SaveMessage() {}\n",
+		"dao/dao_test.go":         "package dao\n\nfunc TestSaveMessage() {}\n",
+		"node_modules/x/index.js": "SaveMessage()\n",
+		"debug.log":               "SaveMessage\n",
+		"keep.log":                "SaveMessage\n",
+		"web/.gitignore":          "generated.ts\n",
+		"web/generated.ts":        "SaveMessage\n",
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+
+	res, _ := a.SearchCode(t.Context(), SearchCodeParams{Query: "savemessage", Exclude: []string{"*_test.go"}})
+	if res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	got := map[string]int{}
+	for _, f := range res.Files {
+		got[f.File] = len(f.Matches)
+	}
+	want := map[string]int{"main.go": 1, "dao/dao.chat.go": 2, "keep.log": 1}
+	if len(got) != len(want) {
+		t.Fatalf("expected files %v, got %v", want, got)
+	}
+	for f, n := range want {
+		if got[f] != n {
+			t.Errorf("expected %d matches in %s, got %d", n, f, got[f])
+		}
+	}
+
+	res, _ = a.SearchCode(t.Context(), SearchCodeParams{Query: `^func \w+\(`, Regex: true, Include: []string{"dao/*.go"}, ContextLines: 1})
+	if res.TotalMatches != 2 {
+		t.Fatalf("expected 2 regex matches, got %d (%+v)", res.TotalMatches, res.Files)
+	}
+	if res.Files[0].Matches[0].Context == "" {
+		t.Errorf("expected context lines to be populated")
+	}
+
+	res, _ = a.SearchCode(t.Context(), SearchCodeParams{Query: "SaveMessage", MaxResults: 1})
+	if res.TotalMatches != 1 || !res.Truncated {
+		t.Errorf("expected result limit to truncate, got %d matches truncated=%v", res.TotalMatches, res.Truncated)
+	}
+}
+
+func TestGlobToRegex(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*.go", "astra/main.go", true},
+		{"astra/**/*.go", "astra/agents/actions/fs.go", true},
+		{"astra/**/*.go", "astra/main.go", true},
+		{"frontend/*.ts", "frontend/src/api.ts", false},
+		{"dao.?.go", "dao.x.go", true},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.path); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
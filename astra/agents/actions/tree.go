@@ -0,0 +1,323 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultTreeSummarizeOver = 50
+	maxTreeTextBytes         = 200 * 1024
+	treeNodeDir              = "dir"
+	treeNodeFile             = "file"
+)
+
+// FetchFileStructureParams defines parameters for fetching a repo structure.
+type FetchFileStructureParams struct {
+	Path           string   `json:"path" desc:"Base path relative to the workspace (default .)"`
+	IgnoreDirs     []string `json:"ignore_dirs" desc:"Extra names/globs to skip on top of .gitignore, e.g. logs or *.lock"`
+	MaxDepth       int      `json:"max_depth,omitempty" desc:"Levels to expand below path; deeper directories are summarized (0 = unlimited)"`
+	Include        []string `json:"include,omitempty" desc:"Only list files matching these globs"`
+	Exclude        []string `json:"exclude,omitempty" desc:"Skip files matching these globs"`
+	ShowSizes      bool     `json:"show_sizes,omitempty" desc:"Report file and directory sizes"`
+	ShowLineCounts bool     `json:"show_line_counts,omitempty" desc:"Report line counts of text files"`
+	SummarizeOver  int      `json:"summarize_over,omitempty" desc:"Summarize directories with more direct files than this (default 50)"`
+	Format         string   `json:"format,omitempty" enum:"text,json,both" desc:"Output format (default text)"`
+}
+
+// FileTreeNode is one file or directory in the JSON tree.
+type FileTreeNode struct {
+	Name      string          `json:"name"`
+	Path      string          `json:"path"` // Slash-separated, relative to the workspace
+	Type      string          `json:"type"` // "dir" or "file"
+	Size      int64           `json:"size,omitempty"`
+	Lines     int             `json:"lines,omitempty"`
+	FileCount int             `json:"file_count,omitempty"` // Directories: files underneath, recursively
+	DirCount  int             `json:"dir_count,omitempty"`  // Directories: subdirectories underneath, recursively
+	Collapsed bool            `json:"collapsed,omitempty"`  // Directory not expanded (past max_depth); Summary describes its contents
+	Summary   string          `json:"summary,omitempty"`    // Describes entries not listed in Children
+	Children  []*FileTreeNode `json:"children,omitempty"`
+}
+
+// FetchFileStructureResult holds the resulting file tree.
+type FetchFileStructureResult struct {
+	Structure   string        `json:"structure,omitempty"` // Formatted tree output
+	Tree        *FileTreeNode `json:"tree,omitempty"`      // Structured tree (format "json" or "both")
+	Directories int           `json:"directories"`
+	Files       int           `json:"files"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	Error       string        `json:"error,omitempty"` // Error message, if any
+}
+
+// FetchFileStructureInRepo walks the workspace in Go, respecting .gitignore, and
+// returns a compact tree in which large or deep directories are summarized.
+func (a *DataActions) FetchFileStructureInRepo(ctx context.Context, params FetchFileStructureParams) (FetchFileStructureResult, error) {
+	format := strings.ToLower(params.Format)
+	if format == "" {
+		format = "text"
+	}
+	if format != "text" && format != "json" && format != "both" {
+		return FetchFileStructureResult{Error: fmt.Sprintf("unknown format %q (use text, json or both)", params.Format)}, nil
+	}
+	root, err := a.workspaceRoot()
+	if err != nil {
+		return FetchFileStructureResult{Error: fmt.Sprintf("failed to get workspace root: %v", err)}, nil
+	}
+	start, err := a.resolveInWorkspace(params.Path)
+	if err != nil {
+		return FetchFileStructureResult{Error: err.Error()}, nil
+	}
+	if info, err := os.Stat(start); err != nil || !info.IsDir() {
+		return FetchFileStructureResult{Error: fmt.Sprintf("%s is not a directory", params.Path)}, nil
+	}
+
+	startRel := relativeToWorkspace(root, start)
+	top := &FileTreeNode{Name: startRel, Path: startRel, Type: treeNodeDir}
+	dirs := map[string]*FileTreeNode{startRel: top}
+
+	walkErr := walkRespectingGitIgnore(root, start, func(rel string, d fs.DirEntry) error {
+		if matchAnyGlob(params.IgnoreDirs, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parent, ok := dirs[path.Dir(rel)]
+		if !ok {
+			return nil
+		}
+		if d.IsDir() {
+			node := &FileTreeNode{Name: d.Name(), Path: rel, Type: treeNodeDir}
+			dirs[rel] = node
+			parent.Children = append(parent.Children, node)
+			return nil
+		}
+		if len(params.Include) > 0 && !matchAnyGlob(params.Include, rel) {
+			return nil
+		}
+		if matchAnyGlob(params.Exclude, rel) {
+			return nil
+		}
+		node := &FileTreeNode{Name: d.Name(), Path: rel, Type: treeNodeFile}
+		if params.ShowSizes || params.ShowLineCounts {
+			if info, err := d.Info(); err == nil {
+				if params.ShowSizes {
+					node.Size = info.Size()
+				}
+				if params.ShowLineCounts && info.Mode().IsRegular() && info.Size() <= maxSearchFileBytes {
+					node.Lines = countLines(filepath.Join(root, filepath.FromSlash(rel)))
+				}
+			}
+		}
+		parent.Children = append(parent.Children, node)
+		return nil
+	})
+	if walkErr != nil {
+		return FetchFileStructureResult{Error: fmt.Sprintf("failed to walk %s: %v", startRel, walkErr)}, nil
+	}
+
+	summarizeOver := params.SummarizeOver
+	if summarizeOver <= 0 {
+		summarizeOver = defaultTreeSummarizeOver
+	}
+	finalizeTree(top, 0, params.MaxDepth, summarizeOver, len(params.Include) > 0)
+
+	result := FetchFileStructureResult{Directories: top.DirCount, Files: top.FileCount}
+	if format == "json" || format == "both" {
+		result.Tree = top
+	}
+	if format == "text" || format == "both" {
+		result.Structure, result.Truncated = renderTree(top, params.ShowSizes, params.ShowLineCounts)
+	}
+	return result, nil
+}
+
+// finalizeTree sorts children, aggregates counts, sizes and lines, and collapses
+// directories past maxDepth or with more than summarizeOver direct files.
+// It returns the file-extension histogram of everything below n.
+func finalizeTree(n *FileTreeNode, depth, maxDepth, summarizeOver int, pruneEmpty bool) map[string]int {
+	exts := map[string]int{}
+	var subdirs, files []*FileTreeNode
+	for _, c := range n.Children {
+		if c.Type == treeNodeDir {
+			for ext, count := range finalizeTree(c, depth+1, maxDepth, summarizeOver, pruneEmpty) {
+				exts[ext] += count
+			}
+			if pruneEmpty && c.FileCount == 0 {
+				continue
+			}
+			n.FileCount += c.FileCount
+			n.DirCount += 1 + c.DirCount
+			n.Size += c.Size
+			n.Lines += c.Lines
+			subdirs = append(subdirs, c)
+			continue
+		}
+		n.FileCount++
+		n.Size += c.Size
+		n.Lines += c.Lines
+		exts[fileKind(c.Name)]++
+		files = append(files, c)
+	}
+	byName := func(s []*FileTreeNode) {
+		sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	}
+	byName(subdirs)
+	byName(files)
+
+	if maxDepth > 0 && depth >= maxDepth && (len(subdirs) > 0 || len(files) > 0) {
+		n.Children = nil
+		n.Collapsed = true
+		n.Summary = summarizeFiles(n.FileCount, n.DirCount, exts)
+		return exts
+	}
+	n.Children = subdirs
+	if len(files) > summarizeOver {
+		local := map[string]int{}
+		for _, f := range files {
+			local[fileKind(f.Name)]++
+		}
+		n.Summary = summarizeFiles(len(files), 0, local) + " (not listed)"
+	} else {
+		n.Children = append(n.Children, files...)
+	}
+	return exts
+}
+
+// summarizeFiles renders e.g. "312 files, mostly .ts" or "40 files in 3 dirs: 20 .go, 12 .yaml, 8 .md".
+func summarizeFiles(files, dirs int, exts map[string]int) string {
+	if files == 0 {
+		return plural(dirs, "dir") + ", no files"
+	}
+	head := plural(files, "file")
+	if dirs > 0 {
+		head += " in " + plural(dirs, "dir")
+	}
+	type kind struct {
+		ext   string
+		count int
+	}
+	kinds := make([]kind, 0, len(exts))
+	for ext, count := range exts {
+		kinds = append(kinds, kind{ext, count})
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].count != kinds[j].count {
+			return kinds[i].count > kinds[j].count
+		}
+		return kinds[i].ext < kinds[j].ext
+	})
+	if kinds[0].count*2 >= files {
+		return fmt.Sprintf("%s, mostly %s", head, kinds[0].ext)
+	}
+	parts := make([]string, 0, 3)
+	for i := 0; i < len(kinds) && i < 3; i++ {
+		parts = append(parts, fmt.Sprintf("%d %s", kinds[i].count, kinds[i].ext))
+	}
+	if len(kinds) > 3 {
+		parts = append(parts, "...")
+	}
+	return fmt.Sprintf("%s: %s", head, strings.Join(parts, ", "))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// fileKind is the extension of name, or the name itself for files like Makefile.
+func fileKind(name string) string {
+	if ext := path.Ext(name); ext != "" && ext != name {
+		return ext
+	}
+	return name
+}
+
+// countLines returns the number of lines in a text file, or 0 for binary files.
+func countLines(p string) int {
+	data, err := os.ReadFile(p)
+	if err != nil || len(data) == 0 || isBinary(data) {
+		return 0
+	}
+	n := bytes.Count(data, []byte{'\n'})
+	if data[len(data)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// renderTree draws the tree in the style of the `tree` command.
+func renderTree(top *FileTreeNode, sizes, lines bool) (string, bool) {
+	var sb strings.Builder
+	sb.WriteString(top.Path + "/" + treeAnnotation(top, sizes, lines) + "\n")
+	var walk func(n *FileTreeNode, prefix string)
+	walk = func(n *FileTreeNode, prefix string) {
+		for i, c := range n.Children {
+			if sb.Len() > maxTreeTextBytes {
+				return
+			}
+			branch, indent := "├── ", "│   "
+			if i == len(n.Children)-1 && (n.Collapsed || n.Summary == "") {
+				branch, indent = "└── ", "    "
+			}
+			name := c.Name
+			if c.Type == treeNodeDir {
+				name += "/"
+			}
+			sb.WriteString(prefix + branch + name + treeAnnotation(c, sizes, lines) + "\n")
+			if c.Type == treeNodeDir {
+				walk(c, prefix+indent)
+			}
+		}
+		if n.Summary != "" && !n.Collapsed {
+			sb.WriteString(prefix + "└── … " + n.Summary + "\n")
+		}
+	}
+	walk(top, "")
+	fmt.Fprintf(&sb, "\n%d directories, %d files\n", top.DirCount, top.FileCount)
+
+	out := sb.String()
+	if len(out) > maxTreeTextBytes {
+		return out[:maxTreeTextBytes] + "\n... (output truncated, use max_depth or path to narrow the listing)", true
+	}
+	return out, false
+}
+
+func treeAnnotation(n *FileTreeNode, sizes, lines bool) string {
+	var parts []string
+	if n.Collapsed {
+		parts = append(parts, n.Summary)
+	}
+	if sizes {
+		parts = append(parts, humanSize(n.Size))
+	}
+	if lines && n.Lines > 0 {
+		parts = append(parts, fmt.Sprintf("%d lines", n.Lines))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  (" + strings.Join(parts, ", ") + ")"
+}
+
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
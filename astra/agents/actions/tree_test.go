@@ -0,0 +1,55 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFetchFileStructure_GitIgnoreDepthAndSummaries(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":                "node_modules/\n*.log\n",
+		"main.go":                   "package main\n\nfunc main() {}\n",
+		"debug.log":                 "noise\n",
+		"node_modules/x/index.js":   "x\n",
+		"astra/agents/actions/a.go": "package actions\n",
+		"astra/agents/actions/b.go": "package actions\n",
+		"astra/agents/core/c.yaml":  "k: v\n",
+	}
+	for i := 0; i < 6; i++ {
+		files["web/src/f"+string(rune('a'+i))+".ts"] = "export {}\n"
+	}
+	writeTestTree(t, root, files)
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+
+	res, _ := a.FetchFileStructureInRepo(t.Context(), FetchFileStructureParams{MaxDepth: 1, ShowLineCounts: true, Format: "both"})
+	if res.Error != "" {
+		t.Fatalf("unexpected error: %s", res.Error)
+	}
+	if res.Files != 11 {
+		t.Errorf("expected 11 files (gitignored ones excluded), got %d", res.Files)
+	}
+	for _, unwanted := range []string{"node_modules", "debug.log", ".gitignore/"} {
+		if strings.Contains(res.Structure, unwanted) {
+			t.Errorf("structure should not contain %q:\n%s", unwanted, res.Structure)
+		}
+	}
+	if !strings.Contains(res.Structure, "astra/  (3 files in 3 dirs, mostly .go, 3 lines)") {
+		t.Errorf("expected astra/ to be collapsed with a summary:\n%s", res.Structure)
+	}
+	if !strings.Contains(res.Structure, "main.go  (3 lines)") {
+		t.Errorf("expected line counts:\n%s", res.Structure)
+	}
+	if res.Tree == nil || len(res.Tree.Children) != 4 {
+		t.Fatalf("expected JSON tree with 4 top-level entries, got %+v", res.Tree)
+	}
+
+	res, _ = a.FetchFileStructureInRepo(t.Context(), FetchFileStructureParams{Path: "web", SummarizeOver: 5, Include: []string{"*.ts"}})
+	if !strings.Contains(res.Structure, "… 6 files, mostly .ts (not listed)") || res.Tree != nil {
+		t.Errorf("expected large directory summary in text-only output:\n%s", res.Structure)
+	}
+
+	if res, _ := a.FetchFileStructureInRepo(t.Context(), FetchFileStructureParams{Path: "../"}); res.Error == "" {
+		t.Errorf("expected paths outside the workspace to be rejected")
+	}
+}
@@ -0,0 +1,289 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultValidationTimeout   = 5 * time.Minute
+	validationMaxOutputBytes   = 1024 * 1024
+	maxValidationOutputExcerpt = 4 * 1024 // raw output kept for failed steps without parsed diagnostics
+
+	stepPassed  = "passed"
+	stepFailed  = "failed"
+	stepSkipped = "skipped"
+)
+
+var (
+	defaultGoValidationCommands = [][]string{
+		{"goimports", "-w", "./"},
+		{"go", "fmt", "./..."},
+		{"go", "vet", "./..."},
+		{"go", "mod", "tidy"},
+		{"go", "build", "./..."},
+	}
+	defaultFrontendValidationCommands = [][]string{
+		{"npm", "run", "build"},
+	}
+
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// astra/main.go:12:5: undefined: foo  (go build, go vet; column optional)
+	goDiagPattern = regexp.MustCompile(`^(?:vet: )?([^\s:][^:]*\.go):(\d+)(?::(\d+))?: (.+)$`)
+	// src/App.tsx(12,5): error TS2322: ...
+	tscDiagPattern = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): (error|warning) (TS\d+): (.+)$`)
+	// src/App.tsx:12:5 - error TS2322: ...  (tsc --pretty)
+	tscPrettyDiagPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+) - (error|warning) (TS\d+): (.+)$`)
+	// eslint "stylish": a file header line followed by indented "  12:5  error  message  rule" rows
+	eslintFilePattern = regexp.MustCompile(`^\S.*\.(?:[cm]?[jt]sx?|vue|svelte)$`)
+	eslintRowPattern  = regexp.MustCompile(`^\s+(\d+):(\d+)\s+(error|warning)\s+(.+?)(?:\s{2,}(\S+))?$`)
+)
+
+// Diagnostic is a single compiler, vet or lint finding.
+type Diagnostic struct {
+	Tool     string `json:"tool"`
+	File     string `json:"file"` // Relative to the workspace when possible
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // error or warning
+	Message  string `json:"message"`
+}
+
+// ValidationStep reports how one command went.
+type ValidationStep struct {
+	Command         string  `json:"command"`
+	Tool            string  `json:"tool"`
+	Status          string  `json:"status"` // passed, failed, skipped
+	ExitCode        int     `json:"exit_code"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Reason          string  `json:"reason,omitempty"` // Why the step was skipped or could not run
+	Output          string  `json:"output,omitempty"` // Raw output excerpt when the step failed without parsed diagnostics
+}
+
+// ValidationResult is the output of fmt_vet_build and frontend_build.
+type ValidationResult struct {
+	Success      bool             `json:"success"`
+	Root         string           `json:"root"`
+	Steps        []ValidationStep `json:"steps"`
+	Diagnostics  []Diagnostic     `json:"diagnostics"`
+	ErrorCount   int              `json:"error_count"`
+	WarningCount int              `json:"warning_count"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// SetValidationConfig applies the agent's validation configuration.
+func (a *DataActions) SetValidationConfig(cfg configs.ValidationConfig) {
+	a.validation = cfg
+}
+
+// FmtVetBuild formats, vets and builds the Go module and returns structured diagnostics.
+func (a *DataActions) FmtVetBuild(ctx context.Context, _ NoParams) (ValidationResult, error) {
+	cmds := a.validation.GoCommands
+	if len(cmds) == 0 {
+		cmds = defaultGoValidationCommands
+	}
+	return a.runValidation(ctx, a.validation.GoRoot, cmds), nil
+}
+
+// FrontendBuild builds (and optionally lints) the frontend and returns structured diagnostics.
+func (a *DataActions) FrontendBuild(ctx context.Context, _ NoParams) (ValidationResult, error) {
+	root := a.validation.FrontendRoot
+	if root == "" {
+		root = "frontend"
+	}
+	cmds := a.validation.FrontendCommands
+	if len(cmds) == 0 {
+		cmds = defaultFrontendValidationCommands
+	}
+	return a.runValidation(ctx, root, cmds), nil
+}
+
+// runValidation runs every command in order; a failing command does not stop
+// the ones after it, and commands whose binary is missing are skipped.
+func (a *DataActions) runValidation(ctx context.Context, root string, cmds [][]string) ValidationResult {
+	dir, err := a.resolveInWorkspace(root)
+	if err != nil {
+		return ValidationResult{Error: err.Error()}
+	}
+	ws, err := a.workspaceRoot()
+	if err != nil {
+		return ValidationResult{Error: fmt.Sprintf("failed to get workspace root: %v", err)}
+	}
+	timeout := defaultValidationTimeout
+	if a.validation.TimeoutSeconds > 0 {
+		timeout = time.Duration(a.validation.TimeoutSeconds) * time.Second
+	}
+
+	result := ValidationResult{
+		Success:     true,
+		Root:        relativeToWorkspace(ws, dir),
+		Steps:       []ValidationStep{},
+		Diagnostics: []Diagnostic{},
+	}
+	seen := map[Diagnostic]bool{}
+	for i, cmdArgs := range cmds {
+		if len(cmdArgs) == 0 {
+			continue
+		}
+		step := ValidationStep{Command: formatCommandLine(cmdArgs[0], cmdArgs[1:]), Tool: validationToolName(cmdArgs)}
+		if _, err := exec.LookPath(cmdArgs[0]); err != nil {
+			step.Status = stepSkipped
+			step.ExitCode = -1
+			step.Reason = fmt.Sprintf("%s not found in PATH", cmdArgs[0])
+			result.Steps = append(result.Steps, step)
+			continue
+		}
+
+		a.reportProgress(i, len(cmds), step.Command, progressStarted, "")
+		started := time.Now()
+		run := a.runSandboxed(ctx, sandboxCommand{
+			Binary:         cmdArgs[0],
+			Args:           cmdArgs[1:],
+			Dir:            dir,
+			Timeout:        timeout,
+			MaxOutputBytes: validationMaxOutputBytes,
+			Stream:         true,
+		})
+		step.DurationSeconds = time.Since(started).Seconds()
+		step.ExitCode = run.ExitCode
+
+		output := run.Stdout
+		if run.Stderr != "" {
+			output = strings.TrimRight(output, "\n") + "\n" + run.Stderr
+		}
+		diags := parseDiagnostics(step.Tool, output)
+		for _, d := range diags {
+			d.File = relativeToWorkspace(ws, resolveDiagnosticPath(dir, d.File))
+			if seen[d] {
+				continue // go vet and go build often report the same compile error
+			}
+			seen[d] = true
+			result.Diagnostics = append(result.Diagnostics, d)
+		}
+
+		if run.ExitCode == 0 && run.Error == "" {
+			step.Status = stepPassed
+		} else {
+			step.Status = stepFailed
+			step.Reason = run.Error
+			if run.TimedOut {
+				step.Reason = fmt.Sprintf("timed out after %s", timeout)
+			}
+			if len(diags) == 0 {
+				step.Output = tailExcerpt(ansiPattern.ReplaceAllString(output, ""), maxValidationOutputExcerpt)
+			}
+			result.Success = false
+		}
+		result.Steps = append(result.Steps, step)
+		status := progressDone
+		if step.Status == stepFailed {
+			status = progressFailed
+		}
+		a.reportProgress(i+1, len(cmds), step.Command, status, step.Reason)
+	}
+
+	for _, d := range result.Diagnostics {
+		if d.Severity == "warning" {
+			result.WarningCount++
+		} else {
+			result.ErrorCount++
+		}
+	}
+	if result.ErrorCount > 0 {
+		result.Success = false
+	}
+	return result
+}
+
+// validationToolName labels a step: "go vet", "npm run build", "goimports", ...
+func validationToolName(cmdArgs []string) string {
+	bin := filepath.Base(cmdArgs[0])
+	switch {
+	case bin == "go" && len(cmdArgs) > 1:
+		return "go " + cmdArgs[1]
+	case bin == "npm" && len(cmdArgs) > 2 && cmdArgs[1] == "run":
+		return "npm run " + cmdArgs[2]
+	case bin == "npx" && len(cmdArgs) > 1:
+		return cmdArgs[1]
+	}
+	return bin
+}
+
+// parseDiagnostics extracts Go, tsc and eslint findings from combined command output.
+// Go findings are attributed to the step's tool; tsc and eslint findings to themselves,
+// since they usually run behind an npm script.
+func parseDiagnostics(tool, output string) []Diagnostic {
+	var diags []Diagnostic
+	eslintFile := ""
+	for _, line := range strings.Split(ansiPattern.ReplaceAllString(output, ""), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			eslintFile = ""
+			continue
+		}
+		if m := tscDiagPattern.FindStringSubmatch(line); m != nil {
+			diags = append(diags, newDiagnostic("tsc", m[1], m[2], m[3], m[4], m[5]+": "+m[6]))
+			continue
+		}
+		if m := tscPrettyDiagPattern.FindStringSubmatch(line); m != nil {
+			diags = append(diags, newDiagnostic("tsc", m[1], m[2], m[3], m[4], m[5]+": "+m[6]))
+			continue
+		}
+		if eslintFile != "" {
+			if m := eslintRowPattern.FindStringSubmatch(line); m != nil {
+				msg := strings.TrimSpace(m[4])
+				if m[5] != "" {
+					msg += " (" + m[5] + ")"
+				}
+				diags = append(diags, newDiagnostic("eslint", eslintFile, m[1], m[2], m[3], msg))
+				continue
+			}
+		}
+		if m := goDiagPattern.FindStringSubmatch(line); m != nil {
+			diags = append(diags, newDiagnostic(tool, m[1], m[2], m[3], "error", m[4]))
+			continue
+		}
+		if eslintFilePattern.MatchString(line) {
+			eslintFile = line
+		}
+	}
+	return diags
+}
+
+func newDiagnostic(tool, file, line, col, severity, msg string) Diagnostic {
+	l, _ := strconv.Atoi(line)
+	c, _ := strconv.Atoi(col)
+	return Diagnostic{Tool: tool, File: file, Line: l, Column: c, Severity: severity, Message: strings.TrimSpace(msg)}
+}
+
+func resolveDiagnosticPath(dir, file string) string {
+	if filepath.IsAbs(file) {
+		return filepath.Clean(file)
+	}
+	return filepath.Join(dir, filepath.FromSlash(file))
+}
+
+// relativeToWorkspace returns p relative to root (slash-separated), or p itself
+// when it lies outside the workspace.
+func relativeToWorkspace(root, p string) string {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
+
+func tailExcerpt(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= max {
+		return s
+	}
+	return "... (truncated)\n" + s[len(s)-max:]
+}
@@ -0,0 +1,64 @@
+package actions
+
+import (
+	"astra/astra/agents/configs"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	output := `# astra/astra/agents/core
+astra/agents/core/base_agent.go:42:9: undefined: foo
+vet: astra/main.go:7:2: unreachable code
+src/App.tsx(12,5): error TS2322: Type 'string' is not assignable to type 'number'.
+src/api.ts:3:1 - error TS1005: ';' expected.
+
+/work/frontend/src/hooks/useAstraChat.ts
+  14:7   warning  'unused' is assigned a value but never used  @typescript-eslint/no-unused-vars
+  20:1   error    Unexpected console statement                 no-console
+
+✖ 2 problems (1 error, 1 warning)
+`
+	diags := parseDiagnostics("go vet", output)
+	want := []Diagnostic{
+		{Tool: "go vet", File: "astra/agents/core/base_agent.go", Line: 42, Column: 9, Severity: "error", Message: "undefined: foo"},
+		{Tool: "go vet", File: "astra/main.go", Line: 7, Column: 2, Severity: "error", Message: "unreachable code"},
+		{Tool: "tsc", File: "src/App.tsx", Line: 12, Column: 5, Severity: "error", Message: "TS2322: Type 'string' is not assignable to type 'number'."},
+		{Tool: "tsc", File: "src/api.ts", Line: 3, Column: 1, Severity: "error", Message: "TS1005: ';' expected."},
+		{Tool: "eslint", File: "/work/frontend/src/hooks/useAstraChat.ts", Line: 14, Column: 7, Severity: "warning", Message: "'unused' is assigned a value but never used (@typescript-eslint/no-unused-vars)"},
+		{Tool: "eslint", File: "/work/frontend/src/hooks/useAstraChat.ts", Line: 20, Column: 1, Severity: "error", Message: "Unexpected console statement (no-console)"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %d: %+v", len(want), len(diags), diags)
+	}
+	for i := range want {
+		if diags[i] != want[i] {
+			t.Errorf("diagnostic %d:\n got  %+v\n want %+v", i, diags[i], want[i])
+		}
+	}
+}
+
+func TestRunValidation_SkipsMissingToolsAndKeepsGoing(t *testing.T) {
+	root := t.TempDir()
+	writeTestTree(t, root, map[string]string{
+		"go.mod":       "module example.com/demo\n\ngo 1.21\n",
+		"bad/bad.go":   "package bad\n\nfunc F() int { return undefinedName }\n",
+		"good/good.go": "package good\n",
+	})
+	a := &DataActions{actions: make(map[string]ActionSpec), Workspace: root}
+	a.SetValidationConfig(configs.ValidationConfig{GoCommands: [][]string{
+		{"astra-missing-tool-xyz", "-w", "./"},
+		{"go", "build", "./..."},
+		{"go", "vet", "./good"},
+	}})
+
+	res, _ := a.FmtVetBuild(t.Context(), NoParams{})
+	if res.Success || res.Error != "" || len(res.Steps) != 3 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Steps[0].Status != stepSkipped || res.Steps[1].Status != stepFailed || res.Steps[2].Status != stepPassed {
+		t.Errorf("unexpected step statuses: %+v", res.Steps)
+	}
+	if res.ErrorCount != 1 || res.Diagnostics[0].File != "bad/bad.go" || res.Diagnostics[0].Line != 3 || res.Diagnostics[0].Tool != "go build" {
+		t.Errorf("unexpected diagnostics: %+v", res.Diagnostics)
+	}
+}
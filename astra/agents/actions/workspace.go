@@ -0,0 +1,94 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetWorkspace confines file and process actions to the given root directory.
+func (a *DataActions) SetWorkspace(root string) error {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve workspace %s: %w", root, err)
+	}
+	a.Workspace = abs
+	return nil
+}
+
+// workspaceRoot returns the configured workspace, falling back to the current directory.
+func (a *DataActions) workspaceRoot() (string, error) {
+	if a.Workspace != "" {
+		return a.Workspace, nil
+	}
+	return os.Getwd()
+}
+
+// resolveInWorkspace resolves p relative to the workspace root and rejects
+// any path that escapes it, including through a symlink.
+func (a *DataActions) resolveInWorkspace(p string) (string, error) {
+	root, err := a.workspaceRoot()
+	if err != nil {
+		return "", fmt.Errorf("failed to get workspace root: %w", err)
+	}
+	if p == "" {
+		p = "."
+	}
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(root, p)
+	}
+	abs = filepath.Clean(abs)
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("access denied: %s is outside the workspace %s", p, root)
+	}
+	realRoot, err := evalSymlinksExisting(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+	realAbs, err := evalSymlinksExisting(abs)
+	if err != nil {
+		return "", fmt.Errorf("access denied: %w", err)
+	}
+	rel, err = filepath.Rel(realRoot, realAbs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("access denied: %s resolves to %s, outside the workspace %s", p, realAbs, root)
+	}
+	return abs, nil
+}
+
+// evalSymlinksExisting resolves symlinks in the longest existing prefix of p and
+// appends the part that does not exist yet, so paths about to be created can be
+// checked too. A dangling symlink is an error, since writing through it would
+// create its target.
+func evalSymlinksExisting(p string) (string, error) {
+	rest := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if fi, lerr := os.Lstat(p); lerr == nil && fi.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s is a dangling symlink", p)
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(p, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
+
+// withinWorkspace reports whether p is the workspace or inside it.
+func (a *DataActions) withinWorkspace(p string) bool {
+	_, err := a.resolveInWorkspace(p)
+	return err == nil
+}
@@ -31,12 +31,152 @@ type OutputFormats struct {
 	FinalSummaryJSON        string `yaml:"final_summary_json"`
 }
 
+// CommandRule allowlists a binary together with the argument patterns it may be called with.
+// When Subcommands is set the arguments must start with one of them, word for word
+// (e.g. "mod tidy"); every remaining argument must fully match one of the regex
+// patterns in Args.
+type CommandRule struct {
+	Binary      string   `yaml:"binary"`
+	Subcommands []string `yaml:"subcommands"`
+	Args        []string `yaml:"args"`
+}
+
+// RunCommandConfig configures the sandboxed run_command action for an agent.
+type RunCommandConfig struct {
+	Allowlist      []CommandRule `yaml:"allowlist"`
+	TimeoutSeconds int           `yaml:"timeout_seconds"`
+	MaxOutputBytes int           `yaml:"max_output_bytes"`
+	EnvPassthrough []string      `yaml:"env_passthrough"`
+}
+
+// ValidationConfig configures the fmt_vet_build and frontend_build actions.
+// Roots are relative to the workspace; each command is a binary followed by its args.
+type ValidationConfig struct {
+	GoRoot           string     `yaml:"go_root"`
+	GoCommands       [][]string `yaml:"go_commands"`
+	FrontendRoot     string     `yaml:"frontend_root"`
+	FrontendCommands [][]string `yaml:"frontend_commands"`
+	TimeoutSeconds   int        `yaml:"timeout_seconds"` // Per command
+}
+
+// HTTPRequestConfig configures the http_request action for an agent.
+// AllowedHosts entries are "host:port", "host" (any port) or "*.domain"; calls to other
+// hosts need user approval. Secrets are referenced in request headers as
+// {{secrets.NAME}} and substituted only for allowed hosts; values may reference
+// environment variables as ${VAR}.
+type HTTPRequestConfig struct {
+	AllowedHosts     []string          `yaml:"allowed_hosts"`
+	Secrets          map[string]string `yaml:"secrets"`
+	TimeoutSeconds   int               `yaml:"timeout_seconds"`
+	MaxResponseBytes int               `yaml:"max_response_bytes"`
+}
+
+// DatabaseConfig configures the read-only database actions. DSN selects the Postgres
+// database to inspect and may reference environment variables as ${VAR}; when empty,
+// the database actions are disabled. Role, when set, is assumed for every query and
+// should only have SELECT on the tables the agent may read. MaxRows caps db_query results.
+type DatabaseConfig struct {
+	DSN                     string `yaml:"dsn"`
+	Role                    string `yaml:"role"`
+	MaxRows                 int    `yaml:"max_rows"`
+	StatementTimeoutSeconds int    `yaml:"statement_timeout_seconds"`
+}
+
+// WorktreeConfig enables isolated runs: the agent edits and verifies in a temporary git
+// worktree on branch astra/<session>, and the user merges, keeps or discards the result.
+// Dir is the parent directory of the worktrees; default <tmp>/astra-worktrees.
+type WorktreeConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`
+}
+
+// PolicyConfig decides which actions an agent run may call. A rule applies when the run
+// matches all of its non-empty selectors. The first applying rule that allows or denies
+// an action decides; without one, Default ("allow" unless set to "deny") does. Approval
+// requirements and param constraints of all applying rules add up. Action names in
+// rules are globs, e.g. "db_*".
+type PolicyConfig struct {
+	Default   string                 `yaml:"default"` // allow or deny
+	Rules     []PolicyRule           `yaml:"rules"`
+	Untrusted UntrustedContentConfig `yaml:"untrusted"`
+}
+
+// UntrustedContentConfig gates actions once a run has taken in untrusted content: web
+// or MCP tool output, or file content with instruction-like text. RequireApproval lists
+// action globs that then need the user's approval; left unset, every action that
+// writes, deletes, executes or sends requests out (its effects) needs it, and an empty
+// list turns the gate off.
+type UntrustedContentConfig struct {
+	RequireApproval []string `yaml:"require_approval"`
+}
+
+// PolicyRule grants, denies or gates actions for the runs its selectors match.
+// Agents are compared with the agent_name of the config, ignoring case; Channels are
+// "web", "cli" and "mcp"; Sessions are globs.
+type PolicyRule struct {
+	Name            string   `yaml:"name"`
+	Users           []int    `yaml:"users"`
+	Agents          []string `yaml:"agents"`
+	Sessions        []string `yaml:"sessions"`
+	Channels        []string `yaml:"channels"`
+	Allow           []string `yaml:"allow"`
+	Deny            []string `yaml:"deny"`
+	RequireApproval []string `yaml:"require_approval"`
+	// Params constrains parameters per action glob. Keys are param paths like "url" or
+	// "edits.file"; list values are checked element by element.
+	Params map[string]map[string]PolicyParamRule `yaml:"params"`
+}
+
+// PolicyParamRule constrains one parameter. Pattern must fully match string values.
+type PolicyParamRule struct {
+	Pattern   string   `yaml:"pattern"`
+	Enum      []string `yaml:"enum"`
+	Max       *float64 `yaml:"max"`
+	Forbidden bool     `yaml:"forbidden"` // the parameter must not be set
+}
+
+// MCPServerConfig describes an MCP server whose tools are imported as actions.
+// Set Command for a stdio server or URL for a streamable HTTP server.
+// Env values and Headers values may reference environment variables as ${VAR}. A stdio
+// server's environment is the run_command passthrough plus Env; relative Dirs are
+// inside the workspace.
+type MCPServerConfig struct {
+	Name           string            `yaml:"name"`
+	Command        string            `yaml:"command"`
+	Args           []string          `yaml:"args"`
+	Env            map[string]string `yaml:"env"`
+	Dir            string            `yaml:"dir"`
+	URL            string            `yaml:"url"`
+	Headers        map[string]string `yaml:"headers"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"` // Per tool call
+	Disabled       bool              `yaml:"disabled"`
+}
+
+// DefaultAgentName is the agent's name when astra.yaml does not set agent_name.
+const DefaultAgentName = "astra"
+
 // AgentConfig matches astra.yaml
 type AgentConfig struct {
-	AgentName       string                `yaml:"agent_name"`
-	AgentRole       string                `yaml:"agent_role"`
-	DecisionProcess DecisionProcessConfig `yaml:"decision_process"`
-	OutputFormats   OutputFormats         `yaml:"output_formats"`
+	AgentName        string                `yaml:"agent_name"`
+	AgentRole        string                `yaml:"agent_role"`
+	DecisionProcess  DecisionProcessConfig `yaml:"decision_process"`
+	OutputFormats    OutputFormats         `yaml:"output_formats"`
+	RunCommand       RunCommandConfig      `yaml:"run_command"`
+	Validation       ValidationConfig      `yaml:"validation"`
+	HTTPRequest      HTTPRequestConfig     `yaml:"http_request"`
+	Database         DatabaseConfig        `yaml:"database"`
+	Worktree         WorktreeConfig        `yaml:"worktree"`
+	Policy           PolicyConfig          `yaml:"policy"`
+	CustomActionDirs []string              `yaml:"custom_action_dirs"` // Dirs of YAML-defined actions; relative ones are in the workspace
+	MCPServers       []MCPServerConfig     `yaml:"mcp_servers"`
+}
+
+// Name returns the configured agent name, or DefaultAgentName.
+func (c *AgentConfig) Name() string {
+	if c == nil || c.AgentName == "" {
+		return DefaultAgentName
+	}
+	return c.AgentName
 }
 
 // ---------- LOADER ----------
@@ -74,15 +214,109 @@ func LoadConfig() *AgentConfig {
 	return cfg
 }
 
-// ActionYAMLConfig for loading description/details from YAML
+// WorkspaceConfigFile is the optional per-project config checked into a workspace root.
+const WorkspaceConfigFile = ".astra.yaml"
+
+// WorkspaceConfig is the content of .astra.yaml.
+type WorkspaceConfig struct {
+	Project  ProjectConfig  `yaml:"project"`
+	Scaffold ScaffoldConfig `yaml:"scaffold"`
+}
+
+// ScaffoldConfig customizes scaffold_resource for a project.
+type ScaffoldConfig struct {
+	BackendRoot  string `yaml:"backend_root"`  // Directory holding main.go, controllers/, routes/ and sources/psql/; detected when empty
+	TemplatesDir string `yaml:"templates_dir"` // model/dao/controller/route .go.tmpl overrides; default .astra/templates/scaffold
+}
+
+// ProjectConfig overrides the detected project profile. Each entry is matched to a
+// detected language by name and root; its non-empty command lists replace the detected
+// ones, and entries that match nothing are added.
+type ProjectConfig struct {
+	Languages []ProjectLanguageConfig `yaml:"languages"`
+}
+
+// ProjectLanguageConfig holds the commands of one language in one project root; each
+// command is a binary followed by its args.
+type ProjectLanguageConfig struct {
+	Name     string     `yaml:"name"` // go, node, python, rust or make
+	Root     string     `yaml:"root"` // Relative to the workspace; default "."
+	Build    [][]string `yaml:"build"`
+	Test     [][]string `yaml:"test"`
+	Lint     [][]string `yaml:"lint"`
+	Format   [][]string `yaml:"format"`
+	Disabled bool       `yaml:"disabled"` // Drop this language from the profile
+}
+
+// LoadWorkspaceConfig reads .astra.yaml from root. A missing file yields an empty config.
+func LoadWorkspaceConfig(root string) (*WorkspaceConfig, error) {
+	cfg := &WorkspaceConfig{}
+	data, err := os.ReadFile(filepath.Join(root, WorkspaceConfigFile))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ActionYAMLConfig for loading description/details from YAML. Actions that also
+// declare an executor are registered without any Go code (see actions.LoadDeclarativeActions).
 type ActionYAMLConfig struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
-	Details     string `yaml:"details"`
+	Name        string                     `yaml:"name"`
+	Description string                     `yaml:"description"`
+	Details     string                     `yaml:"details"`
+	Params      map[string]ActionParamYAML `yaml:"params"`
+	Executor    *ActionExecutorYAML        `yaml:"executor"`
+	Effects     []string                   `yaml:"effects"` // write, delete, execute, network
+}
+
+// ActionParamYAML describes one parameter of a YAML-defined action.
+type ActionParamYAML struct {
+	Type        string        `yaml:"type" json:"type"` // string, integer, number, boolean, array, object
+	Description string        `yaml:"description" json:"description,omitempty"`
+	Example     interface{}   `yaml:"example" json:"example,omitempty"`
+	Required    bool          `yaml:"required" json:"required,omitempty"`
+	Default     interface{}   `yaml:"default" json:"default,omitempty"`
+	Enum        []interface{} `yaml:"enum" json:"enum,omitempty"`
+	Pattern     string        `yaml:"pattern" json:"pattern,omitempty"` // Regexp string values must fully match
+}
+
+// ActionExecutorYAML says how a declarative action runs. Exactly one of Command or HTTP is set.
+type ActionExecutorYAML struct {
+	Command *CommandExecutorYAML `yaml:"command"`
+	HTTP    *HTTPExecutorYAML    `yaml:"http"`
+	// Result maps result keys to dotted paths into the raw response,
+	// e.g. {version: "json.Version"} or {items: "body.data.items"}.
+	Result          map[string]string `yaml:"result"`
+	RequireApproval bool              `yaml:"require_approval"`
+}
+
+// CommandExecutorYAML runs a binary through the sandboxed runner. Args and Dir are
+// Go templates over the params, e.g. "{{.package}}"; args that render empty are dropped.
+type CommandExecutorYAML struct {
+	Binary         string   `yaml:"binary"`
+	Args           []string `yaml:"args"`
+	Dir            string   `yaml:"dir"`
+	TimeoutSeconds int      `yaml:"timeout_seconds"`
+	ParseJSON      bool     `yaml:"parse_json"` // Decode stdout as JSON into "json"
+}
+
+// HTTPExecutorYAML calls a local service. URL, header values and Body are Go templates.
+type HTTPExecutorYAML struct {
+	Method         string            `yaml:"method"`
+	URL            string            `yaml:"url"`
+	Headers        map[string]string `yaml:"headers"`
+	Body           string            `yaml:"body"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
 }
 
-// loadLearningActionYAML loads a specific YAML config by filename
-func loadLearningActionYAML(filename string) (*ActionYAMLConfig, error) {
+// LoadActionYAML loads a specific YAML config by filename
+func LoadActionYAML(filename string) (*ActionYAMLConfig, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
@@ -101,7 +335,7 @@ func LoadActionsYAMLInDir(dir string) (map[string]*ActionYAMLConfig, error) {
 		return nil, err
 	}
 	for _, f := range files {
-		cfg, err := loadLearningActionYAML(f)
+		cfg, err := LoadActionYAML(f)
 		if err == nil && cfg.Name != "" {
 			result[cfg.Name] = cfg
 		}
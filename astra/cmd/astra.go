@@ -2,27 +2,40 @@
 package main
 
 import (
+	"astra/astra/agents/actions"
+	"astra/astra/agents/configs"
 	"astra/astra/agents/core"
 	"astra/astra/config"
 	"astra/astra/controllers"
+	"astra/astra/services/llm"
+	"astra/astra/services/memory"
+	"astra/astra/services/worktree"
 	"astra/astra/sources/psql"
 	"astra/astra/sources/psql/dao"
+	"astra/astra/sources/psql/models"
 	colorutil "astra/astra/utils/color"
 	"astra/astra/utils/logging"
 	"bufio"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// cliMemoryBackfillInterval is how often a CLI session embeds new and changed memories.
+const cliMemoryBackfillInterval = time.Minute
+
 func main() {
 	// Initialize logger
 	logging.InitLogger()
@@ -59,32 +72,54 @@ func main() {
 		}
 		defer db.Close()
 
-		// --- Setup DAO + Controller ---
-		userDAO := dao.NewUserDAO(db.DB)
-		userCtrl := controllers.NewUserController(userDAO)
-
-		// --- Try to find or create user based on dir path ---
-		user, err := userDAO.GetUserByUsername(ctx, dirPath)
+		user, err := findOrCreateDirUser(ctx, db.DB, dirPath)
 		if err != nil {
-			logging.ErrorLogger.Error("error fetching user", zap.Error(err))
+			logging.ErrorLogger.Error("error resolving user", zap.Error(err))
 			os.Exit(1)
 		}
-		if user == nil {
-			email := fmt.Sprintf("%s@astra.local", filepath.Base(dirPath))
-			user, err = userCtrl.CreateUser(ctx, dirPath, email, nil, nil)
-			if err != nil {
-				logging.ErrorLogger.Error("error creating user", zap.Error(err))
-				os.Exit(1)
-			}
-			logging.AppLogger.Info("Created new Astra CLI user", zap.String("username", dirPath))
-		} else {
-			logging.AppLogger.Info("Found existing Astra CLI user", zap.Int("id", user.ID))
+
+		// --- Start MCP servers before the agent imports their tools ---
+		mcpCtx, mcpCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := actions.StartMCPServers(mcpCtx, configs.LoadConfig()); err != nil {
+			logging.ErrorLogger.Error("Failed to start MCP servers", zap.Error(err))
 		}
+		mcpCancel()
 
 		// --- Initialize agent ---
 		sessionID := fmt.Sprintf("cli-%s", uuid.New().String())
-		agentName := "astra"
-		agent := core.NewBaseAgent(user.ID, sessionID, agentName, db.DB)
+		agent := core.NewBaseAgent(user.ID, sessionID, db.DB)
+		agent.SetChannel(actions.ChannelCLI)
+
+		// Keep this user's memories embedded; searches only query existing vectors.
+		go memory.NewService(db.DB, agent.LLM, llm.DefaultGPTEmbeddingModel).RunBackfill(context.Background(), user.ID, cliMemoryBackfillInterval)
+
+		// Input is shared between the prompt loop and approval questions; the loop is
+		// blocked on agent output while an approval is pending.
+		scanner := bufio.NewScanner(os.Stdin)
+		agent.SetApprovalGate(func(req actions.ApprovalRequest) bool {
+			fmt.Println(colorutil.ColorWarning(fmt.Sprintf("\n⚠️  Approval required for %s: %s", req.Action, req.Reason)))
+			if len(req.Params) > 0 {
+				b, _ := json.Marshal(req.Params)
+				fmt.Println(colorutil.ColorInfo(string(b)))
+			}
+			fmt.Print(colorutil.ColorPrompt("Approve? [y/N] "))
+			if !scanner.Scan() {
+				return false
+			}
+			answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			return answer == "y" || answer == "yes"
+		})
+
+		// --- Isolated worktree mode ---
+		var wt *worktree.Worktree
+		if agent.Config.Worktree.Enabled || hasFlag(args[1:], "--worktree") {
+			wt, err = agent.UseWorktree(agent.Config.Worktree.Dir)
+			if err != nil {
+				logging.ErrorLogger.Error("error creating worktree", zap.Error(err))
+				fmt.Println(colorutil.ColorError(fmt.Sprintf("Could not create a git worktree: %v", err)))
+				os.Exit(1)
+			}
+		}
 
 		logging.AppLogger.Info("Astra agent initialized in CLI",
 			zap.String("dir", dirPath),
@@ -99,6 +134,9 @@ func main() {
 		// --- CLI Intro Message ---
 		fmt.Printf("%s", colorutil.ColorPrompt("\n🧑‍🚀 Astra is now connected in this directory!\n\n"))
 		fmt.Printf(colorutil.ColorInfo("Session: %s\nUser ID: %d\nPath: %s\n\n"), sessionID, user.ID, dirPath)
+		if wt != nil {
+			fmt.Printf(colorutil.ColorInfo("Worktree: %s (branch %s)\nEdits stay there until you merge them when the session ends.\n\n"), wt.Workspace(), wt.Branch)
+		}
 		fmt.Println(colorutil.ColorPrompt("You can:"))
 		fmt.Println(colorutil.ColorInfo("  - Ask for project bootstrapping (e.g., 'Create a new Vite + TS + Three.js frontend here')"))
 		fmt.Println(colorutil.ColorInfo("  - Request backend setup, schema generation, or debugging help"))
@@ -106,7 +144,6 @@ func main() {
 		fmt.Println(colorutil.ColorPrompt("Type your command or 'exit' to quit.\n"))
 
 		// --- Input Loop ---
-		scanner := bufio.NewScanner(os.Stdin)
 		for {
 			fmt.Print(colorutil.ColorPrompt("astra> "))
 			if !scanner.Scan() {
@@ -148,6 +185,9 @@ func main() {
 						if msg, ok := payload["message"].(string); ok {
 							fmt.Println(colorutil.ColorFinalSuccess(msg))
 						}
+						if redactions, ok := payload["redactions"].(map[string]interface{}); ok && len(redactions) > 0 {
+							fmt.Println(colorutil.ColorWarning(fmt.Sprintf("Secrets redacted from LLM requests: %v", redactions)))
+						}
 					}
 				case "intermediate":
 					// Show intermediate status/plan/step updates
@@ -156,6 +196,21 @@ func main() {
 							fmt.Println(colorutil.ColorInfo(msg))
 						}
 					}
+				case "action_output":
+					if payload != nil {
+						printActionOutput(payload)
+					}
+				case "untrusted_content":
+					if payload != nil {
+						msg, _ := payload["message"].(string)
+						fmt.Println(colorutil.ColorWarning("Warning: " + msg))
+						flags, _ := payload["injection_flags"].([]interface{})
+						for _, f := range flags {
+							if flag, ok := f.(map[string]interface{}); ok {
+								fmt.Printf(colorutil.ColorWarning("   %v: %q\n"), flag["pattern"], flag["excerpt"])
+							}
+						}
+					}
 				case "response_chunk":
 					// if payload != nil {
 					// 	if chunk, ok := payload["chunk"].(string); ok {
@@ -169,15 +224,301 @@ func main() {
 			}
 			fmt.Println()
 		}
+		if wt != nil {
+			reviewWorktree(scanner, wt)
+		}
+		actions.CloseMCPServers()
 		os.Exit(0)
 
+	} else if len(args) >= 2 && args[0] == "mcp" && args[1] == "tools" {
+		os.Exit(listMCPTools())
+
+	} else if len(args) == 1 && args[0] == "mcp" {
+		os.Exit(serveMCP(ctx, cfg))
+
+	} else if len(args) >= 1 && args[0] == "history" {
+		os.Exit(showHistory(ctx, cfg, args[1:]))
+
 	} else {
 		fmt.Println(colorutil.ColorPrompt("Astra CLI usage:"))
-		fmt.Println(colorutil.ColorInfo("  astra connect   # Connect to Astra agent in this directory"))
+		fmt.Println(colorutil.ColorInfo("  astra connect     # Connect to Astra agent in this directory"))
+		fmt.Println(colorutil.ColorInfo("  astra connect --worktree  # Work in a temporary git worktree; review and merge at the end"))
+		fmt.Println(colorutil.ColorInfo("  astra mcp         # Serve Astra's actions, notes and knowledge as an MCP server over stdio"))
+		fmt.Println(colorutil.ColorInfo("  astra mcp tools   # List tools imported from the configured MCP servers"))
+		fmt.Println(colorutil.ColorInfo("  astra history     # Show actions run in this directory (--group file_edits --since 7d, --status failed --stats)"))
 		os.Exit(1)
 	}
 }
 
+// --- Helper: Find or create the user a working directory maps to ---
+func findOrCreateDirUser(ctx context.Context, db *gorm.DB, dirPath string) (*models.User, error) {
+	userDAO := dao.NewUserDAO(db)
+	userCtrl := controllers.NewUserController(userDAO)
+
+	user, err := userDAO.GetUserByUsername(ctx, dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("fetch user: %w", err)
+	}
+	if user != nil {
+		logging.AppLogger.Info("Found existing Astra CLI user", zap.Int("id", user.ID))
+		return user, nil
+	}
+	email := fmt.Sprintf("%s@astra.local", filepath.Base(dirPath))
+	user, err = userCtrl.CreateUser(ctx, dirPath, email, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create user: %w", err)
+	}
+	logging.AppLogger.Info("Created new Astra CLI user", zap.String("username", dirPath))
+	return user, nil
+}
+
+// --- Helper: Serve the action registry as an MCP server over stdio ---
+func serveMCP(ctx context.Context, cfg config.Config) int {
+	// Stdout carries the protocol; anything else printed (action debug output) goes to stderr.
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
+
+	dirPath := getWorkingDir()
+	db, err := psql.NewDatabase(ctx, cfg)
+	if err != nil {
+		logging.ErrorLogger.Error("database connection error", zap.Error(err))
+		fmt.Fprintln(os.Stderr, "astra mcp: database connection error:", err)
+		return 1
+	}
+	defer db.Close()
+
+	user, err := findOrCreateDirUser(ctx, db.DB, dirPath)
+	if err != nil {
+		logging.ErrorLogger.Error("error resolving user", zap.Error(err))
+		fmt.Fprintln(os.Stderr, "astra mcp:", err)
+		return 1
+	}
+
+	dataActions := actions.NewDataActions(db.DB, user.ID)
+	dataActions.Channel = actions.ChannelMCP
+	if agentCfg := configs.LoadConfig(); agentCfg != nil {
+		if _, err := dataActions.ApplyAgentConfig(agentCfg); err != nil {
+			logging.ErrorLogger.Error("Failed to load declarative actions", zap.Error(err))
+		}
+	}
+	srv := dataActions.NewMCPServer("astra", config.HealthAPIVersion)
+
+	serveCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logging.AppLogger.Info("Serving MCP over stdio", zap.String("dir", dirPath), zap.Int("userID", user.ID))
+	if err := srv.ServeStdio(serveCtx, os.Stdin, protocolOut); err != nil && serveCtx.Err() == nil {
+		logging.ErrorLogger.Error("mcp server stopped", zap.Error(err))
+		return 1
+	}
+	return 0
+}
+
+// --- Helper: List MCP tools imported by the agent config ---
+func listMCPTools() int {
+	agentCfg := configs.LoadConfig()
+	if agentCfg == nil || len(agentCfg.MCPServers) == 0 {
+		fmt.Println(colorutil.ColorWarning("No mcp_servers configured in astra.yaml"))
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	defer actions.CloseMCPServers()
+
+	tools, err := actions.ListMCPTools(ctx, agentCfg)
+	server := ""
+	for _, t := range tools {
+		if t.Server != server {
+			server = t.Server
+			fmt.Println(colorutil.ColorPrompt(fmt.Sprintf("\n%s", server)))
+		}
+		fmt.Printf("  %s\n", colorutil.ColorInfo(t.Action))
+		if t.Description != "" {
+			fmt.Printf("      %s\n", t.Description)
+		}
+	}
+	fmt.Printf("\n%d tool(s) imported\n", len(tools))
+	if err != nil {
+		fmt.Println(colorutil.ColorError(err.Error()))
+		return 1
+	}
+	return 0
+}
+
+// --- Helper: Show the action execution history of this directory's user ---
+func showHistory(ctx context.Context, cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	var q controllers.ExecutionQuery
+	fs.StringVar(&q.Actions, "action", "", "comma-separated action names or globs")
+	fs.StringVar(&q.Group, "group", "", "action group: file_edits, commands, database, network or mcp")
+	fs.StringVar(&q.Status, "status", "", "comma-separated statuses: succeeded, failed, denied")
+	fs.StringVar(&q.Since, "since", "", "only executions after this time, date or age (7d, 12h)")
+	fs.StringVar(&q.Until, "until", "", "only executions before this time, date or age")
+	fs.StringVar(&q.SessionID, "session", "", "only this chat session")
+	fs.StringVar(&q.RunID, "run", "", "only this run")
+	fs.IntVar(&q.Limit, "limit", 50, "maximum number of rows")
+	stats := fs.Bool("stats", false, "count executions by action and status instead of listing them")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	filter, err := q.Filter(0, time.Now())
+	if err != nil {
+		fmt.Println(colorutil.ColorError(err.Error()))
+		return 2
+	}
+
+	dirPath := getWorkingDir()
+	db, err := psql.NewDatabase(ctx, cfg)
+	if err != nil {
+		logging.ErrorLogger.Error("database connection error", zap.Error(err))
+		fmt.Println(colorutil.ColorError("database connection error: " + err.Error()))
+		return 1
+	}
+	defer db.Close()
+	user, err := findOrCreateDirUser(ctx, db.DB, dirPath)
+	if err != nil {
+		fmt.Println(colorutil.ColorError(err.Error()))
+		return 1
+	}
+	filter.UserID = user.ID
+	ctrl := controllers.NewActionExecutionsController(dao.NewActionExecutionDAO(db.DB))
+
+	var rows any
+	if *stats {
+		rows, err = ctrl.CountExecutions(ctx, filter)
+	} else {
+		rows, err = ctrl.ListExecutions(ctx, filter)
+	}
+	if err != nil {
+		fmt.Println(colorutil.ColorError(err.Error()))
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	switch rows := rows.(type) {
+	case []dao.ActionExecutionCount:
+		for _, c := range rows {
+			fmt.Printf("%6d  %s %-40s avg %6.0fms  last %s\n", c.Count, colorStatus(c.Status), c.Action, c.AvgDurationMs, c.LastAt.Local().Format(time.DateTime))
+		}
+		fmt.Printf("\n%d group(s)\n", len(rows))
+	case []models.ActionExecution:
+		for _, e := range rows {
+			fmt.Printf("%s  %s %-32s %6dms", e.CreatedAt.Local().Format(time.DateTime), colorStatus(e.Status), e.Action, e.DurationMs)
+			if e.Approval != "" {
+				fmt.Printf("  %s by %s", e.Approval, e.Approver)
+			}
+			fmt.Println()
+			if e.Error != "" {
+				fmt.Printf("    %s\n", colorutil.ColorError(truncateLine(e.Error, 160)))
+			}
+		}
+		fmt.Printf("\n%d execution(s)\n", len(rows))
+	}
+	return 0
+}
+
+// colorStatus pads status to a column before colouring it, so rows stay aligned.
+func colorStatus(status string) string {
+	padded := fmt.Sprintf("%-10s", status)
+	switch status {
+	case models.ExecutionSucceeded:
+		return colorutil.ColorFinalSuccess(padded)
+	case models.ExecutionDenied:
+		return colorutil.ColorWarning(padded)
+	default:
+		return colorutil.ColorError(padded)
+	}
+}
+
+func truncateLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// --- Helper: Check for a command-line flag ---
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Helper: Let the user merge, keep or discard the session's worktree ---
+func reviewWorktree(scanner *bufio.Scanner, wt *worktree.Worktree) {
+	diff, err := wt.Diff()
+	if err != nil {
+		fmt.Println(colorutil.ColorError(fmt.Sprintf("Could not diff the worktree, keeping it at %s: %v", wt.Path, err)))
+		return
+	}
+	if diff == "" {
+		fmt.Println(colorutil.ColorInfo("No changes were made; removing the worktree."))
+		if err := wt.Discard(); err != nil {
+			fmt.Println(colorutil.ColorError(err.Error()))
+		}
+		return
+	}
+	stat, _ := wt.DiffStat()
+	fmt.Println(colorutil.ColorPrompt(fmt.Sprintf("\nChanges on branch %s:\n", wt.Branch)))
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Println(line)
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(colorutil.ColorFinalSuccess(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(colorutil.ColorError(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(colorutil.ColorInfo(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+	fmt.Println(colorutil.ColorInfo("\n" + stat + "\n"))
+
+	for {
+		fmt.Print(colorutil.ColorPrompt("[m]erge into the working copy, [k]eep branch, [d]iscard? "))
+		answer := "k" // Keep the work when input ends
+		if scanner.Scan() {
+			answer = strings.ToLower(strings.TrimSpace(scanner.Text()))
+		}
+		switch answer {
+		case "m", "merge":
+			if err := wt.Merge(); err != nil {
+				fmt.Println(colorutil.ColorError(err.Error()))
+				return
+			}
+			fmt.Println(colorutil.ColorFinalSuccess("Merged into the working copy (uncommitted)."))
+		case "k", "keep":
+			if err := wt.Keep(); err != nil {
+				fmt.Println(colorutil.ColorError(err.Error()))
+				return
+			}
+			fmt.Println(colorutil.ColorFinalSuccess(fmt.Sprintf("Kept on branch %s.", wt.Branch)))
+		case "d", "discard":
+			if err := wt.Discard(); err != nil {
+				fmt.Println(colorutil.ColorError(err.Error()))
+				return
+			}
+			fmt.Println(colorutil.ColorFinalSuccess("Discarded."))
+		default:
+			continue
+		}
+		return
+	}
+}
+
 // --- Helper: Get Working Directory ---
 func getWorkingDir() string {
 	wd, err := os.Getwd()
@@ -237,3 +578,32 @@ func detectActiveAstraSessions() []string {
 	}
 	return paths
 }
+
+// printActionOutput shows a live output line or progress update of a running action.
+func printActionOutput(payload map[string]interface{}) {
+	if line, ok := payload["line"].(string); ok {
+		if payload["stream"] == "stderr" {
+			fmt.Println(colorutil.ColorWarning("  │ " + line))
+		} else {
+			fmt.Println(colorutil.ColorInfo("  │ " + line))
+		}
+		return
+	}
+	text := ""
+	if pct, ok := payload["percent"].(float64); ok {
+		text = fmt.Sprintf("[%3.0f%%] ", pct)
+	}
+	for _, key := range []string{"item", "status", "message"} {
+		if v, ok := payload[key].(string); ok && v != "" {
+			text += v + " "
+		}
+	}
+	if text == "" {
+		return
+	}
+	if payload["status"] == "failed" {
+		fmt.Println(colorutil.ColorWarning("  " + strings.TrimSpace(text)))
+	} else {
+		fmt.Println(colorutil.ColorInfo("  " + strings.TrimSpace(text)))
+	}
+}
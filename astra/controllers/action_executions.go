@@ -0,0 +1,137 @@
+// astra/controllers/action_executions.go
+package controllers
+
+import (
+	"astra/astra/sources/psql/dao"
+	"astra/astra/sources/psql/models"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultExecutionLimit = 100
+	maxExecutionLimit     = 1000
+)
+
+// ActionGroups names sets of actions a history query can ask for at once.
+var ActionGroups = map[string][]string{
+	"file_edits": {"apply_code_edits", "scaffold_resource"},
+	"commands":   {"run_command", "fmt_vet_build", "frontend_build", "build_project", "test_project", "lint_project"},
+	"database":   {"db_*"},
+	"network":    {"http_request", "scrape_urls", "query_web"},
+	"mcp":        {"mcp_*"},
+}
+
+// ExecutionQuery is a history query as given on the REST API and the CLI.
+type ExecutionQuery struct {
+	Actions   string // Comma-separated action names or globs
+	Group     string // Key of ActionGroups, added to Actions
+	Status    string // Comma-separated statuses
+	Since     string // RFC 3339 time, date, or age such as 7d or 12h
+	Until     string
+	SessionID string
+	RunID     string
+	Limit     int
+}
+
+// Filter resolves q into a DAO filter over userID's executions.
+func (q ExecutionQuery) Filter(userID int, now time.Time) (dao.ActionExecutionFilter, error) {
+	f := dao.ActionExecutionFilter{UserID: userID, SessionID: q.SessionID, RunID: q.RunID, Actions: splitList(q.Actions), Limit: q.Limit}
+	if q.Group != "" {
+		group, ok := ActionGroups[q.Group]
+		if !ok {
+			return f, fmt.Errorf("unknown action group %q", q.Group)
+		}
+		f.Actions = append(f.Actions, group...)
+	}
+	for _, s := range splitList(q.Status) {
+		if s != models.ExecutionSucceeded && s != models.ExecutionFailed && s != models.ExecutionDenied {
+			return f, fmt.Errorf("unknown status %q: use succeeded, failed or denied", s)
+		}
+		f.Statuses = append(f.Statuses, s)
+	}
+	var err error
+	if f.Since, err = parseTimeBound(q.Since, now); err != nil {
+		return f, fmt.Errorf("since: %w", err)
+	}
+	if f.Until, err = parseTimeBound(q.Until, now); err != nil {
+		return f, fmt.Errorf("until: %w", err)
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultExecutionLimit
+	}
+	f.Limit = min(f.Limit, maxExecutionLimit)
+	return f, nil
+}
+
+// parseTimeBound reads an RFC 3339 time, a date, or an age counted back from now.
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return t, nil
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a time, a date or an age such as 7d or 12h", s)
+	}
+	return now.Add(-age), nil
+}
+
+// parseAge extends time.ParseDuration with days (d) and weeks (w).
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" && !slices.Contains(out, part) {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+type ActionExecutionsController struct {
+	dao *dao.ActionExecutionDAO
+}
+
+func NewActionExecutionsController(dao *dao.ActionExecutionDAO) *ActionExecutionsController {
+	return &ActionExecutionsController{dao: dao}
+}
+
+func (c *ActionExecutionsController) ListExecutions(ctx context.Context, f dao.ActionExecutionFilter) ([]models.ActionExecution, error) {
+	return c.dao.ListActionExecutions(ctx, f)
+}
+
+func (c *ActionExecutionsController) CountExecutions(ctx context.Context, f dao.ActionExecutionFilter) ([]dao.ActionExecutionCount, error) {
+	return c.dao.CountActionExecutions(ctx, f)
+}
+
+func (c *ActionExecutionsController) GetExecution(ctx context.Context, userID int, id uuid.UUID) (*models.ActionExecution, error) {
+	return c.dao.GetActionExecution(ctx, userID, id)
+}
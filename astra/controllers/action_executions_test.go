@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"astra/astra/sources/psql/dao"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestExecutionQuery_Filter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	f, err := ExecutionQuery{Group: "file_edits", Actions: "db_*, db_*", Status: "failed", Since: "7d", Until: "2026-03-10"}.Filter(5, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.UserID != 5 || len(f.Actions) != 3 || f.Actions[0] != "db_*" || f.Statuses[0] != "failed" {
+		t.Errorf("unexpected filter %+v", f)
+	}
+	if !f.Since.Equal(now.Add(-7*24*time.Hour)) || !f.Until.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)) || f.Limit != defaultExecutionLimit {
+		t.Errorf("unexpected bounds %v - %v, limit %d", f.Since, f.Until, f.Limit)
+	}
+	for _, q := range []ExecutionQuery{{Status: "done"}, {Since: "last week"}, {Group: "files"}} {
+		if _, err := q.Filter(5, now); err == nil {
+			t.Errorf("expected %+v to be rejected", q)
+		}
+	}
+}
+
+func TestActionExecutions_RequireUser(t *testing.T) {
+	ctrl := NewActionExecutionsController(dao.NewActionExecutionDAO(nil))
+	f, err := ExecutionQuery{}.Filter(0, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctrl.ListExecutions(t.Context(), f); !errors.Is(err, dao.ErrActionExecutionUser) {
+		t.Errorf("expected listing without a user to fail, got %v", err)
+	}
+	if _, err := ctrl.CountExecutions(t.Context(), f); !errors.Is(err, dao.ErrActionExecutionUser) {
+		t.Errorf("expected counting without a user to fail, got %v", err)
+	}
+	if _, err := ctrl.GetExecution(t.Context(), 0, uuid.New()); !errors.Is(err, dao.ErrActionExecutionUser) {
+		t.Errorf("expected a lookup without a user to fail, got %v", err)
+	}
+}
@@ -1,20 +1,27 @@
 package controllers
 
 import (
+	"astra/astra/agents/actions"
+	"astra/astra/agents/configs"
 	"astra/astra/agents/core"
 	"astra/astra/utils/logging"
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type AgentsController struct {
-	db *gorm.DB
+	db      *gorm.DB
+	inboxes sync.Map // *websocket.Conn -> *wsInbox
 }
 
 func NewAgentsController(db *gorm.DB) *AgentsController {
@@ -35,6 +42,11 @@ func (c *AgentsController) ProcessAgentRequest(ctx context.Context, w *websocket
 		w.Write(ctx, websocket.MessageText, []byte(`{"error":"invalid user_id"}`))
 		return false
 	}
+	// Policy rules select by agent name, so it must name the configured agent.
+	if req.AgentName != "" && !strings.EqualFold(req.AgentName, configs.LoadConfig().Name()) {
+		w.Write(ctx, websocket.MessageText, []byte(`{"error":"unknown agent_name"}`))
+		return false
+	}
 
 	if req.SessionID == "" {
 		req.SessionID = fmt.Sprintf("agent-%d-%s", validatedUserID, time.Now().Format("20060102150405"))
@@ -50,8 +62,10 @@ func (c *AgentsController) ProcessAgentRequest(ctx context.Context, w *websocket
 		return true
 	}
 
-	agent := core.NewBaseAgent(validatedUserID, req.SessionID, req.AgentName, c.db)
-	respCh := agent.ProcessQuery(req.Query)
+	agent := core.NewBaseAgent(validatedUserID, req.SessionID, c.db)
+	agent.SetChannel(actions.ChannelWeb)
+	agent.SetApprovalGate(c.websocketApprovalGate(ctx, w, agent.Name, req.SessionID))
+	respCh := agent.ProcessQueryContext(ctx, req.Query)
 
 	for chunk := range respCh {
 		if err := w.Write(ctx, websocket.MessageText, []byte(chunk)); err != nil {
@@ -62,6 +76,139 @@ func (c *AgentsController) ProcessAgentRequest(ctx context.Context, w *websocket
 	return true
 }
 
+// ListActions returns the actions an agent of userID can call over the web, with their
+// param schemas, sorted by name. Actions the policy forbids and tools imported from MCP
+// servers are not included.
+func (c *AgentsController) ListActions(userID int) []actions.ActionSpec {
+	dataActions := actions.NewDataActions(c.db, userID)
+	dataActions.Channel = actions.ChannelWeb
+	if cfg := configs.LoadConfig(); cfg != nil {
+		if _, err := dataActions.ApplyAgentConfig(cfg); err != nil {
+			logging.ErrorLogger.Error("Failed to load declarative actions", zap.Error(err))
+		}
+	}
+	specs := dataActions.ListActions()
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// approvalTimeout bounds how long an agent run waits for the user to answer.
+var approvalTimeout = 2 * time.Minute
+
+type wsMessage struct {
+	typ  websocket.MessageType
+	data []byte
+	err  error
+}
+
+// wsInbox reads a connection in a single goroutine. The request loop and approval gates
+// wait on msgs with their own deadlines instead of cancelling a Read, which would close
+// the socket. msgs is closed after the first read error has been delivered.
+type wsInbox struct {
+	msgs chan wsMessage
+}
+
+// inbox returns the connection's inbox, starting its reader on first use.
+func (c *AgentsController) inbox(ctx context.Context, w *websocket.Conn) *wsInbox {
+	if in, ok := c.inboxes.Load(w); ok {
+		return in.(*wsInbox)
+	}
+	in := &wsInbox{msgs: make(chan wsMessage)}
+	if actual, loaded := c.inboxes.LoadOrStore(w, in); loaded {
+		return actual.(*wsInbox)
+	}
+	go func() {
+		defer c.inboxes.Delete(w)
+		defer close(in.msgs)
+		for {
+			typ, data, err := w.Read(ctx)
+			select {
+			case in.msgs <- wsMessage{typ: typ, data: data, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return in
+}
+
+// approvalResponse is what the client sends to answer an approval_required event.
+type approvalResponse struct {
+	Approval *struct {
+		ID       string `json:"id"`
+		Approved bool   `json:"approved"`
+	} `json:"approval"`
+}
+
+// websocketApprovalGate sends an approval_required event to the client and waits for
+// {"approval": {"id": "...", "approved": true}}. The request loop is blocked inside
+// ProcessAgentRequest while the agent runs, so the gate takes the next messages from
+// the connection's inbox. Without an answer within approvalTimeout the call is denied
+// and the connection stays open.
+func (c *AgentsController) websocketApprovalGate(ctx context.Context, w *websocket.Conn, agentName, sessionID string) actions.ApprovalGate {
+	return func(req actions.ApprovalRequest) bool {
+		approvalID := uuid.New().String()
+		event := map[string]interface{}{
+			"agent_name": agentName,
+			"session_id": sessionID,
+			"type":       "approval_required",
+			"payload": map[string]interface{}{
+				"approval_id": approvalID,
+				"action":      req.Action,
+				"reason":      req.Reason,
+				"params":      req.Params,
+			},
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		}
+		b, err := json.Marshal(event)
+		if err != nil {
+			return false
+		}
+		if err := w.Write(ctx, websocket.MessageText, b); err != nil {
+			logging.ErrorLogger.Error("websocket approval write error", zap.Error(err))
+			return false
+		}
+
+		in := c.inbox(ctx, w)
+		timer := time.NewTimer(approvalTimeout)
+		defer timer.Stop()
+		for {
+			var msg wsMessage
+			select {
+			case <-timer.C:
+				logging.AppLogger.Info("websocket approval timed out", zap.String("action", req.Action), zap.String("session_id", sessionID))
+				return false
+			case <-ctx.Done():
+				return false
+			case m, ok := <-in.msgs:
+				if !ok {
+					return false
+				}
+				msg = m
+			}
+			if msg.err != nil {
+				logging.ErrorLogger.Error("websocket approval read error", zap.Error(msg.err))
+				return false
+			}
+			if msg.typ != websocket.MessageText {
+				continue
+			}
+			var resp approvalResponse
+			if err := json.Unmarshal(msg.data, &resp); err != nil || resp.Approval == nil {
+				w.Write(ctx, websocket.MessageText, []byte(`{"error":"approval pending, send an approval response first"}`))
+				continue
+			}
+			if resp.Approval.ID != approvalID {
+				continue
+			}
+			return resp.Approval.Approved
+		}
+	}
+}
+
 func (c *AgentsController) AgentWebSocket(ctx context.Context, w *websocket.Conn, validatedUserID int) {
 	// Set up ping/pong to keep connection alive
 	pingInterval := 30 * time.Second
@@ -94,38 +241,49 @@ func (c *AgentsController) AgentWebSocket(ctx context.Context, w *websocket.Conn
 		}
 	}()
 
+	in := c.inbox(ctx, w)
 	for {
+		var msg wsMessage
 		select {
 		case <-ctx.Done():
 			logging.AppLogger.Info("websocket context done, closing connection")
 			return
-		default:
-			typ, data, err := w.Read(ctx)
-			if err != nil {
-				if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
-					logging.AppLogger.Info("client closed connection")
-					return
-				}
-				logging.ErrorLogger.Error("websocket read error", zap.Error(err))
-				time.Sleep(1 * time.Second) // give client time to reconnect
-				continue
+		case m, ok := <-in.msgs:
+			if !ok {
+				return
 			}
-
-			if typ != websocket.MessageText {
-				w.Write(ctx, websocket.MessageText, []byte(`{"error":"unsupported data"}`))
-				continue
+			msg = m
+		}
+		if msg.err != nil {
+			if websocket.CloseStatus(msg.err) == websocket.StatusNormalClosure {
+				logging.AppLogger.Info("client closed connection")
+			} else {
+				logging.ErrorLogger.Error("websocket read error", zap.Error(msg.err))
 			}
+			return
+		}
 
-			var req AgentRequest
-			if err := json.Unmarshal(data, &req); err != nil {
-				w.Write(ctx, websocket.MessageText, []byte(`{"error":"invalid json"}`))
-				continue
-			}
+		if msg.typ != websocket.MessageText {
+			w.Write(ctx, websocket.MessageText, []byte(`{"error":"unsupported data"}`))
+			continue
+		}
 
-			// Process the request (validates user_id and handles session/init)
-			if !c.ProcessAgentRequest(ctx, w, &req, validatedUserID) {
-				continue // Or close on repeated failures if needed
-			}
+		// An answer that arrives after its approval timed out.
+		var approval approvalResponse
+		if json.Unmarshal(msg.data, &approval) == nil && approval.Approval != nil {
+			w.Write(ctx, websocket.MessageText, []byte(`{"error":"approval is no longer pending"}`))
+			continue
+		}
+
+		var req AgentRequest
+		if err := json.Unmarshal(msg.data, &req); err != nil {
+			w.Write(ctx, websocket.MessageText, []byte(`{"error":"invalid json"}`))
+			continue
+		}
+
+		// Process the request (validates user_id and handles session/init)
+		if !c.ProcessAgentRequest(ctx, w, &req, validatedUserID) {
+			continue // Or close on repeated failures if needed
 		}
 	}
 }
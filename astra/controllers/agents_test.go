@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"astra/astra/agents/actions"
+	"astra/astra/utils/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestWebsocketApprovalGate_TimeoutKeepsConnectionOpen(t *testing.T) {
+	logging.InitLogger()
+	defer func(d time.Duration) { approvalTimeout = d }(approvalTimeout)
+	approvalTimeout = 50 * time.Millisecond
+
+	c := &AgentsController{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w, err := websocket.Accept(rw, r, nil)
+		if err != nil {
+			return
+		}
+		ctx := r.Context()
+		gate := c.websocketApprovalGate(ctx, w, "astra", "s1")
+		approved := gate(actions.ApprovalRequest{Action: "run_command", Reason: "test"})
+		// The late answer reaches the request loop, which must still be able to read.
+		msg := <-c.inbox(ctx, w).msgs
+		result := "denied"
+		if approved {
+			result = "approved"
+		}
+		w.Write(ctx, websocket.MessageText, []byte(result+" "+string(msg.data)))
+		w.Close(websocket.StatusNormalClosure, "")
+	}))
+	defer srv.Close()
+
+	ctx := t.Context()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	if _, data, err := conn.Read(ctx); err != nil || !strings.Contains(string(data), "approval_required") {
+		t.Fatalf("expected an approval request, got %s (%v)", data, err)
+	}
+	time.Sleep(4 * approvalTimeout)
+	if err := conn.Write(ctx, websocket.MessageText, []byte("late")); err != nil {
+		t.Fatalf("expected the connection to stay open after the timeout: %v", err)
+	}
+	if _, data, err := conn.Read(ctx); err != nil || string(data) != "denied late" {
+		t.Errorf("expected a denial and the late message to be read, got %q (%v)", data, err)
+	}
+}
+
+func TestProcessAgentRequest_RejectsUnknownAgent(t *testing.T) {
+	c := &AgentsController{}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w, err := websocket.Accept(rw, r, nil)
+		if err != nil {
+			return
+		}
+		ok := c.ProcessAgentRequest(r.Context(), w, &AgentRequest{UserID: 1, AgentName: "other", Query: "init"}, 1)
+		if ok {
+			w.Write(r.Context(), websocket.MessageText, []byte("accepted"))
+		}
+		w.Close(websocket.StatusNormalClosure, "")
+	}))
+	defer srv.Close()
+
+	ctx := t.Context()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	if _, data, err := conn.Read(ctx); err != nil || !strings.Contains(string(data), "unknown agent_name") {
+		t.Errorf("expected the agent name to be rejected, got %s (%v)", data, err)
+	}
+}
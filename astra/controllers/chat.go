@@ -2,22 +2,48 @@
 package controllers
 
 import (
+	"astra/astra/services/redact"
 	"astra/astra/sources/psql/dao"
 	httputils "astra/astra/utils/http"
+	"astra/astra/utils/logging"
 	"astra/astra/utils/types"
 	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 type ChatController struct {
-	chatDAO *dao.ChatMessageDAO
+	chatDAO  *dao.ChatMessageDAO
+	redactor *redact.Redactor
 }
 
 func NewChatController(chatDAO *dao.ChatMessageDAO) *ChatController {
-	return &ChatController{chatDAO: chatDAO}
+	return &ChatController{chatDAO: chatDAO, redactor: redact.New()}
+}
+
+// redactHistory removes secrets from the messages sent to the LLM; the stored history
+// keeps the originals.
+func (c *ChatController) redactHistory(sessionID string, history []map[string]string) ([]map[string]string, redact.Counts) {
+	counts := redact.Counts{}
+	out := make([]map[string]string, len(history))
+	for i, msg := range history {
+		out[i] = make(map[string]string, len(msg))
+		for k, v := range msg {
+			out[i][k] = v
+		}
+		content, n := c.redactor.Redact(msg["content"])
+		out[i]["content"] = content
+		counts.Add(n)
+	}
+	if counts.Total() > 0 {
+		logging.AppLogger.Info("Redacted secrets from chat request", zap.String("session_id", sessionID), zap.Any("redactions", counts))
+	}
+	return out, counts
 }
 
 func (c *ChatController) Chat(ctx context.Context, userID int, req types.ChatRequest) (map[string]string, error) {
@@ -33,6 +59,7 @@ func (c *ChatController) Chat(ctx context.Context, userID int, req types.ChatReq
 	if err != nil {
 		return nil, err
 	}
+	history, redactions := c.redactHistory(sessionID, history)
 	llmReq := map[string]interface{}{
 		"model":    "llama3:8b",
 		"messages": history,
@@ -54,9 +81,16 @@ func (c *ChatController) Chat(ctx context.Context, userID int, req types.ChatReq
 	if err != nil {
 		return nil, err
 	}
-	return map[string]string{"response": content, "session_id": sessionID}, nil
+	res := map[string]string{"response": content, "session_id": sessionID}
+	if n := redactions.Total(); n > 0 {
+		res["redactions"] = strconv.Itoa(n)
+	}
+	return res, nil
 }
 
+// ChatStream sends the reply as text deltas. The last message is a JSON object like
+// Chat's response without the text: {"done": "true", "session_id": ...}, plus
+// "redactions" when secrets were removed from the request.
 func (c *ChatController) ChatStream(ctx context.Context, userID int, req types.ChatRequest) (chan string, chan error) {
 	errCh := make(chan error, 1)
 	ch := make(chan string)
@@ -79,6 +113,7 @@ func (c *ChatController) ChatStream(ctx context.Context, userID int, req types.C
 		close(errCh)
 		return ch, errCh
 	}
+	history, redactions := c.redactHistory(sessionID, history)
 	llmReq := map[string]interface{}{
 		"model":    "llama3:8b",
 		"messages": history,
@@ -119,7 +154,14 @@ func (c *ChatController) ChatStream(ctx context.Context, userID int, req types.C
 				_, err := c.chatDAO.SaveMessage(ctx2, sessionID, userID, "assistant", fullContent)
 				if err != nil {
 					errCh <- err
+					return
+				}
+				final := map[string]string{"done": "true", "session_id": sessionID}
+				if n := redactions.Total(); n > 0 {
+					final["redactions"] = strconv.Itoa(n)
 				}
+				b, _ := json.Marshal(final)
+				ch <- string(b)
 				return
 			}
 			delta := chunk.Message.Content
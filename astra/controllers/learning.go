@@ -2,17 +2,21 @@
 package controllers
 
 import (
+	"astra/astra/services/knowledge"
 	"astra/astra/sources/psql/dao"
 	"astra/astra/sources/psql/models"
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type LongTermController struct {
-	dao *dao.LongTermKnowledgeDAO
+	dao       *dao.LongTermKnowledgeDAO
+	knowledge *knowledge.Service
 }
 
 func NewLongTermController(dao *dao.LongTermKnowledgeDAO) *LongTermController {
-	return &LongTermController{dao: dao}
+	return &LongTermController{dao: dao, knowledge: knowledge.NewService(dao)}
 }
 
 func (c *LongTermController) GetAllLongTermKnowledgeByUser(ctx context.Context, userID int) ([]models.LongTermKnowledge, error) {
@@ -22,3 +26,19 @@ func (c *LongTermController) GetAllLongTermKnowledgeByUser(ctx context.Context,
 func (c *LongTermController) GetAllLongTermKnowledgeByUserAndType(ctx context.Context, userID int, knowledgeType string) ([]models.LongTermKnowledge, error) {
 	return c.dao.GetLongTermKnowledgeByKnowledgeType(ctx, userID, knowledgeType)
 }
+
+func (c *LongTermController) CreateLongTermKnowledge(ctx context.Context, entry *models.LongTermKnowledge, onDuplicate knowledge.DuplicatePolicy) (*knowledge.CreateResult, error) {
+	return c.knowledge.Create(ctx, entry, onDuplicate)
+}
+
+func (c *LongTermController) GetLongTermKnowledge(ctx context.Context, userID int, id uuid.UUID) (*models.LongTermKnowledge, error) {
+	return c.knowledge.Get(ctx, userID, id)
+}
+
+func (c *LongTermController) UpdateLongTermKnowledge(ctx context.Context, userID int, id uuid.UUID, u knowledge.Update) (*models.LongTermKnowledge, error) {
+	return c.knowledge.Update(ctx, userID, id, u)
+}
+
+func (c *LongTermController) DeleteLongTermKnowledge(ctx context.Context, userID int, id uuid.UUID) (*models.LongTermKnowledge, error) {
+	return c.knowledge.Delete(ctx, userID, id)
+}
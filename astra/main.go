@@ -1,9 +1,13 @@
 package main
 
 import (
+	"astra/astra/agents/actions"
+	"astra/astra/agents/configs"
 	"astra/astra/config"
 	"astra/astra/controllers"
 	"astra/astra/routes"
+	"astra/astra/services/llm"
+	"astra/astra/services/memory"
 	"astra/astra/sources/psql"
 	"astra/astra/sources/psql/dao"
 	"astra/astra/sources/storage"
@@ -22,6 +26,13 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// memoryBackfillInterval is how often new and changed memories are embedded.
+	memoryBackfillInterval = 10 * time.Minute
+	// mcpStartTimeout bounds connecting the configured MCP servers at startup.
+	mcpStartTimeout = 30 * time.Second
+)
+
 func main() {
 	logging.InitLogger()
 	cfg := config.LoadConfig()
@@ -38,17 +49,32 @@ func main() {
 	chatDAO := dao.NewChatMessageDAO(db.DB)
 	learningDAO := dao.NewLongTermKnowledgeDAO(db.DB)
 	noteDAO := dao.NewNoteDAO(db.DB)
+	executionDAO := dao.NewActionExecutionDAO(db.DB)
 	authCtrl := controllers.NewAuthController(userDAO, cfg)
 	userCtrl := controllers.NewUserController(userDAO)
 	chatCtrl := controllers.NewChatController(chatDAO)
 	learningCtrl := controllers.NewLongTermController(learningDAO)
 	notesCtrl := controllers.NewNotesController(noteDAO)
+	executionsCtrl := controllers.NewActionExecutionsController(executionDAO)
 	agentCtrl := controllers.NewAgentsController(db.DB)
 
 	healthCtrl := controllers.NewHealthController()
 
 	logging.AppLogger.Info("Started")
 
+	// Embed existing and new knowledge, notes and session summaries for semantic search.
+	memorySvc := memory.NewService(db.DB, llm.NewGPTClient(), llm.DefaultGPTEmbeddingModel)
+	backfillCtx, stopBackfill := context.WithCancel(context.Background())
+	defer stopBackfill()
+	go memorySvc.RunBackfill(backfillCtx, 0, memoryBackfillInterval)
+
+	// MCP servers are shared by every agent, so they start once here.
+	mcpCtx, mcpCancel := context.WithTimeout(context.Background(), mcpStartTimeout)
+	if err := actions.StartMCPServers(mcpCtx, configs.LoadConfig()); err != nil {
+		logging.ErrorLogger.Error("Failed to start MCP servers", zap.Error(err))
+	}
+	mcpCancel()
+
 	minioClient, err := storage.NewMinIOClient(cfg)
 	if err != nil {
 		logging.ErrorLogger.Error("minio connection error", zap.Error(err))
@@ -83,6 +109,7 @@ func main() {
 	r.Mount("/agents", routes.AgentRoutes(agentCtrl, cfg)) // Add agents route
 	r.Mount("/learning", routes.LongTermRoutes(learningCtrl, cfg))
 	r.Mount("/notes", routes.NotesRoutes(notesCtrl, cfg))
+	r.Mount("/executions", routes.ActionExecutionRoutes(executionsCtrl, cfg))
 	r.Mount("/test", routes.ScrapeRoutes(scrapeCtrl, cfg))
 
 	r.Mount("/health", routes.HealthRoutes(healthCtrl))
@@ -104,5 +131,6 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logging.ErrorLogger.Error("server shutdown error", zap.Error(err))
 	}
+	actions.CloseMCPServers()
 	logging.AppLogger.Info("server shutdown complete")
 }
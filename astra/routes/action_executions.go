@@ -0,0 +1,112 @@
+// astra/routes/action_executions.go
+package routes
+
+import (
+	"astra/astra/config"
+	"astra/astra/controllers"
+	"astra/astra/middlewares"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// errMissingUser is returned when an authenticated request carries no user id.
+var errMissingUser = errors.New("missing user")
+
+// executionQuery reads a history query from the URL: action, group, status, since,
+// until, session_id, run_id and limit.
+func executionQuery(r *http.Request) (controllers.ExecutionQuery, error) {
+	v := r.URL.Query()
+	q := controllers.ExecutionQuery{
+		Actions:   v.Get("action"),
+		Group:     v.Get("group"),
+		Status:    v.Get("status"),
+		Since:     v.Get("since"),
+		Until:     v.Get("until"),
+		SessionID: v.Get("session_id"),
+		RunID:     v.Get("run_id"),
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return q, errors.New("limit must be a number")
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// ActionExecutionRoutes serves the authenticated user's action execution history.
+func ActionExecutionRoutes(ctrl *controllers.ActionExecutionsController, cfg config.Config) chi.Router {
+	r := chi.NewRouter()
+	r.Group(func(gr chi.Router) {
+		gr.Use(middlewares.AuthMiddleware(cfg))
+
+		// List executions, newest first
+		gr.Get("/", handleJSON(func(r *http.Request) (any, int, error) {
+			userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+			if !ok || userID == 0 {
+				return nil, http.StatusUnauthorized, errMissingUser
+			}
+			q, err := executionQuery(r)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			f, err := q.Filter(userID, time.Now())
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			execs, err := ctrl.ListExecutions(r.Context(), f)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return execs, http.StatusOK, nil
+		}))
+
+		// Counts by action and status, e.g. ?status=failed for failures by action
+		gr.Get("/stats", handleJSON(func(r *http.Request) (any, int, error) {
+			userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+			if !ok || userID == 0 {
+				return nil, http.StatusUnauthorized, errMissingUser
+			}
+			q, err := executionQuery(r)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			f, err := q.Filter(userID, time.Now())
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			counts, err := ctrl.CountExecutions(r.Context(), f)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return counts, http.StatusOK, nil
+		}))
+
+		// Single execution with its params and result
+		gr.Get("/{id}", handleJSON(func(r *http.Request) (any, int, error) {
+			userID, ok := r.Context().Value(middlewares.UserIDKey).(int)
+			if !ok || userID == 0 {
+				return nil, http.StatusUnauthorized, errMissingUser
+			}
+			id, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			exec, err := ctrl.GetExecution(r.Context(), userID, id)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			if exec == nil {
+				return nil, http.StatusNotFound, errors.New("execution not found")
+			}
+			return exec, http.StatusOK, nil
+		}))
+	})
+	return r
+}
@@ -3,9 +3,11 @@ package routes
 import (
 	"astra/astra/config"
 	"astra/astra/controllers"
+	"astra/astra/middlewares"
 	"astra/astra/utils/logging"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/coder/websocket"
@@ -17,6 +19,27 @@ import (
 func AgentRoutes(ctrl *controllers.AgentsController, cfg config.Config) chi.Router {
 	r := chi.NewRouter()
 
+	r.Group(func(gr chi.Router) {
+		gr.Use(middlewares.AuthMiddleware(cfg))
+
+		// Actions with their JSON Schemas
+		gr.Get("/actions", handleJSON(func(r *http.Request) (any, int, error) {
+			userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+			return ctrl.ListActions(userID), http.StatusOK, nil
+		}))
+
+		gr.Get("/actions/{name}", handleJSON(func(r *http.Request) (any, int, error) {
+			userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+			name := chi.URLParam(r, "name")
+			for _, spec := range ctrl.ListActions(userID) {
+				if spec.Name == name {
+					return spec, http.StatusOK, nil
+				}
+			}
+			return nil, http.StatusNotFound, fmt.Errorf("action not found: %s", name)
+		}))
+	})
+
 	r.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		// Upgrade to WebSocket
 		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
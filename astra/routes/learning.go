@@ -5,11 +5,15 @@ import (
 	"astra/astra/config"
 	"astra/astra/controllers"
 	"astra/astra/middlewares"
+	"astra/astra/services/knowledge"
+	"astra/astra/sources/psql/models"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 func handleLongTermJSON(handler func(r *http.Request) (any, int, error)) http.HandlerFunc {
@@ -56,6 +60,93 @@ func LongTermRoutes(ctrl *controllers.LongTermController, cfg config.Config) chi
 			}
 			return longterms, http.StatusOK, nil
 		}))
+
+		// Write endpoints act on the entries of the authenticated user.
+		gr.Post("/", handleLongTermJSON(func(r *http.Request) (any, int, error) {
+			var req struct {
+				KnowledgeType string                    `json:"knowledge_type"`
+				KnowledgeBlob string                    `json:"knowledge_blob"`
+				SessionID     string                    `json:"session_id"`
+				Source        string                    `json:"source"`
+				Confidence    float64                   `json:"confidence"`
+				OnDuplicate   knowledge.DuplicatePolicy `json:"on_duplicate"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+			res, err := ctrl.CreateLongTermKnowledge(r.Context(), &models.LongTermKnowledge{
+				UserID:        userID,
+				KnowledgeType: req.KnowledgeType,
+				KnowledgeBlob: req.KnowledgeBlob,
+				SessionID:     req.SessionID,
+				Source:        req.Source,
+				Confidence:    req.Confidence,
+			}, req.OnDuplicate)
+			if err != nil {
+				return nil, knowledgeErrorStatus(err), err
+			}
+			switch res.Outcome {
+			case knowledge.OutcomeCreated:
+				return res, http.StatusCreated, nil
+			case knowledge.OutcomeRejected:
+				return res, http.StatusConflict, nil
+			}
+			return res, http.StatusOK, nil
+		}))
+
+		gr.Get("/{id}", handleLongTermJSON(func(r *http.Request) (any, int, error) {
+			id, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+			entry, err := ctrl.GetLongTermKnowledge(r.Context(), userID, id)
+			if err != nil {
+				return nil, knowledgeErrorStatus(err), err
+			}
+			return entry, http.StatusOK, nil
+		}))
+
+		gr.Put("/{id}", handleLongTermJSON(func(r *http.Request) (any, int, error) {
+			id, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			var req knowledge.Update
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+			entry, err := ctrl.UpdateLongTermKnowledge(r.Context(), userID, id, req)
+			if err != nil {
+				return nil, knowledgeErrorStatus(err), err
+			}
+			return entry, http.StatusOK, nil
+		}))
+
+		gr.Delete("/{id}", handleLongTermJSON(func(r *http.Request) (any, int, error) {
+			id, err := uuid.Parse(chi.URLParam(r, "id"))
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			userID, _ := r.Context().Value(middlewares.UserIDKey).(int)
+			entry, err := ctrl.DeleteLongTermKnowledge(r.Context(), userID, id)
+			if err != nil {
+				return nil, knowledgeErrorStatus(err), err
+			}
+			return entry, http.StatusOK, nil
+		}))
 	})
 	return r
 }
+
+func knowledgeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, knowledge.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, knowledge.ErrInvalidInput):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
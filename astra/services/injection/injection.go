@@ -0,0 +1,95 @@
+// Package injection spots instruction-like text in content the agent did not write,
+// such as scraped pages or files, which may be trying to steer the agent (prompt
+// injection). Findings are heuristics for labelling and gating, not proof.
+package injection
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxFindings caps the findings reported for one value.
+const MaxFindings = 10
+
+const excerptRadius = 40
+
+// Finding is one instruction-like passage.
+type Finding struct {
+	Pattern string `json:"pattern"`
+	Excerpt string `json:"excerpt"`
+}
+
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+|the\s+)?(?:previous|prior|above|earlier|preceding|your|system)\s+(?:instructions?|prompts?|rules|directions|guidelines)`)},
+	{"role_override", regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(?:a|an|in|the)\b|\bnew\s+(?:system\s+)?instructions\s*:|\bact\s+as\s+(?:an?\s+)?(?:unrestricted|jailbroken|dan\b)|\bdeveloper\s+mode\s+enabled`)},
+	{"prompt_markers", regexp.MustCompile(`(?im)<\|im_start\|>|<\|(?:system|assistant)\|>|\[/?INST\]|<<SYS>>|^\s*#{2,}\s*(?:system|instruction)s?\s*:?\s*$`)},
+	{"prompt_extraction", regexp.MustCompile(`(?i)\b(?:reveal|print|show|repeat)\s+(?:your|the)\s+(?:system\s+prompt|instructions|hidden\s+prompt)`)},
+	// Docs describe tools too ("use the run_command action"); only text addressed to an AI counts.
+	{"tool_directive", regexp.MustCompile("(?i)\\b(?:ai|assistants?|agents?|models?|llms?)\\b[^.\\n]{0,60}\\b(?:call|invoke|use|run|execute)\\s+(?:the\\s+)?`?[a-z]+(?:_[a-z]+)+`?\\s+(?:action|tool|function)\\b")},
+	{"hide_from_user", regexp.MustCompile(`(?i)\b(?:do\s+not|don't|never)\s+(?:tell|inform|mention|show|reveal)\s+(?:this\s+)?(?:to\s+)?(?:the\s+)?user\b`)},
+	{"shell_payload", regexp.MustCompile(`(?i)\b(?:curl|wget)\s[^|\n]{0,200}\|\s*(?:sudo\s+)?(?:ba|z)?sh\b|\brm\s+-rf\s+[/~]|\bbase64\s+(?:-d|--decode)\b[^|\n]{0,50}\|\s*(?:ba)?sh\b`)},
+	{"exfiltration", regexp.MustCompile(`(?i)\b(?:send|post|upload|exfiltrate|forward)\s+(?:the\s+|all\s+|your\s+|any\s+)?(?:api\s*keys?|secrets?|credentials|tokens?|\.env|environment\s+variables|passwords?)\b`)},
+}
+
+// Scan returns the instruction-like passages in text, at most one per pattern.
+func Scan(text string) []Finding {
+	var findings []Finding
+	for _, p := range patterns {
+		loc := p.re.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		findings = append(findings, Finding{Pattern: p.name, Excerpt: excerpt(text, loc[0], loc[1])})
+	}
+	return findings
+}
+
+// ScanValue scans every string in v, a decoded JSON value, and returns at most
+// MaxFindings findings, one per pattern.
+func ScanValue(v interface{}) []Finding {
+	var findings []Finding
+	seen := map[string]bool{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		if len(findings) >= MaxFindings {
+			return
+		}
+		switch v := v.(type) {
+		case string:
+			for _, f := range Scan(v) {
+				if !seen[f.Pattern] && len(findings) < MaxFindings {
+					seen[f.Pattern] = true
+					findings = append(findings, f)
+				}
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+	return findings
+}
+
+func excerpt(text string, start, end int) string {
+	from, to := max(start-excerptRadius, 0), min(end+excerptRadius, len(text))
+	s := strings.ToValidUTF8(text[from:to], "")
+	s = strings.Join(strings.Fields(s), " ")
+	if from > 0 {
+		s = "…" + s
+	}
+	if to < len(text) {
+		s += "…"
+	}
+	return s
+}
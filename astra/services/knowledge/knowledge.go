@@ -0,0 +1,268 @@
+// Package knowledge manages a user's long-term knowledge entries on top of the DAO:
+// ownership checks, provenance and near-duplicate detection on create.
+package knowledge
+
+import (
+	"astra/astra/sources/psql/dao"
+	"astra/astra/sources/psql/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DuplicatePolicy says what Create does when a near-duplicate of the same type exists.
+type DuplicatePolicy string
+
+const (
+	OnDuplicateMerge  DuplicatePolicy = "merge"  // Fold the new entry into the existing one
+	OnDuplicateReject DuplicatePolicy = "reject" // Keep the existing entry, store nothing
+	OnDuplicateAllow  DuplicatePolicy = "allow"  // Store the new entry anyway
+)
+
+// Outcomes reported by Create.
+const (
+	OutcomeCreated  = "created"
+	OutcomeMerged   = "merged"
+	OutcomeRejected = "rejected"
+)
+
+// DefaultDuplicateThreshold is the trigram similarity above which two entries of the
+// same type are treated as the same knowledge.
+const DefaultDuplicateThreshold = 0.9
+
+var (
+	ErrNotFound     = errors.New("knowledge not found")
+	ErrInvalidInput = errors.New("invalid knowledge")
+)
+
+// Service is the write path for long-term knowledge, shared by agent actions and REST.
+type Service struct {
+	dao       *dao.LongTermKnowledgeDAO
+	Threshold float64
+}
+
+func NewService(d *dao.LongTermKnowledgeDAO) *Service {
+	return &Service{dao: d, Threshold: DefaultDuplicateThreshold}
+}
+
+// CreateResult reports what Create did. DuplicateOf and Similarity are set when a
+// near-duplicate was found.
+type CreateResult struct {
+	Outcome     string                    `json:"outcome"`
+	Knowledge   *models.LongTermKnowledge `json:"knowledge"`
+	DuplicateOf *uuid.UUID                `json:"duplicate_of,omitempty"`
+	Similarity  float64                   `json:"similarity,omitempty"`
+}
+
+// Update lists the fields to change; nil fields are left as they are.
+type Update struct {
+	KnowledgeType *string  `json:"knowledge_type,omitempty"`
+	KnowledgeBlob *string  `json:"knowledge_blob,omitempty"`
+	Source        *string  `json:"source,omitempty"`
+	Confidence    *float64 `json:"confidence,omitempty"`
+}
+
+// Create stores entry for entry.UserID unless a near-duplicate of the same type exists,
+// in which case policy decides. An empty policy means merge.
+func (s *Service) Create(ctx context.Context, entry *models.LongTermKnowledge, policy DuplicatePolicy) (*CreateResult, error) {
+	entry.KnowledgeType = strings.TrimSpace(entry.KnowledgeType)
+	if entry.KnowledgeType == "" || strings.TrimSpace(entry.KnowledgeBlob) == "" {
+		return nil, fmt.Errorf("%w: knowledge_type and knowledge_blob are required", ErrInvalidInput)
+	}
+	if err := checkConfidence(entry.Confidence); err != nil {
+		return nil, err
+	}
+	if entry.Confidence == 0 {
+		entry.Confidence = 1
+	}
+	switch policy {
+	case "":
+		policy = OnDuplicateMerge
+	case OnDuplicateMerge, OnDuplicateReject, OnDuplicateAllow:
+	default:
+		return nil, fmt.Errorf("%w: on_duplicate must be merge, reject or allow", ErrInvalidInput)
+	}
+
+	if policy != OnDuplicateAllow {
+		existing, err := s.dao.GetLongTermKnowledgeByKnowledgeType(ctx, entry.UserID, entry.KnowledgeType)
+		if err != nil {
+			return nil, err
+		}
+		if dup, score := s.closest(entry.KnowledgeBlob, existing); dup != nil {
+			res := &CreateResult{Outcome: OutcomeRejected, Knowledge: dup, DuplicateOf: &dup.ID, Similarity: score}
+			if policy == OnDuplicateReject {
+				return res, nil
+			}
+			merged, err := s.merge(ctx, dup, entry)
+			if err != nil {
+				return nil, err
+			}
+			res.Outcome, res.Knowledge = OutcomeMerged, merged
+			return res, nil
+		}
+	}
+
+	if err := s.dao.CreateLongTermKnowledge(ctx, entry); err != nil {
+		return nil, err
+	}
+	return &CreateResult{Outcome: OutcomeCreated, Knowledge: entry}, nil
+}
+
+// closest returns the most similar entry at or above the threshold, if any.
+func (s *Service) closest(blob string, candidates []models.LongTermKnowledge) (*models.LongTermKnowledge, float64) {
+	var best *models.LongTermKnowledge
+	bestScore := 0.0
+	for i := range candidates {
+		if score := Similarity(blob, candidates[i].KnowledgeBlob); score >= s.Threshold && score > bestScore {
+			best, bestScore = &candidates[i], score
+		}
+	}
+	return best, bestScore
+}
+
+// merge keeps the longer text, the higher confidence and the newest provenance.
+func (s *Service) merge(ctx context.Context, existing, incoming *models.LongTermKnowledge) (*models.LongTermKnowledge, error) {
+	updates := map[string]interface{}{}
+	if len(strings.TrimSpace(incoming.KnowledgeBlob)) > len(strings.TrimSpace(existing.KnowledgeBlob)) {
+		updates["knowledge_blob"] = incoming.KnowledgeBlob
+	}
+	if incoming.Confidence > existing.Confidence {
+		updates["confidence"] = incoming.Confidence
+	}
+	if incoming.Source != "" {
+		updates["source"] = incoming.Source
+	}
+	if incoming.SessionID != "" {
+		updates["session_id"] = incoming.SessionID
+	}
+	if len(updates) == 0 {
+		return existing, nil
+	}
+	if err := s.dao.UpdateLongTermKnowledge(ctx, existing.ID, updates); err != nil {
+		return nil, err
+	}
+	return s.dao.GetLongTermKnowledgeByID(ctx, existing.ID)
+}
+
+// Get returns an entry of userID. Entries of other users are reported as ErrNotFound.
+func (s *Service) Get(ctx context.Context, userID int, id uuid.UUID) (*models.LongTermKnowledge, error) {
+	k, err := s.dao.GetLongTermKnowledgeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil || k.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return k, nil
+}
+
+// Update changes an entry of userID and returns it.
+func (s *Service) Update(ctx context.Context, userID int, id uuid.UUID, u Update) (*models.LongTermKnowledge, error) {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{}
+	if u.KnowledgeType != nil {
+		if strings.TrimSpace(*u.KnowledgeType) == "" {
+			return nil, fmt.Errorf("%w: knowledge_type must not be empty", ErrInvalidInput)
+		}
+		updates["knowledge_type"] = strings.TrimSpace(*u.KnowledgeType)
+	}
+	if u.KnowledgeBlob != nil {
+		if strings.TrimSpace(*u.KnowledgeBlob) == "" {
+			return nil, fmt.Errorf("%w: knowledge_blob must not be empty", ErrInvalidInput)
+		}
+		updates["knowledge_blob"] = *u.KnowledgeBlob
+	}
+	if u.Source != nil {
+		updates["source"] = *u.Source
+	}
+	if u.Confidence != nil {
+		if err := checkConfidence(*u.Confidence); err != nil {
+			return nil, err
+		}
+		updates["confidence"] = *u.Confidence
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("%w: nothing to update", ErrInvalidInput)
+	}
+	if err := s.dao.UpdateLongTermKnowledge(ctx, id, updates); err != nil {
+		return nil, err
+	}
+	return s.dao.GetLongTermKnowledgeByID(ctx, id)
+}
+
+// Delete removes an entry of userID and returns it as it was.
+func (s *Service) Delete(ctx context.Context, userID int, id uuid.UUID) (*models.LongTermKnowledge, error) {
+	k, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.dao.DeleteLongTermKnowledge(ctx, id); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func checkConfidence(c float64) error {
+	if c < 0 || c > 1 {
+		return fmt.Errorf("%w: confidence must be between 0 and 1", ErrInvalidInput)
+	}
+	return nil
+}
+
+// Similarity is the Dice coefficient of the character trigrams of both texts after
+// lowercasing and collapsing punctuation and whitespace: 1 for identical texts, 0 for
+// texts with no trigram in common.
+func Similarity(a, b string) float64 {
+	na, nb := normalize(a), normalize(b)
+	if na == nb {
+		return 1
+	}
+	ta, tb := trigrams(na), trigrams(nb)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for g, n := range ta {
+		if m, ok := tb[g]; ok {
+			common += min(n, m)
+		}
+	}
+	total := 0
+	for _, n := range ta {
+		total += n
+	}
+	for _, n := range tb {
+		total += n
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+func normalize(s string) string {
+	var sb strings.Builder
+	space := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			space = false
+		} else if !space {
+			sb.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func trigrams(s string) map[string]int {
+	runes := []rune(" " + s + " ")
+	grams := make(map[string]int)
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])]++
+	}
+	return grams
+}
@@ -0,0 +1,24 @@
+package knowledge
+
+import "testing"
+
+func TestSimilarity(t *testing.T) {
+	base := "Goroutines are lightweight threads managed by the Go runtime."
+	cases := []struct {
+		other string
+		dup   bool
+	}{
+		{base, true},
+		{"goroutines are lightweight threads, managed by the Go runtime", true},
+		{"Goroutines are lightweight threads that are managed by the Go runtime.", true},
+		{"Goroutines are lightweight threads managed by the Go scheduler.", false},
+		{"Channels let goroutines communicate safely.", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		score := Similarity(base, c.other)
+		if got := score >= DefaultDuplicateThreshold; got != c.dup {
+			t.Errorf("Similarity(%q) = %.2f, duplicate=%v, want %v", c.other, score, got, c.dup)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// astra/services/llm/embeddings.go
+package llm
+
+import (
+	httputils "astra/astra/utils/http"
+	"astra/astra/utils/logging"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	DefaultGPTEmbeddingModel    = "text-embedding-3-small"
+	DefaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// ErrEmbeddingsUnsupported is returned by clients whose provider has no embeddings API.
+var ErrEmbeddingsUnsupported = errors.New("embeddings are not supported by this provider")
+
+// EmbeddingRequest asks for one vector per input text. An empty Model means the
+// provider's default embedding model.
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type gptEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns the embeddings of req.Input, in input order.
+func (c *GPTClient) Embed(ctx context.Context, req EmbeddingRequest) ([][]float32, error) {
+	defer logging.LogDuration(ctx, "gpt_service_embed")()
+
+	if req.Model == "" {
+		req.Model = DefaultGPTEmbeddingModel
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.embeddingsURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GPT embeddings request failed: %s - %s", resp.Status, string(b))
+	}
+
+	var parsed gptEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode GPT embeddings response: %w", err)
+	}
+	vectors := make([][]float32, len(req.Input))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("GPT embeddings response has unexpected index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("GPT embeddings response is missing input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// Embed returns the embeddings of req.Input using Ollama's /embed endpoint.
+func (c *OllamaClient) Embed(ctx context.Context, req EmbeddingRequest) ([][]float32, error) {
+	defer logging.LogDuration(ctx, "llm_service_embed")()
+
+	if req.Model == "" {
+		req.Model = DefaultOllamaEmbeddingModel
+	}
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := httputils.PostJSON(c.baseURL+"/embed", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(req.Input) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(resp.Embeddings), len(req.Input))
+	}
+	return resp.Embeddings, nil
+}
+
+// Embed is not available on Groq.
+func (c *GroqClient) Embed(ctx context.Context, req EmbeddingRequest) ([][]float32, error) {
+	return nil, ErrEmbeddingsUnsupported
+}
@@ -0,0 +1,32 @@
+package llm
+
+import (
+	"astra/astra/utils/logging"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGPTClientEmbed_OrdersByIndex(t *testing.T) {
+	logging.InitLogger()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != DefaultGPTEmbeddingModel || len(req.Input) != 2 {
+			t.Errorf("unexpected request %+v (%v)", req, err)
+		}
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer srv.Close()
+
+	c := &GPTClient{apiKey: "test", embeddingsURL: srv.URL}
+	got, err := c.Embed(context.Background(), EmbeddingRequest{Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1, 0}, {0, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -18,8 +18,9 @@ import (
 )
 
 type GPTClient struct {
-	apiKey  string
-	baseURL string
+	apiKey        string
+	baseURL       string
+	embeddingsURL string
 }
 
 func NewGPTClient() *GPTClient {
@@ -50,8 +51,9 @@ func NewGPTClient() *GPTClient {
 	}
 
 	return &GPTClient{
-		apiKey:  apiKey,
-		baseURL: "https://api.openai.com/v1/chat/completions",
+		apiKey:        apiKey,
+		baseURL:       "https://api.openai.com/v1/chat/completions",
+		embeddingsURL: "https://api.openai.com/v1/embeddings",
 	}
 }
 
@@ -25,6 +25,7 @@ func NewOllamaClient() *OllamaClient {
 type LLMClient interface {
 	Run(ctx context.Context, req ChatRequest) (string, error)
 	RunStream(ctx context.Context, req ChatRequest) (<-chan string, error)
+	Embed(ctx context.Context, req EmbeddingRequest) ([][]float32, error)
 }
 
 func NewClient(provider string) LLMClient {
@@ -0,0 +1,506 @@
+package mcp
+
+import (
+	"astra/astra/utils/logging"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxMessageBytes  = 16 * 1024 * 1024
+	stdioStopTimeout = 3 * time.Second
+)
+
+// ErrClosed is returned for calls on a client whose server has gone away.
+var ErrClosed = errors.New("mcp server connection closed")
+
+// transport moves JSON-RPC messages to and from one server.
+type transport interface {
+	roundTrip(ctx context.Context, req *Message) (*Message, error)
+	notify(ctx context.Context, msg *Message) error
+	close() error
+	alive() bool
+}
+
+// Client talks to a single MCP server.
+type Client struct {
+	Name   string
+	Server InitializeResult // Filled in by Initialize
+	t      transport
+	nextID atomic.Int64
+}
+
+// NewStdioClient starts command as a child process and speaks newline-delimited
+// JSON-RPC over its stdin/stdout. env ("KEY=value" entries) is the complete
+// environment of the process; nothing is inherited. The process stderr goes to the
+// app log.
+func NewStdioClient(name, command string, args, env []string, dir string) (*Client, error) {
+	t, err := startStdio(name, command, args, env, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Name: name, t: t}, nil
+}
+
+// NewHTTPClient connects to a streamable HTTP endpoint such as http://localhost:3001/mcp.
+func NewHTTPClient(name, url string, headers map[string]string) *Client {
+	return &Client{Name: name, t: &httpTransport{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{},
+	}}
+}
+
+// Initialize performs the MCP handshake. It must be called before any other method.
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      Implementation{Name: "astra", Version: "1.0.0"},
+	}
+	if err := c.call(ctx, "initialize", params, &c.Server); err != nil {
+		return nil, fmt.Errorf("initialize %s: %w", c.Name, err)
+	}
+	if h, ok := c.t.(*httpTransport); ok {
+		h.setProtocolVersion(c.Server.ProtocolVersion)
+	}
+	if err := c.t.notify(ctx, &Message{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		return nil, fmt.Errorf("initialize %s: %w", c.Name, err)
+	}
+	return &c.Server, nil
+}
+
+// ListTools returns every tool the server offers, following pagination cursors.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var res ListToolsResult
+		if err := c.call(ctx, "tools/list", ListToolsParams{Cursor: cursor}, &res); err != nil {
+			return nil, err
+		}
+		tools = append(tools, res.Tools...)
+		if res.NextCursor == "" || res.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = res.NextCursor
+	}
+}
+
+// CallTool invokes a tool. Tool-level failures come back as a result with IsError set;
+// the error return is for protocol and transport problems.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	var res CallToolResult
+	if err := c.call(ctx, "tools/call", CallToolParams{Name: name, Arguments: args}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Alive reports whether the connection can still be used.
+func (c *Client) Alive() bool {
+	return c.t.alive()
+}
+
+// Close ends the session and, for stdio servers, stops the process.
+func (c *Client) Close() error {
+	return c.t.close()
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id := strconv.FormatInt(c.nextID.Add(1), 10)
+	resp, err := c.t.roundTrip(ctx, &Message{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: p})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+func idKey(id json.RawMessage) string {
+	return string(bytes.TrimSpace(id))
+}
+
+// ---------- stdio ----------
+
+type stdioTransport struct {
+	name    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *Message
+	done    chan struct{}
+	err     error
+}
+
+func startStdio(name, command string, args, env []string, dir string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start mcp server %s: %w", name, err)
+	}
+	t := &stdioTransport{
+		name:    name,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[string]chan *Message),
+		done:    make(chan struct{}),
+	}
+	go t.logStderr(stderr)
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+func (t *stdioTransport) logStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logging.AppLogger.Debug("mcp server stderr", zap.String("server", t.name), zap.String("line", scanner.Text()))
+	}
+}
+
+func (t *stdioTransport) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			logging.ErrorLogger.Warn("mcp: invalid message from server", zap.String("server", t.name), zap.Error(err))
+			continue
+		}
+		switch {
+		case msg.IsResponse():
+			t.mu.Lock()
+			ch, ok := t.pending[idKey(msg.ID)]
+			delete(t.pending, idKey(msg.ID))
+			t.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case len(msg.ID) > 0:
+			t.answerServerRequest(&msg)
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = ErrClosed
+	}
+	t.mu.Lock()
+	t.err = err
+	t.mu.Unlock()
+	close(t.done)
+	_ = t.cmd.Wait()
+}
+
+// answerServerRequest replies to server-initiated requests; Astra only supports ping.
+func (t *stdioTransport) answerServerRequest(req *Message) {
+	resp := &Message{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not supported by client: " + req.Method}
+	}
+	_ = t.write(resp)
+}
+
+func (t *stdioTransport) write(msg *Message) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+func (t *stdioTransport) roundTrip(ctx context.Context, req *Message) (*Message, error) {
+	if !t.alive() {
+		return nil, ErrClosed
+	}
+	ch := make(chan *Message, 1)
+	key := idKey(req.ID)
+	t.mu.Lock()
+	t.pending[key] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		return nil, t.err
+	case <-ctx.Done():
+		// Tell the server to stop working on it; the reply, if any, is dropped.
+		cancel, _ := json.Marshal(map[string]interface{}{"requestId": req.ID, "reason": ctx.Err().Error()})
+		_ = t.write(&Message{JSONRPC: "2.0", Method: "notifications/cancelled", Params: cancel})
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(_ context.Context, msg *Message) error {
+	return t.write(msg)
+}
+
+func (t *stdioTransport) alive() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// close closes stdin, which well-behaved servers treat as shutdown, and kills the
+// process if it has not exited shortly after.
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	select {
+	case <-t.done:
+	case <-time.After(stdioStopTimeout):
+		_ = t.cmd.Process.Kill()
+		<-t.done
+	}
+	return nil
+}
+
+// ---------- streamable HTTP ----------
+
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+	closed          bool
+}
+
+func (t *httpTransport) setProtocolVersion(v string) {
+	t.mu.Lock()
+	t.protocolVersion = v
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set("Mcp-Protocol-Version", t.protocolVersion)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+func (t *httpTransport) post(ctx context.Context, msg *Message) (*http.Response, error) {
+	if !t.alive() {
+		return nil, ErrClosed
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if sid := resp.Header.Get("Mcp-Session-Id"); sid != "" {
+		t.mu.Lock()
+		t.sessionID = sid
+		t.mu.Unlock()
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("mcp http %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, req *Message) (*Message, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	want := idKey(req.ID)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readSSEResponse(resp.Body, want)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageBytes))
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	var msgs []Message
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, fmt.Errorf("invalid mcp response: %w", err)
+		}
+	} else {
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, fmt.Errorf("invalid mcp response: %w", err)
+		}
+		msgs = []Message{msg}
+	}
+	for i := range msgs {
+		if msgs[i].IsResponse() && idKey(msgs[i].ID) == want {
+			return &msgs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("mcp response for request %s missing", want)
+}
+
+// readSSEResponse reads server-sent events until the response to request id arrives.
+func readSSEResponse(r io.Reader, id string) (*Message, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	var data strings.Builder
+	dispatch := func() *Message {
+		defer data.Reset()
+		if data.Len() == 0 {
+			return nil
+		}
+		var msg Message
+		if err := json.Unmarshal([]byte(data.String()), &msg); err != nil {
+			return nil
+		}
+		if msg.IsResponse() && idKey(msg.ID) == id {
+			return &msg
+		}
+		return nil
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if msg := dispatch(); msg != nil {
+				return msg, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if msg := dispatch(); msg != nil {
+		return msg, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("mcp event stream ended before response to request %s", id)
+}
+
+func (t *httpTransport) notify(ctx context.Context, msg *Message) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func (t *httpTransport) alive() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.closed
+}
+
+// close ends the server-side session when the server issued one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	t.closed = true
+	sid := t.sessionID
+	t.mu.Unlock()
+	if sid == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"astra/astra/utils/logging"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary double as a stdio MCP server.
+func TestMain(m *testing.M) {
+	if os.Getenv("ASTRA_FAKE_MCP_SERVER") == "1" {
+		runFakeStdioServer()
+		os.Exit(0)
+	}
+	logging.InitLogger()
+	os.Exit(m.Run())
+}
+
+func runFakeStdioServer() {
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var msg Message
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil || len(msg.ID) == 0 {
+			continue
+		}
+		_ = out.Encode(fakeServerReply(&msg))
+	}
+}
+
+// fakeServerReply implements a two-page tools/list and an "echo" tool.
+func fakeServerReply(req *Message) *Message {
+	resp := &Message{JSONRPC: "2.0", ID: req.ID}
+	result := func(v interface{}) {
+		resp.Result, _ = json.Marshal(v)
+	}
+	switch req.Method {
+	case "initialize":
+		result(InitializeResult{ProtocolVersion: ProtocolVersion, ServerInfo: Implementation{Name: "fake", Version: "0.1"}})
+	case "tools/list":
+		var p ListToolsParams
+		_ = json.Unmarshal(req.Params, &p)
+		if p.Cursor == "" {
+			result(ListToolsResult{Tools: []Tool{{Name: "echo", InputSchema: map[string]interface{}{"type": "object"}}}, NextCursor: "page2"})
+		} else {
+			result(ListToolsResult{Tools: []Tool{{Name: "fail"}}})
+		}
+	case "tools/call":
+		var p CallToolParams
+		_ = json.Unmarshal(req.Params, &p)
+		if p.Name == "fail" {
+			result(CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: "boom"}}})
+		} else {
+			result(CallToolResult{Content: []Content{{Type: "text", Text: fmt.Sprint(p.Arguments["text"])}}})
+		}
+	default:
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: req.Method}
+	}
+	return resp
+}
+
+func exerciseClient(t *testing.T, c *Client) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := c.Initialize(ctx)
+	if err != nil || info.ServerInfo.Name != "fake" {
+		t.Fatalf("initialize: %+v, %v", info, err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 2 || tools[1].Name != "fail" {
+		t.Fatalf("expected both pages of tools, got %+v (%v)", tools, err)
+	}
+	res, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "hi"})
+	if err != nil || res.IsError || res.Content[0].Text != "hi" {
+		t.Errorf("unexpected echo result: %+v (%v)", res, err)
+	}
+	res, err = c.CallTool(ctx, "fail", nil)
+	if err != nil || !res.IsError {
+		t.Errorf("expected a tool error result, got %+v (%v)", res, err)
+	}
+	if err := c.call(ctx, "resources/list", nil, nil); err == nil {
+		t.Errorf("expected unknown methods to return an RPC error")
+	}
+}
+
+func TestStdioClient(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewStdioClient("fake", exe, []string{"-test.run=^$"}, []string{"ASTRA_FAKE_MCP_SERVER=1"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exerciseClient(t, c)
+	if err := c.Close(); err != nil || c.Alive() {
+		t.Errorf("expected the server process to stop, alive=%v err=%v", c.Alive(), err)
+	}
+	if _, err := c.ListTools(context.Background()); err != ErrClosed {
+		t.Errorf("expected ErrClosed after close, got %v", err)
+	}
+}
+
+func TestHTTPClient_SSEAndSession(t *testing.T) {
+	deleted := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = r.Header.Get("Mcp-Session-Id") == "sess-1"
+			return
+		}
+		var msg Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		if msg.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+		} else if r.Header.Get("Mcp-Session-Id") != "sess-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		if len(msg.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		reply, _ := json.Marshal(fakeServerReply(&msg))
+		if msg.Method == "tools/call" {
+			// Stream a progress notification before the response.
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", reply)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(reply)
+	}))
+	defer srv.Close()
+
+	c := NewHTTPClient("fake", srv.URL, map[string]string{"Authorization": "Bearer x"})
+	exerciseClient(t, c)
+	if err := c.Close(); err != nil || !deleted {
+		t.Errorf("expected the session to be deleted on close (err=%v)", err)
+	}
+}
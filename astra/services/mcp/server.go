@@ -0,0 +1,308 @@
+package mcp
+
+import (
+	"astra/astra/utils/logging"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ErrElicitationUnsupported is returned by Elicit when the client did not declare
+// the elicitation capability, so the server cannot ask the user anything.
+var ErrElicitationUnsupported = errors.New("mcp client does not support elicitation")
+
+// ToolHandler runs one tools/call request. Tool failures belong in the result
+// (IsError); a returned error is reported to the client as a JSON-RPC error.
+type ToolHandler func(ctx context.Context, args map[string]interface{}) (*CallToolResult, error)
+
+// ResourceProvider lists and reads the resources a server exposes.
+type ResourceProvider interface {
+	ListResources(ctx context.Context) ([]Resource, error)
+	ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error)
+}
+
+// Server answers MCP requests over a single stdio connection. Register tools and
+// resources before calling ServeStdio.
+type Server struct {
+	info         Implementation
+	Instructions string
+
+	tools     []Tool
+	handlers  map[string]ToolHandler
+	resources ResourceProvider
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	mu         sync.Mutex
+	clientCaps map[string]interface{}
+	inflight   map[string]context.CancelFunc
+	pending    map[string]chan *Message
+	nextID     int64
+}
+
+// NewServer creates a server that identifies itself as name/version.
+func NewServer(name, version string) *Server {
+	return &Server{
+		info:     Implementation{Name: name, Version: version},
+		handlers: make(map[string]ToolHandler),
+		inflight: make(map[string]context.CancelFunc),
+		pending:  make(map[string]chan *Message),
+	}
+}
+
+// AddTool registers a tool; tools are listed in registration order.
+func (s *Server) AddTool(tool Tool, handler ToolHandler) {
+	if tool.InputSchema == nil {
+		tool.InputSchema = map[string]interface{}{"type": "object"}
+	}
+	if _, exists := s.handlers[tool.Name]; !exists {
+		s.tools = append(s.tools, tool)
+	}
+	s.handlers[tool.Name] = handler
+}
+
+// SetResources installs the provider behind resources/list and resources/read.
+func (s *Server) SetResources(p ResourceProvider) {
+	s.resources = p
+}
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes replies to w
+// until r is exhausted or ctx is cancelled. Requests are handled concurrently so a
+// long tool call does not block pings, cancellations or elicitation replies.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.out = w
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+		for scanner.Scan() {
+			line := append([]byte(nil), bytes.TrimSpace(scanner.Bytes())...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-scanErr:
+			return err
+		case line = <-lines:
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			_ = s.write(&Message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: err.Error()}})
+			continue
+		}
+		switch {
+		case msg.IsResponse():
+			s.mu.Lock()
+			ch, ok := s.pending[idKey(msg.ID)]
+			delete(s.pending, idKey(msg.ID))
+			s.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case len(msg.ID) > 0:
+			reqCtx, reqCancel := context.WithCancel(ctx)
+			s.mu.Lock()
+			s.inflight[idKey(msg.ID)] = reqCancel
+			s.mu.Unlock()
+			wg.Add(1)
+			go func(req Message) {
+				defer wg.Done()
+				defer func() {
+					s.mu.Lock()
+					delete(s.inflight, idKey(req.ID))
+					s.mu.Unlock()
+					reqCancel()
+				}()
+				_ = s.write(s.handle(reqCtx, &req))
+			}(msg)
+		default:
+			s.handleNotification(&msg)
+		}
+	}
+}
+
+func (s *Server) handleNotification(msg *Message) {
+	if msg.Method != "notifications/cancelled" {
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(msg.Params, &p) != nil {
+		return
+	}
+	s.mu.Lock()
+	if cancel, ok := s.inflight[idKey(p.RequestID)]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+}
+
+// handle answers one request. It never returns nil.
+func (s *Server) handle(ctx context.Context, req *Message) *Message {
+	resp := &Message{JSONRPC: "2.0", ID: req.ID}
+	result, err := s.dispatch(ctx, req)
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Result = nil
+		resp.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+	}
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, req *Message) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		var p InitializeParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.mu.Lock()
+		s.clientCaps = p.Capabilities
+		s.mu.Unlock()
+		logging.AppLogger.Info("mcp client connected",
+			zap.String("client", p.ClientInfo.Name),
+			zap.String("client_version", p.ClientInfo.Version),
+			zap.String("protocol", p.ProtocolVersion),
+		)
+		caps := map[string]interface{}{"tools": map[string]interface{}{}}
+		if s.resources != nil {
+			caps["resources"] = map[string]interface{}{}
+		}
+		return InitializeResult{ProtocolVersion: ProtocolVersion, Capabilities: caps, ServerInfo: s.info, Instructions: s.Instructions}, nil
+
+	case "ping":
+		return struct{}{}, nil
+
+	case "tools/list":
+		return ListToolsResult{Tools: s.tools}, nil
+
+	case "tools/call":
+		var p CallToolParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		handler, ok := s.handlers[p.Name]
+		if !ok {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + p.Name}
+		}
+		return handler(ctx, p.Arguments)
+
+	case "resources/list":
+		if s.resources == nil {
+			break
+		}
+		resources, err := s.resources.ListResources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ListResourcesResult{Resources: resources}, nil
+
+	case "resources/read":
+		if s.resources == nil {
+			break
+		}
+		var p ReadResourceParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.resources.ReadResource(ctx, p.URI)
+	}
+	return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// Elicit asks the user a question through the client and waits for the answer.
+// It fails with ErrElicitationUnsupported when the client cannot show the prompt.
+func (s *Server) Elicit(ctx context.Context, message string, schema map[string]interface{}) (*ElicitResult, error) {
+	s.mu.Lock()
+	_, supported := s.clientCaps["elicitation"]
+	s.nextID++
+	id := json.RawMessage(fmt.Sprintf(`"astra-%d"`, s.nextID))
+	ch := make(chan *Message, 1)
+	if supported {
+		s.pending[idKey(id)] = ch
+	}
+	s.mu.Unlock()
+	if !supported {
+		return nil, ErrElicitationUnsupported
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, idKey(id))
+		s.mu.Unlock()
+	}()
+
+	params, err := json.Marshal(ElicitParams{Message: message, RequestedSchema: schema})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(&Message{JSONRPC: "2.0", ID: id, Method: "elicitation/create", Params: params}); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		var res ElicitResult
+		if err := json.Unmarshal(resp.Result, &res); err != nil {
+			return nil, fmt.Errorf("decode elicitation result: %w", err)
+		}
+		return &res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Server) write(msg *Message) error {
+	if s.out == nil {
+		return ErrClosed
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err = s.out.Write(append(b, '\n'))
+	return err
+}
+
+func invalidParams(err error) error {
+	return &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+}
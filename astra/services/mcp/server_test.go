@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+// pipeSession drives a Server over in-memory pipes the way a stdio client would.
+type pipeSession struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *bufio.Scanner
+}
+
+func startPipeSession(t *testing.T, srv *Server) *pipeSession {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- srv.ServeStdio(context.Background(), inR, outW) }()
+	t.Cleanup(func() {
+		_ = inW.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("server did not stop after stdin closed")
+		}
+		_ = outW.Close()
+	})
+	return &pipeSession{t: t, in: inW, out: bufio.NewScanner(outR)}
+}
+
+func (p *pipeSession) send(msg string) {
+	p.t.Helper()
+	if _, err := io.WriteString(p.in, msg+"\n"); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p *pipeSession) next() *Message {
+	p.t.Helper()
+	if !p.out.Scan() {
+		p.t.Fatalf("server closed output: %v", p.out.Err())
+	}
+	var msg Message
+	if err := json.Unmarshal(p.out.Bytes(), &msg); err != nil {
+		p.t.Fatalf("invalid server message %q: %v", p.out.Text(), err)
+	}
+	return &msg
+}
+
+func TestServer_ToolsAndElicitation(t *testing.T) {
+	srv := NewServer("astra-test", "1.0")
+	srv.AddTool(Tool{Name: "echo"}, func(_ context.Context, args map[string]interface{}) (*CallToolResult, error) {
+		return &CallToolResult{Content: []Content{{Type: "text", Text: args["text"].(string)}}}, nil
+	})
+	srv.AddTool(Tool{Name: "guarded"}, func(ctx context.Context, _ map[string]interface{}) (*CallToolResult, error) {
+		res, err := srv.Elicit(ctx, "allow?", map[string]interface{}{"type": "object"})
+		if err != nil {
+			return &CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: err.Error()}}}, nil
+		}
+		return &CallToolResult{Content: []Content{{Type: "text", Text: res.Action}}}, nil
+	})
+	s := startPipeSession(t, srv)
+
+	s.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{"elicitation":{}},"clientInfo":{"name":"t","version":"0"}}}`)
+	var init InitializeResult
+	if resp := s.next(); resp.Error != nil || json.Unmarshal(resp.Result, &init) != nil || init.ServerInfo.Name != "astra-test" {
+		t.Fatalf("unexpected initialize reply: %+v", resp)
+	}
+	if _, ok := init.Capabilities["resources"]; ok {
+		t.Errorf("resources capability advertised without a provider")
+	}
+
+	s.send(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	var list ListToolsResult
+	if resp := s.next(); json.Unmarshal(resp.Result, &list) != nil || len(list.Tools) != 2 || list.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("unexpected tools/list reply: %s", resp.Result)
+	}
+
+	s.send(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	var res CallToolResult
+	if resp := s.next(); json.Unmarshal(resp.Result, &res) != nil || res.Content[0].Text != "hi" {
+		t.Errorf("unexpected echo reply: %s", resp.Result)
+	}
+
+	// The guarded tool asks the client before answering the call.
+	s.send(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"guarded"}}`)
+	req := s.next()
+	if req.Method != "elicitation/create" || len(req.ID) == 0 {
+		t.Fatalf("expected an elicitation request, got %+v", req)
+	}
+	s.send(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":{"action":"accept","content":{"approve":true}}}`)
+	if resp := s.next(); json.Unmarshal(resp.Result, &res) != nil || res.Content[0].Text != "accept" {
+		t.Errorf("unexpected guarded reply: %s", resp.Result)
+	}
+
+	s.send(`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`)
+	if resp := s.next(); resp.Error == nil || resp.Error.Code != CodeMethodNotFound {
+		t.Errorf("expected method not found without resources, got %+v", resp)
+	}
+}
+
+func TestServer_ElicitUnsupported(t *testing.T) {
+	srv := NewServer("astra-test", "1.0")
+	s := startPipeSession(t, srv)
+	s.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`)
+	s.next()
+	if _, err := srv.Elicit(context.Background(), "allow?", nil); err != ErrElicitationUnsupported {
+		t.Errorf("expected ErrElicitationUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package memory provides semantic search over a user's long-term knowledge, notes and
+// session summaries. Rows are embedded in the background (RunBackfill); vectors live
+// in Postgres and are scored with cosine similarity in Go.
+package memory
+
+import (
+	"astra/astra/services/llm"
+	"astra/astra/services/redact"
+	"astra/astra/sources/psql/dao"
+	"astra/astra/sources/psql/models"
+	"astra/astra/utils/logging"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	backfillBatchSize = 32
+	maxEmbedRunes     = 8000 // Keeps inputs well inside embedding model limits
+)
+
+// Sources lists every memory source, in the order they are backfilled.
+var Sources = []string{models.MemorySourceKnowledge, models.MemorySourceNote, models.MemorySourceSession}
+
+// Embedder is the part of llm.LLMClient the service needs.
+type Embedder interface {
+	Embed(ctx context.Context, req llm.EmbeddingRequest) ([][]float32, error)
+}
+
+// Hit is one search result.
+type Hit struct {
+	Source    string    `json:"source"` // knowledge, note or session
+	ID        uuid.UUID `json:"id"`
+	Score     float64   `json:"score"`
+	Title     string    `json:"title,omitempty"` // Knowledge type, note title or session ID
+	Content   string    `json:"content"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Service struct {
+	embedder   Embedder
+	model      string
+	embeddings *dao.MemoryEmbeddingDAO
+	knowledge  *dao.LongTermKnowledgeDAO
+	notes      *dao.NoteDAO
+	summaries  *dao.SessionSummaryDAO
+	redactor   *redact.Redactor
+}
+
+// NewService returns a service embedding with model. Vectors of other models are
+// ignored by Search and replaced by Backfill.
+func NewService(db *gorm.DB, embedder Embedder, model string) *Service {
+	return &Service{
+		embedder:   embedder,
+		model:      model,
+		embeddings: dao.NewMemoryEmbeddingDAO(db),
+		knowledge:  dao.NewLongTermKnowledgeDAO(db),
+		notes:      dao.NewNoteDAO(db),
+		summaries:  dao.NewSessionSummaryDAO(db),
+		redactor:   redact.New(),
+	}
+}
+
+// SetRedactor replaces the redactor that removes secrets from texts before they are
+// sent to the embedding API, e.g. with an agent's, which also knows its configured secrets.
+func (s *Service) SetRedactor(r *redact.Redactor) {
+	s.redactor = r
+}
+
+// Search returns up to k memories of userID most similar to query, best first. Hits
+// scoring below minScore are dropped; sources limits the search to some source types.
+// Only rows embedded by the background backfill are found; only the query is embedded.
+func (s *Service) Search(ctx context.Context, userID int, query string, k int, sources []string, minScore float64) ([]Hit, error) {
+	for _, src := range sources {
+		if !isSource(src) {
+			return nil, fmt.Errorf("unknown memory source %q", src)
+		}
+	}
+	vectors, err := s.embedder.Embed(ctx, llm.EmbeddingRequest{Model: s.model, Input: []string{s.embedText(query)}})
+	if err != nil {
+		return nil, err
+	}
+	embeddings, err := s.embeddings.ListMemoryEmbeddingsByUser(ctx, userID, s.model, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		e     *models.MemoryEmbedding
+		score float64
+	}
+	ranked := make([]scored, 0, len(embeddings))
+	for i := range embeddings {
+		if score := cosine(vectors[0], embeddings[i].Embedding); score >= minScore {
+			ranked = append(ranked, scored{&embeddings[i], score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	hits := make([]Hit, 0, k)
+	for _, r := range ranked {
+		if len(hits) == k {
+			break
+		}
+		hit, err := s.resolve(ctx, userID, r.e.SourceType, r.e.SourceID)
+		if err != nil {
+			return nil, err
+		}
+		if hit == nil {
+			continue // Deleted since it was embedded
+		}
+		hit.Score = r.score
+		hits = append(hits, *hit)
+	}
+	return hits, nil
+}
+
+// resolve loads the source row of an embedding; nil when it is gone or not the user's.
+func (s *Service) resolve(ctx context.Context, userID int, source string, id uuid.UUID) (*Hit, error) {
+	switch source {
+	case models.MemorySourceKnowledge:
+		k, err := s.knowledge.GetLongTermKnowledgeByID(ctx, id)
+		if err != nil || k == nil || k.UserID != userID {
+			return nil, err
+		}
+		return &Hit{Source: source, ID: id, Title: k.KnowledgeType, Content: k.KnowledgeBlob, UpdatedAt: k.UpdatedAt}, nil
+	case models.MemorySourceNote:
+		n, err := s.notes.GetNoteByID(ctx, id)
+		if err != nil || n == nil || n.UserID != userID {
+			return nil, err
+		}
+		return &Hit{Source: source, ID: id, Title: n.Title, Content: n.Content, UpdatedAt: n.UpdatedAt}, nil
+	case models.MemorySourceSession:
+		ss, err := s.summaries.GetSessionSummaryByID(ctx, id)
+		if err != nil || ss == nil || ss.UserID != userID {
+			return nil, err
+		}
+		return &Hit{Source: source, ID: id, Title: ss.SessionID, Content: ss.Summary, UpdatedAt: ss.UpdatedAt}, nil
+	}
+	return nil, nil
+}
+
+// Backfill embeds every row that has no up-to-date embedding and returns how many it
+// embedded. userID 0 means all users.
+func (s *Service) Backfill(ctx context.Context, userID int) (int, error) {
+	total := 0
+	for _, source := range Sources {
+		done := map[uuid.UUID]bool{}
+		for {
+			batch, err := s.embeddings.FindStaleMemorySources(ctx, source, s.model, userID, backfillBatchSize)
+			if err != nil {
+				return total, err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			inputs := make([]string, len(batch))
+			for i, row := range batch {
+				if done[row.ID] {
+					return total, fmt.Errorf("%s %s is still stale after embedding it", source, row.ID)
+				}
+				inputs[i] = s.embedText(row.Text)
+			}
+			vectors, err := s.embedder.Embed(ctx, llm.EmbeddingRequest{Model: s.model, Input: inputs})
+			if err != nil {
+				return total, err
+			}
+			for i, row := range batch {
+				err := s.embeddings.UpsertMemoryEmbedding(ctx, &models.MemoryEmbedding{
+					UserID:          row.UserID,
+					SourceType:      source,
+					SourceID:        row.ID,
+					SourceUpdatedAt: row.UpdatedAt,
+					Model:           s.model,
+					Embedding:       vectors[i],
+				})
+				if err != nil {
+					return total, err
+				}
+				done[row.ID] = true
+				total++
+			}
+			if len(batch) < backfillBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+// RunBackfill embeds new and changed rows of userID (0 for all users) every interval,
+// and removes embeddings of deleted rows, until ctx is done.
+func (s *Service) RunBackfill(ctx context.Context, userID int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := s.Backfill(ctx, userID)
+		if err != nil && ctx.Err() == nil {
+			logging.ErrorLogger.Error("Memory backfill failed", zap.Int("embedded", n), zap.Error(err))
+		} else if n > 0 {
+			logging.AppLogger.Info("Memory backfill", zap.Int("embedded", n))
+		}
+		if _, err := s.embeddings.DeleteOrphanMemoryEmbeddings(ctx); err != nil && ctx.Err() == nil {
+			logging.ErrorLogger.Error("Removing orphan memory embeddings failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func isSource(s string) bool {
+	for _, src := range Sources {
+		if s == src {
+			return true
+		}
+	}
+	return false
+}
+
+// embedText removes secrets from an embedding input, truncates it when overly long and
+// replaces it when empty, which embedding APIs reject.
+func (s *Service) embedText(text string) string {
+	text, counts := s.redactor.Redact(text)
+	if counts.Total() > 0 {
+		logging.AppLogger.Info("Redacted secrets from embedding input", zap.Any("redactions", counts))
+	}
+	if r := []rune(text); len(r) > maxEmbedRunes {
+		text = string(r[:maxEmbedRunes])
+	}
+	if text == "" {
+		return "(empty)"
+	}
+	return text
+}
+
+// cosine is the cosine similarity of a and b; 0 when their lengths differ or either is zero.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
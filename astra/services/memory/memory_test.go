@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"astra/astra/services/redact"
+	"astra/astra/utils/logging"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	cases := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{2, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 3}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+	}
+	for _, c := range cases {
+		if got := cosine(c.a, c.b); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("cosine(%v, %v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestEmbedText_RedactsSecrets(t *testing.T) {
+	logging.InitLogger()
+	r := redact.New()
+	r.AddValue("docs_token", "dt-4f9a1c7e2b")
+	s := &Service{redactor: r}
+	got := s.embedText("call the docs API with dt-4f9a1c7e2b")
+	if strings.Contains(got, "dt-4f9a1c7e2b") || !strings.Contains(got, redact.Placeholder("docs_token")) {
+		t.Errorf("expected the secret to be redacted, got %q", got)
+	}
+	if got := s.embedText(""); got != "(empty)" {
+		t.Errorf("expected an empty input to be replaced, got %q", got)
+	}
+}
@@ -0,0 +1,206 @@
+// Package redact removes secrets from text before it leaves the process, e.g. in LLM
+// requests: API keys, JWTs, private keys, passwords in connection strings, values
+// assigned to secret-looking names, high-entropy tokens and the values of the process's
+// own secret environment variables. Redacted spans become placeholders naming what was
+// removed, so the model can still reason about the text.
+package redact
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Counts is the number of redactions per detector.
+type Counts map[string]int
+
+// Total returns the number of redactions of every kind.
+func (c Counts) Total() int {
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// Add adds other's redactions to c.
+func (c Counts) Add(other Counts) {
+	for k, v := range other {
+		c[k] += v
+	}
+}
+
+// Kinds of redaction, used in placeholders and Counts.
+const (
+	KindEnv              = "env"
+	KindPrivateKey       = "private_key"
+	KindJWT              = "jwt"
+	KindConnectionString = "connection_string"
+	KindAPIKey           = "api_key"
+	KindBearerToken      = "bearer_token"
+	KindAssignment       = "secret_assignment"
+	KindHighEntropy      = "high_entropy"
+)
+
+// Placeholder is what a redacted span of kind is replaced with.
+func Placeholder(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	group int               // Submatch to replace; 0 replaces the whole match
+	check func(string) bool // Optional test the span must also pass
+}
+
+// Detectors run in order; earlier ones win where spans overlap.
+var detectors = []detector{
+	{kind: KindPrivateKey, re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z0-9 ]*PRIVATE KEY-----|$)`)},
+	{kind: KindJWT, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)},
+	{kind: KindConnectionString, re: regexp.MustCompile(`(?i)\b(?:postgres(?:ql)?|mysql|mariadb|mongodb(?:\+srv)?|rediss?|amqps?|sqlserver|mssql)://[^\s:/@"']+:([^\s@/"']+)@`), group: 1},
+	{kind: KindAPIKey, re: regexp.MustCompile(`\b(?:sk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35}|[sr]k_live_[0-9A-Za-z]{20,}|glpat-[0-9A-Za-z_-]{20,})`)},
+	{kind: KindBearerToken, re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`), group: 1},
+	{kind: KindAssignment, re: regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:secret|token|passw(?:or)?d|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{6,})`), group: 1, check: literalValue},
+	{kind: KindHighEntropy, re: regexp.MustCompile(`[A-Za-z0-9+_=-]{24,}`), check: highEntropy},
+}
+
+// expression spots values that are code rather than a literal, e.g. os.Getenv("X") or
+// cfg.JWTSecret, so redacting source code does not break it.
+var expression = regexp.MustCompile(`[(){}\[\]<>$]|^[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+func literalValue(v string) bool {
+	if expression.MatchString(v) || strings.HasPrefix(v, "[REDACTED") {
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "string", "required", "optional", "secret", "password", "changeme", "undefined":
+		return false
+	}
+	return true
+}
+
+// highEntropy accepts random-looking tokens: letters and digits mixed with an entropy
+// close to the maximum for their length. Hex strings (hashes, UUIDs) are left alone.
+func highEntropy(s string) bool {
+	hasLetter, hasDigit, hex := false, false, true
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			hasLetter = true
+			if !(r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+				hex = false
+			}
+		case r != '-':
+			hex = false
+		}
+	}
+	if !hasLetter || !hasDigit || hex {
+		return false
+	}
+	return entropy(s) >= 0.85*math.Log2(float64(min(len(s), 64)))
+}
+
+// entropy returns the Shannon entropy of s in bits per byte.
+func entropy(s string) float64 {
+	var freq [256]int
+	for i := 0; i < len(s); i++ {
+		freq[s[i]]++
+	}
+	var h float64
+	for _, n := range freq {
+		if n > 0 {
+			p := float64(n) / float64(len(s))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// secretEnvName matches environment variables whose values are secrets.
+var secretEnvName = regexp.MustCompile(`(?i)secret|token|passw(?:or)?d|api_?key|access_?key|private_?key|credential|_dsn$|database_url`)
+
+// Redactor removes secrets from text. The zero value only runs the detectors.
+type Redactor struct {
+	values []secretValue // Longest first, so a value containing another is replaced whole
+}
+
+type secretValue struct {
+	name  string
+	value string
+}
+
+// New returns a Redactor that also removes the values of the process's secret
+// environment variables, as loaded at the time of the call.
+func New() *Redactor {
+	r := &Redactor{}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if secretEnvName.MatchString(name) {
+			r.AddValue(name, value)
+		}
+	}
+	return r
+}
+
+// AddValue makes r remove value, naming it after name. Values too short or too common
+// to be told apart from ordinary text are ignored.
+func (r *Redactor) AddValue(name, value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < 8 || len(value) < 12 && strings.Trim(value, "abcdefghijklmnopqrstuvwxyz") == "" {
+		return
+	}
+	for _, v := range r.values {
+		if v.value == value {
+			return
+		}
+	}
+	r.values = append(r.values, secretValue{name: name, value: value})
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i].value) > len(r.values[j].value) })
+}
+
+// Redact returns s with every secret found replaced by its placeholder, and how many
+// spans of each kind were replaced.
+func (r *Redactor) Redact(s string) (string, Counts) {
+	counts := Counts{}
+	if r != nil {
+		for _, v := range r.values {
+			if n := strings.Count(s, v.value); n > 0 {
+				s = strings.ReplaceAll(s, v.value, Placeholder(v.name))
+				counts[KindEnv] += n
+			}
+		}
+	}
+	for _, d := range detectors {
+		s = d.redact(s, counts)
+	}
+	return s, counts
+}
+
+func (d *detector) redact(s string, counts Counts) string {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*d.group], m[2*d.group+1]
+		if start < 0 || d.check != nil && !d.check(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Placeholder(d.kind))
+		last = end
+		counts[d.kind]++
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
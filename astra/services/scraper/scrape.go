@@ -18,6 +18,9 @@ import (
 // Scraper struct to manage Playwright browser context
 type Scraper struct {
 	pw *playwright.Playwright
+	// OnPage, when set, is called as soon as a page of ReadMultiplePages is done,
+	// from the goroutine that read it.
+	OnPage func(types.ScrapeResult)
 }
 
 // NewScraper initializes Playwright
@@ -127,6 +130,9 @@ func (s *Scraper) ReadMultiplePages(urls []string, maxConcurrent int) ([]types.S
 		wg.Add(1)
 		go func(i int, url string) {
 			defer wg.Done()
+			if s.OnPage != nil {
+				defer func() { s.OnPage(results[i]) }()
+			}
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
@@ -0,0 +1,245 @@
+// Package worktree isolates an agent session in a temporary git worktree on its own
+// branch. Edits and verification happen there; the live working copy only changes when
+// the user merges the result.
+package worktree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// BranchPrefix is prepended to the session name to form the worktree branch.
+const BranchPrefix = "astra/"
+
+// gitIdentity lets commits succeed on machines without a configured git user.
+var gitIdentity = []string{"-c", "user.name=Astra", "-c", "user.email=astra@astra.local"}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Worktree is a checked-out branch of the user's repository in a separate directory.
+type Worktree struct {
+	RepoRoot string // Top level of the live working copy
+	Path     string // Top level of the worktree
+	Subdir   string // Session directory relative to RepoRoot ("." for the top level)
+	Branch   string
+	Base     string // Commit the session's changes are diffed against
+}
+
+// Create checks out a new branch for session name in a worktree under parent (default
+// <tmp>/astra-worktrees). Uncommitted and untracked changes of the working copy that
+// contains dir are carried over, so the agent starts from what the user sees.
+func Create(dir, name, parent string) (*Worktree, error) {
+	top, err := git(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository: %w", dir, err)
+	}
+	if _, err := git(top, nil, "rev-parse", "--verify", "HEAD"); err != nil {
+		return nil, fmt.Errorf("the repository at %s has no commits yet", top)
+	}
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	subdir, err := filepath.Rel(top, realDir)
+	if err != nil {
+		return nil, err
+	}
+
+	name = unsafeNameChars.ReplaceAllString(name, "-")
+	if parent == "" {
+		parent = filepath.Join(os.TempDir(), "astra-worktrees")
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, err
+	}
+	wt := &Worktree{
+		RepoRoot: top,
+		Path:     filepath.Join(parent, name),
+		Subdir:   subdir,
+		Branch:   BranchPrefix + name,
+	}
+	if _, err := git(top, nil, "worktree", "add", "-b", wt.Branch, wt.Path, "HEAD"); err != nil {
+		return nil, err
+	}
+	if err := wt.carryOver(); err != nil {
+		wt.Discard()
+		return nil, fmt.Errorf("copying uncommitted changes into the worktree: %w", err)
+	}
+	if wt.Base, err = git(wt.Path, nil, "rev-parse", "HEAD"); err != nil {
+		wt.Discard()
+		return nil, err
+	}
+	return wt, nil
+}
+
+// Workspace is the directory the session works in: the worktree counterpart of the
+// directory Create was called with.
+func (w *Worktree) Workspace() string {
+	return filepath.Join(w.Path, w.Subdir)
+}
+
+// carryOver applies the working copy's uncommitted changes and untracked files to the
+// worktree and commits them, so they are not part of the session's diff.
+func (w *Worktree) carryOver() error {
+	patch, err := git(w.RepoRoot, nil, "diff", "HEAD", "--binary")
+	if err != nil {
+		return err
+	}
+	if patch != "" {
+		if _, err := git(w.Path, []byte(patch+"\n"), "apply", "--binary"); err != nil {
+			return err
+		}
+	}
+	untracked, err := git(w.RepoRoot, nil, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return err
+	}
+	for _, rel := range strings.Split(untracked, "\x00") {
+		if rel == "" {
+			continue
+		}
+		if err := copyFile(filepath.Join(w.RepoRoot, rel), filepath.Join(w.Path, rel)); err != nil {
+			return err
+		}
+	}
+	_, err = w.commit("astra: uncommitted changes of the working copy")
+	return err
+}
+
+// Diff returns the session's changes, including new files, as a unified diff.
+func (w *Worktree) Diff() (string, error) {
+	if _, err := git(w.Path, nil, "add", "-A"); err != nil {
+		return "", err
+	}
+	return git(w.Path, nil, "diff", "--cached", w.Base)
+}
+
+// DiffStat summarizes the session's changes per file.
+func (w *Worktree) DiffStat() (string, error) {
+	if _, err := git(w.Path, nil, "add", "-A"); err != nil {
+		return "", err
+	}
+	return git(w.Path, nil, "diff", "--cached", "--stat", w.Base)
+}
+
+// Merge applies the session's changes to the live working copy as uncommitted changes
+// and removes the worktree and branch. When the changes do not apply cleanly, conflict
+// markers are left in the working copy and the branch is kept.
+func (w *Worktree) Merge() error {
+	if _, err := w.commit("astra: session changes"); err != nil {
+		return err
+	}
+	patch, err := git(w.Path, nil, "diff", "--binary", w.Base, "HEAD")
+	if err != nil {
+		return err
+	}
+	if patch != "" {
+		if _, err := git(w.RepoRoot, []byte(patch+"\n"), "apply", "--binary"); err != nil {
+			if _, err := git(w.RepoRoot, []byte(patch+"\n"), "apply", "--binary", "--3way"); err != nil {
+				w.removeWorktree()
+				return fmt.Errorf("the changes conflict with the working copy and were merged with conflict markers; they are kept on branch %s: %w", w.Branch, err)
+			}
+		}
+	}
+	return w.Discard()
+}
+
+// Keep commits the session's changes to the branch and removes the worktree.
+func (w *Worktree) Keep() error {
+	if _, err := w.commit("astra: session changes"); err != nil {
+		return err
+	}
+	return w.removeWorktree()
+}
+
+// Discard removes the worktree and deletes the branch.
+func (w *Worktree) Discard() error {
+	if err := w.removeWorktree(); err != nil {
+		return err
+	}
+	_, err := git(w.RepoRoot, nil, "branch", "-D", w.Branch)
+	return err
+}
+
+func (w *Worktree) removeWorktree() error {
+	if _, err := git(w.RepoRoot, nil, "worktree", "remove", "--force", w.Path); err != nil {
+		if _, statErr := os.Stat(w.Path); !os.IsNotExist(statErr) {
+			return err
+		}
+		_, err = git(w.RepoRoot, nil, "worktree", "prune")
+		return err
+	}
+	return nil
+}
+
+// commit records all changes in the worktree; it reports false when there were none.
+func (w *Worktree) commit(msg string) (bool, error) {
+	if _, err := git(w.Path, nil, "add", "-A"); err != nil {
+		return false, err
+	}
+	if _, err := git(w.Path, nil, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	args := append(append([]string{}, gitIdentity...), "commit", "--no-verify", "-q", "-m", msg)
+	if _, err := git(w.Path, nil, args...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// git runs git in dir and returns its trimmed stdout; stderr is included in errors.
+func git(dir string, stdin []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+		}
+		return "", errors.New("git " + strings.Join(args, " ") + ": " + msg)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,125 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if _, err := git(dir, nil, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "app", "main.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "demo\n")
+	if _, err := git(dir, nil, "add", "-A"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(dir, nil, "commit", "-q", "-m", "init"); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestWorktree_MergeAppliesOnlySessionChanges(t *testing.T) {
+	repo := initRepo(t)
+	// Uncommitted work of the user is carried over but not part of the session diff.
+	writeFile(t, filepath.Join(repo, "README.md"), "demo\nwip\n")
+	writeFile(t, filepath.Join(repo, "notes.txt"), "untracked\n")
+
+	wt, err := Create(filepath.Join(repo, "app"), "cli-test", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(wt.Path, "notes.txt")); got != "untracked\n" {
+		t.Fatalf("untracked file not carried over: %q", got)
+	}
+	writeFile(t, filepath.Join(wt.Workspace(), "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(wt.Workspace(), "util.go"), "package main\n")
+	if got := readFile(t, filepath.Join(repo, "app", "main.go")); got != "package main\n" {
+		t.Fatalf("live working copy changed before merge: %q", got)
+	}
+
+	diff, err := wt.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+func main() {}") || !strings.Contains(diff, "app/util.go") || strings.Contains(diff, "wip") {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+
+	if err := wt.Merge(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filepath.Join(repo, "app", "main.go")); !strings.Contains(got, "func main()") {
+		t.Errorf("merge did not apply the change: %q", got)
+	}
+	if got := readFile(t, filepath.Join(repo, "README.md")); got != "demo\nwip\n" {
+		t.Errorf("merge touched the user's uncommitted change: %q", got)
+	}
+	if _, err := git(repo, nil, "rev-parse", "--verify", wt.Branch); err == nil {
+		t.Errorf("branch %s still exists after merge", wt.Branch)
+	}
+	if _, err := os.Stat(wt.Path); !os.IsNotExist(err) {
+		t.Errorf("worktree %s still exists after merge", wt.Path)
+	}
+}
+
+func TestWorktree_KeepAndDiscard(t *testing.T) {
+	repo := initRepo(t)
+
+	kept, err := Create(repo, "keep", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(kept.Path, "README.md"), "changed\n")
+	if err := kept.Keep(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := git(repo, nil, "show", kept.Branch+":README.md"); err != nil || got != "changed" {
+		t.Errorf("kept branch does not have the change: %q, %v", got, err)
+	}
+
+	discarded, err := Create(repo, "discard", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(discarded.Path, "README.md"), "thrown away\n")
+	if err := discarded.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(repo, nil, "rev-parse", "--verify", discarded.Branch); err == nil {
+		t.Errorf("branch %s still exists after discard", discarded.Branch)
+	}
+	if got := readFile(t, filepath.Join(repo, "README.md")); got != "demo\n" {
+		t.Errorf("working copy changed: %q", got)
+	}
+}
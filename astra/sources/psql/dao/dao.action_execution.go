@@ -0,0 +1,137 @@
+// astra/sources/psql/dao/dao.action_execution.go
+package dao
+
+import (
+	"astra/astra/sources/psql/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrActionExecutionUser is returned by history queries without a user; history is
+// only ever read for one user.
+var ErrActionExecutionUser = errors.New("action executions: a user id is required")
+
+// ActionExecutionFilter narrows a history query. UserID is required; other zero fields
+// do not filter.
+type ActionExecutionFilter struct {
+	UserID    int
+	SessionID string
+	RunID     string
+	Actions   []string // Names or globs ("db_*")
+	Statuses  []string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// ActionExecutionCount is one row of CountActionExecutions.
+type ActionExecutionCount struct {
+	Action        string    `json:"action"`
+	Status        string    `json:"status"`
+	Count         int64     `json:"count"`
+	AvgDurationMs float64   `json:"avg_duration_ms"`
+	LastAt        time.Time `json:"last_at"`
+}
+
+type ActionExecutionDAO struct {
+	DB *gorm.DB
+}
+
+func NewActionExecutionDAO(db *gorm.DB) *ActionExecutionDAO {
+	return &ActionExecutionDAO{DB: db}
+}
+
+func (dao *ActionExecutionDAO) CreateActionExecution(ctx context.Context, exec *models.ActionExecution) error {
+	return dao.DB.WithContext(ctx).Create(exec).Error
+}
+
+// GetActionExecution returns the user's execution with id, or nil if there is none.
+func (dao *ActionExecutionDAO) GetActionExecution(ctx context.Context, userID int, id uuid.UUID) (*models.ActionExecution, error) {
+	if userID == 0 {
+		return nil, ErrActionExecutionUser
+	}
+	var exec models.ActionExecution
+	err := dao.DB.WithContext(ctx).Where("user_id = ?", userID).First(&exec, "id = ?", id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exec, nil
+}
+
+// ListActionExecutions returns the executions matching f, newest first.
+func (dao *ActionExecutionDAO) ListActionExecutions(ctx context.Context, f ActionExecutionFilter) ([]models.ActionExecution, error) {
+	if f.UserID == 0 {
+		return nil, ErrActionExecutionUser
+	}
+	q := dao.filtered(ctx, f).Order("created_at desc")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var execs []models.ActionExecution
+	if err := q.Find(&execs).Error; err != nil {
+		return nil, err
+	}
+	return execs, nil
+}
+
+// CountActionExecutions groups the executions matching f by action and status, most
+// frequent first; f.Limit caps the number of groups.
+func (dao *ActionExecutionDAO) CountActionExecutions(ctx context.Context, f ActionExecutionFilter) ([]ActionExecutionCount, error) {
+	if f.UserID == 0 {
+		return nil, ErrActionExecutionUser
+	}
+	q := dao.filtered(ctx, f).
+		Select("action, status, COUNT(*) AS count, AVG(duration_ms) AS avg_duration_ms, MAX(created_at) AS last_at").
+		Group("action, status").
+		Order("count desc, action")
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var counts []ActionExecutionCount
+	if err := q.Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (dao *ActionExecutionDAO) filtered(ctx context.Context, f ActionExecutionFilter) *gorm.DB {
+	q := dao.DB.WithContext(ctx).Model(&models.ActionExecution{}).Where("user_id = ?", f.UserID)
+	if f.SessionID != "" {
+		q = q.Where("session_id = ?", f.SessionID)
+	}
+	if f.RunID != "" {
+		q = q.Where("run_id = ?", f.RunID)
+	}
+	if len(f.Actions) > 0 {
+		var conds []string
+		var args []interface{}
+		for _, a := range f.Actions {
+			conds = append(conds, `action LIKE ? ESCAPE '\'`)
+			args = append(args, globToLike(a))
+		}
+		q = q.Where("("+strings.Join(conds, " OR ")+")", args...)
+	}
+	if len(f.Statuses) > 0 {
+		q = q.Where("status IN ?", f.Statuses)
+	}
+	if !f.Since.IsZero() {
+		q = q.Where("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		q = q.Where("created_at < ?", f.Until)
+	}
+	return q
+}
+
+// globToLike turns a * / ? glob into a LIKE pattern matching it literally otherwise.
+func globToLike(glob string) string {
+	return strings.NewReplacer("*", "%", "?", "_").Replace(likeEscaper.Replace(glob))
+}
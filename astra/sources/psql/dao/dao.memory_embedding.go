@@ -0,0 +1,99 @@
+// astra/sources/psql/dao/dao.memory_embedding.go
+package dao
+
+import (
+	"astra/astra/sources/psql/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// memorySourceTables maps a memory source type to its table and the SQL expression of
+// the text that gets embedded.
+var memorySourceTables = map[string]struct{ table, text string }{
+	models.MemorySourceKnowledge: {"long_term_knowledge", "src.knowledge_type || ': ' || src.knowledge_blob"},
+	models.MemorySourceNote:      {"notes", "concat_ws(E'\\n', NULLIF(src.title, ''), src.content)"},
+	models.MemorySourceSession:   {"session_summaries", "src.summary"},
+}
+
+// MemorySource is a row that needs (re-)embedding.
+type MemorySource struct {
+	ID        uuid.UUID
+	UserID    int
+	UpdatedAt time.Time
+	Text      string
+}
+
+type MemoryEmbeddingDAO struct {
+	DB *gorm.DB
+}
+
+func NewMemoryEmbeddingDAO(db *gorm.DB) *MemoryEmbeddingDAO {
+	return &MemoryEmbeddingDAO{DB: db}
+}
+
+// UpsertMemoryEmbedding stores e, replacing any earlier embedding of the same source.
+func (dao *MemoryEmbeddingDAO) UpsertMemoryEmbedding(ctx context.Context, e *models.MemoryEmbedding) error {
+	return dao.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source_type"}, {Name: "source_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "source_updated_at", "model", "embedding", "updated_at"}),
+	}).Create(e).Error
+}
+
+// ListMemoryEmbeddingsByUser returns the user's embeddings made with model, limited to
+// sourceTypes when given.
+func (dao *MemoryEmbeddingDAO) ListMemoryEmbeddingsByUser(ctx context.Context, userID int, model string, sourceTypes []string) ([]models.MemoryEmbedding, error) {
+	var embeddings []models.MemoryEmbedding
+	db := dao.DB.WithContext(ctx).Where("user_id = ? AND model = ?", userID, model)
+	if len(sourceTypes) > 0 {
+		db = db.Where("source_type IN ?", sourceTypes)
+	}
+	if err := db.Find(&embeddings).Error; err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// FindStaleMemorySources returns up to limit rows of sourceType that have no embedding
+// made with model, or changed since they were embedded. userID 0 means all users.
+func (dao *MemoryEmbeddingDAO) FindStaleMemorySources(ctx context.Context, sourceType, model string, userID, limit int) ([]MemorySource, error) {
+	src, ok := memorySourceTables[sourceType]
+	if !ok {
+		return nil, fmt.Errorf("unknown memory source type %q", sourceType)
+	}
+	query := fmt.Sprintf(`
+		SELECT src.id, src.user_id, COALESCE(src.updated_at, src.created_at) AS updated_at, %s AS text
+		FROM %s src
+		LEFT JOIN memory_embeddings e ON e.source_type = ? AND e.source_id = src.id
+		WHERE (e.id IS NULL OR e.model <> ? OR e.source_updated_at < COALESCE(src.updated_at, src.created_at))
+		  AND (? = 0 OR src.user_id = ?)
+		ORDER BY src.user_id, src.id
+		LIMIT ?`, src.text, src.table)
+
+	var sources []MemorySource
+	err := dao.DB.WithContext(ctx).Raw(query, sourceType, model, userID, userID, limit).Scan(&sources).Error
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// DeleteOrphanMemoryEmbeddings removes embeddings whose source row no longer exists.
+func (dao *MemoryEmbeddingDAO) DeleteOrphanMemoryEmbeddings(ctx context.Context) (int64, error) {
+	var deleted int64
+	for sourceType, src := range memorySourceTables {
+		res := dao.DB.WithContext(ctx).Exec(fmt.Sprintf(`
+			DELETE FROM memory_embeddings e
+			WHERE e.source_type = ? AND NOT EXISTS (SELECT 1 FROM %s src WHERE src.id = e.source_id)`, src.table),
+			sourceType)
+		if res.Error != nil {
+			return deleted, res.Error
+		}
+		deleted += res.RowsAffected
+	}
+	return deleted, nil
+}
@@ -4,11 +4,15 @@ package dao
 import (
 	"astra/astra/sources/psql/models"
 	"context"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// likeEscaper escapes LIKE wildcards so search words match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type NoteDAO struct {
 	DB *gorm.DB
 }
@@ -45,6 +49,27 @@ func (dao *NoteDAO) GetAllNotesByUser(ctx context.Context, userID int) ([]models
 	return notes, nil
 }
 
+// SearchNotesByUser returns the user's notes whose title or content contains every word
+// of query (case-insensitive), favourites first. An empty query matches all notes.
+func (dao *NoteDAO) SearchNotesByUser(ctx context.Context, userID int, query string, favouritesOnly bool, limit int) ([]models.Note, error) {
+	q := dao.DB.WithContext(ctx).Where("user_id = ?", userID)
+	for _, word := range strings.Fields(query) {
+		pattern := "%" + likeEscaper.Replace(word) + "%"
+		q = q.Where("(title ILIKE ? OR content ILIKE ?)", pattern, pattern)
+	}
+	if favouritesOnly {
+		q = q.Where("favourite = ?", true)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var notes []models.Note
+	if err := q.Order("favourite desc, updated_at desc").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
 func (dao *NoteDAO) UpdateNote(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
 	return dao.DB.WithContext(ctx).Model(&models.Note{}).Where("id = ?", id).Updates(updates).Error
 }
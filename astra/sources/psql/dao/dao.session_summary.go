@@ -5,6 +5,7 @@ import (
 	"astra/astra/sources/psql/models"
 	"context"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -76,3 +77,16 @@ func (dao *SessionSummaryDAO) ListRecentSessionSummaries(ctx context.Context, us
 	}
 	return summaries, nil
 }
+
+// GetSessionSummaryByID retrieves a session summary by its ID. Returns nil if not found.
+func (dao *SessionSummaryDAO) GetSessionSummaryByID(ctx context.Context, id uuid.UUID) (*models.SessionSummary, error) {
+	var ss models.SessionSummary
+	err := dao.DB.WithContext(ctx).First(&ss, "id = ?", id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ss, nil
+}
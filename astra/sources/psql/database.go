@@ -46,6 +46,8 @@ func NewDatabase(ctx context.Context, cfg config.Config) (*Database, error) {
 			&models.LongTermKnowledge{},
 			&models.Note{},
 			&models.SessionSummary{},
+			&models.MemoryEmbedding{},
+			&models.ActionExecution{},
 		)
 	fmt.Println("err in migrate", err)
 	if err != nil {
@@ -0,0 +1,45 @@
+// astra/sources/psql/models/action_execution.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outcomes of an action execution.
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionFailed    = "failed"
+	ExecutionDenied    = "denied" // Refused by the action policy or an approval
+)
+
+// ActionExecution is the durable record of one action call made by an agent.
+type ActionExecution struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	RunID      string          `json:"run_id" gorm:"type:varchar(64);index;default:''"` // One processed query; empty for MCP calls
+	SessionID  string          `json:"session_id" gorm:"type:varchar(255);index;default:''"`
+	UserID     int             `json:"user_id" gorm:"not null;index"`
+	User       User            `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Agent      string          `json:"agent" gorm:"type:varchar(255);default:''"`
+	Channel    string          `json:"channel" gorm:"type:varchar(20);default:''"`
+	Action     string          `json:"action" gorm:"type:varchar(255);not null;index"`
+	Params     json.RawMessage `json:"params,omitempty" gorm:"type:jsonb"` // As validated, with defaults filled in
+	Result     json.RawMessage `json:"result,omitempty" gorm:"type:jsonb"`
+	Error      string          `json:"error,omitempty" gorm:"type:text;default:''"`
+	Status     string          `json:"status" gorm:"type:varchar(20);not null;index"`
+	DurationMs int64           `json:"duration_ms" gorm:"not null;default:0"`
+	Approval   string          `json:"approval,omitempty" gorm:"type:varchar(20);default:''"`  // approved or rejected when the call asked for one
+	Approver   string          `json:"approver,omitempty" gorm:"type:varchar(255);default:''"` // Who decided the approval
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (ActionExecution) TableName() string {
+	return "action_executions"
+}
+
+func (e *ActionExecution) BeforeCreate(tx *gorm.DB) (err error) {
+	return tx.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`).Error
+}
@@ -14,7 +14,11 @@ type LongTermKnowledge struct {
 	User          User      `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 	KnowledgeType string    `json:"knowledge_type" gorm:"type:varchar(255);not null"`
 	KnowledgeBlob string    `json:"knowledge_blob" gorm:"type:text;not null"`
+	SessionID     string    `json:"session_id" gorm:"type:varchar(255);not null;default:''"` // Chat session the entry was learned in
+	Source        string    `json:"source" gorm:"type:text;not null;default:''"`             // URL or file the knowledge came from
+	Confidence    float64   `json:"confidence" gorm:"not null;default:1"`                    // 0..1; zero on create means 1
 	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"not null;default:now();autoUpdateTime"`
 }
 
 func (LongTermKnowledge) TableName() string {
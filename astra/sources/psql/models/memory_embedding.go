@@ -0,0 +1,88 @@
+// astra/sources/psql/models/memory_embedding.go
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Memory sources that get embedded for semantic search.
+const (
+	MemorySourceKnowledge = "knowledge"
+	MemorySourceNote      = "note"
+	MemorySourceSession   = "session"
+)
+
+// MemoryEmbedding is the embedding of one knowledge entry, note or session summary.
+type MemoryEmbedding struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID          int       `json:"user_id" gorm:"not null;index"`
+	User            User      `json:"-" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	SourceType      string    `json:"source_type" gorm:"type:varchar(32);not null;uniqueIndex:idx_memory_embedding_source"`
+	SourceID        uuid.UUID `json:"source_id" gorm:"type:uuid;not null;uniqueIndex:idx_memory_embedding_source"`
+	SourceUpdatedAt time.Time `json:"source_updated_at" gorm:"not null"` // updated_at of the source when it was embedded
+	Model           string    `json:"model" gorm:"type:varchar(255);not null"`
+	Embedding       Vector    `json:"-" gorm:"type:real[];not null"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (MemoryEmbedding) TableName() string {
+	return "memory_embeddings"
+}
+
+func (e *MemoryEmbedding) BeforeCreate(tx *gorm.DB) (err error) {
+	return tx.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`).Error
+}
+
+// Vector is stored as a Postgres real[] column.
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(float64(f), 'g', -1, 32)
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+func (v *Vector) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into Vector", src)
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return fmt.Errorf("invalid real[] literal %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return fmt.Errorf("invalid real[] element %q: %w", p, err)
+		}
+		out[i] = float32(f)
+	}
+	*v = out
+	return nil
+}
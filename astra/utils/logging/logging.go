@@ -15,6 +15,7 @@ var (
 	RequestLogger *zap.Logger
 	TimerLogger   *zap.Logger
 	ErrorLogger   *zap.Logger
+	AuditLogger   *zap.Logger
 )
 
 // ensureLogsDir makes sure the ./logs folder exists
@@ -66,6 +67,15 @@ func InitLogger() {
 		zap.ErrorLevel,
 	)
 	ErrorLogger = zap.New(errorCore)
+
+	// audit.log (policy decisions on agent actions)
+	auditCore := zapcore.NewCore(encoder,
+		zapcore.AddSync(&lumberjack.Logger{
+			Filename: "./logs/audit.log", MaxSize: 100, MaxAge: 90, Compress: true,
+		}),
+		zap.InfoLevel,
+	)
+	AuditLogger = zap.New(auditCore)
 }
 
 // LogDuration lets you do: defer logging.LogDuration(ctx, "FuncName")()
@@ -1,8 +1,9 @@
 /* eslint-disable @typescript-eslint/no-explicit-any */
 // ThoughtProcessPanel shows Astra's reasoning and intermediate steps.
-import React, { useState } from "react";
+import React, { useEffect, useRef, useState } from "react";
 import { FaBars, FaChevronRight } from "react-icons/fa";
 import RenderYamlView from "./RenderYamlView";
+import type { ActionLog } from "../hooks/useAstraChat";
 
 interface IntermediateMessage {
   text: string;
@@ -11,6 +12,7 @@ interface IntermediateMessage {
 
 interface ThoughtProcessPanelProps {
   thoughts: IntermediateMessage[];
+  actionLog?: ActionLog | null;
 }
 
 const isJsonString = (str: string): boolean => {
@@ -40,7 +42,43 @@ const parseMaybeJson = (input: any): any => {
   }
 };
 
-const ThoughtProcessPanel: React.FC<ThoughtProcessPanelProps> = ({ thoughts }) => {
+// ActionLogView shows the live output and progress of the running action.
+const ActionLogView: React.FC<{ log: ActionLog }> = ({ log }) => {
+  const endRef = useRef<HTMLDivElement>(null);
+  useEffect(() => {
+    endRef.current?.scrollIntoView({ block: "nearest" });
+  }, [log.lines.length]);
+
+  const { progress } = log;
+  return (
+    <div className="action-log">
+      <div className="action-log-header">
+        <span className="action-log-name">{log.action}</span>
+        {progress?.percent !== undefined && (
+          <span className="action-log-percent">{Math.round(progress.percent)}%</span>
+        )}
+      </div>
+      {progress?.percent !== undefined && (
+        <div className="action-log-bar">
+          <div style={{ width: `${progress.percent}%` }} />
+        </div>
+      )}
+      {progress?.item && (
+        <div className={`action-log-item ${progress.status ?? ""}`}>
+          {progress.item} {progress.status} {progress.message}
+        </div>
+      )}
+      {log.lines.length > 0 && (
+        <pre className="action-log-lines">
+          {log.lines.join("\n")}
+          <div ref={endRef} />
+        </pre>
+      )}
+    </div>
+  );
+};
+
+const ThoughtProcessPanel: React.FC<ThoughtProcessPanelProps> = ({ thoughts, actionLog }) => {
   const [expanded, setExpanded] = useState(true);
 
   return (
@@ -56,6 +94,8 @@ const ThoughtProcessPanel: React.FC<ThoughtProcessPanelProps> = ({ thoughts }) =
         </button>
       </div>
 
+      {expanded && actionLog && <ActionLogView log={actionLog} />}
+
       {expanded ? (
         <div className="thought-messages">
           {thoughts.length === 0 ? (
@@ -19,6 +19,22 @@ export interface IntermediateMessage {
   timestamp: string;
 }
 
+export interface ActionProgress {
+  percent?: number;
+  item?: string;
+  status?: string;
+  message?: string;
+}
+
+// ActionLog is the live output of the action that is currently running.
+export interface ActionLog {
+  action: string;
+  lines: string[];
+  progress?: ActionProgress;
+}
+
+const MAX_ACTION_LOG_LINES = 500;
+
 export interface ChatSessionSummary {
   session_id: string;
   last_message: string;
@@ -38,6 +54,7 @@ export function useAstraChat({ token, userId }: UseAstraChatParams) {
   const [messages, setMessages] = useState<Message[]>([]);
   const [isLoadingMessages, setIsLoadingMessages] = useState(false);
   const [intermediateMessages, setIntermediateMessages] = useState<IntermediateMessage[]>([]);
+  const [actionLog, setActionLog] = useState<ActionLog | null>(null);
   const [input, setInput] = useState("");
   const [sessionId, setSessionId] = useState<string>("");
   const [isConnected, setIsConnected] = useState(false);
@@ -148,6 +165,7 @@ export function useAstraChat({ token, userId }: UseAstraChatParams) {
       setSessionId(sid);
     }
     setIntermediateMessages([]);
+    setActionLog(null);
   };
 
   // WebSocket Operations
@@ -175,6 +193,30 @@ export function useAstraChat({ token, userId }: UseAstraChatParams) {
         const { type, payload } = msg;
         if (type === "session_created") {
           return;
+        } else if (type === "approval_required") {
+          const params = payload?.params ? `\n\n${JSON.stringify(payload.params, null, 2)}` : "";
+          const approved = window.confirm(`Astra wants to run "${payload?.action}".\n${payload?.reason ?? ""}${params}\n\nApprove?`);
+          ws.current?.send(JSON.stringify({ approval: { id: payload?.approval_id, approved } }));
+          setIntermediateMessages((prev) => [
+            ...prev,
+            { text: `Approval ${approved ? "granted" : "denied"}: ${payload?.action}`, timestamp: getCurrentTime() },
+          ]);
+        } else if (type === "action_output") {
+          setActionLog((prev) => {
+            const log = prev && prev.action === payload?.action ? prev : { action: payload?.action ?? "", lines: [] };
+            if (payload?.line || (payload?.message && !payload?.status)) {
+              const line = payload.line ?? payload.message;
+              return { ...log, lines: [...log.lines, line].slice(-MAX_ACTION_LOG_LINES) };
+            }
+            const { percent, item, status, message } = payload ?? {};
+            return { ...log, progress: { percent, item, status, message } };
+          });
+        } else if (type === "untrusted_content") {
+          const flags = (payload?.injection_flags ?? []).map((f: any) => `\n  ${f.pattern}: "${f.excerpt}"`).join("");
+          setIntermediateMessages((prev) => [
+            ...prev,
+            { text: `Warning: ${payload?.message ?? "untrusted content entered the run"}${flags}`, timestamp: getCurrentTime() },
+          ]);
         } else if (type === "response_chunk") {
           const chunk = typeof payload === "object" && payload.chunk ? payload.chunk : JSON.stringify(payload);
           messageBuffer.current.push(chunk);
@@ -387,6 +429,7 @@ export function useAstraChat({ token, userId }: UseAstraChatParams) {
     messages,
     isLoadingMessages,
     intermediateMessages,
+    actionLog,
     input, setInput,
     sendMessage,
     sendMessageDirect,
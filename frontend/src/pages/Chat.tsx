@@ -21,6 +21,7 @@ const Chat: React.FC<ChatProps> = ({ token, userId }) => {
     messages,
     isLoadingMessages,
     intermediateMessages,
+    actionLog,
     input, setInput,
     sendMessage,
     sendMessageDirect,
@@ -64,7 +65,7 @@ const Chat: React.FC<ChatProps> = ({ token, userId }) => {
         isLoadingMessages={isLoadingMessages}
       />
       {/* <ResizableThoughtPanel> */}
-        <ThoughtProcessPanel thoughts={intermediateMessages} />
+        <ThoughtProcessPanel thoughts={intermediateMessages} actionLog={actionLog} />
       {/* </ResizableThoughtPanel> */}
     </div>
   );